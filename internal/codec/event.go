@@ -31,6 +31,16 @@ func EncodeEvent(event events.Event) []byte {
 		encodedEvent = encodeUpdateDBVersion(event)
 	case *events.FreePages:
 		encodedEvent = encodeFreePages(event)
+	case *events.UpdateSequence:
+		encodedEvent = encodeUpdateSequence(event)
+	case *events.TruncateTable:
+		encodedEvent = encodeTruncateTable(event)
+	case *events.RenameTable:
+		encodedEvent = encodeRenameTable(event)
+	case *events.ReclaimTable:
+		encodedEvent = encodeReclaimTable(event)
+	case *events.PurgeTable:
+		encodedEvent = encodePurgeTable(event)
 	default:
 		panic("EncodeEvent: unknown event type")
 	}
@@ -51,6 +61,10 @@ func DecodeEvent(data []byte) (event events.Event, err error) {
 	encodedEvent := data[2:]
 
 	switch eventType {
+	case events.START_TRANSACTION_EVENT:
+		event = decodeStartTransaction(encodedEvent)
+	case events.COMMIT_TRANSACTION_EVENT:
+		event = decodeCommitTransaction(encodedEvent)
 	case events.CREATE_TABLE_EVENT:
 		event = decodeCreateTable(encodedEvent)
 	case events.UPDATE_TABLE_EVENT:
@@ -67,11 +81,21 @@ func DecodeEvent(data []byte) (event events.Event, err error) {
 		event = decodeUpdateDBVersion(encodedEvent)
 	case events.FREE_PAGES_EVENT:
 		event = decodeFreePages(encodedEvent)
+	case events.UPDATE_SEQUENCE_EVENT:
+		event = decodeUpdateSequence(encodedEvent)
+	case events.TRUNCATE_TABLE_EVENT:
+		event = decodeTruncateTable(encodedEvent)
+	case events.RENAME_TABLE_EVENT:
+		event = decodeRenameTable(encodedEvent)
+	case events.RECLAIM_TABLE_EVENT:
+		event = decodeReclaimTable(encodedEvent)
+	case events.PURGE_TABLE_EVENT:
+		event = decodePurgeTable(encodedEvent)
 	default:
-		panic("DecodeEvent: unknown event type")
+		err = fmt.Errorf("DecodeEvent: unknown event type %d", eventType)
 	}
 
-	return event, nil
+	return
 }
 
 func encodeStartTransaction(_ *events.StartTransaction) []byte {
@@ -287,3 +311,81 @@ func decodeFreePages(data []byte) *events.FreePages {
 	}
 	return events.NewFreePages(version, pager.NewPageList(pages...))
 }
+
+func encodeUpdateSequence(event *events.UpdateSequence) []byte {
+	out := make([]byte, 24)
+
+	binary.LittleEndian.PutUint64(out[0:8], event.TableID)
+	binary.LittleEndian.PutUint64(out[8:16], event.OldValue)
+	binary.LittleEndian.PutUint64(out[16:24], event.NewValue)
+
+	return out
+}
+
+func decodeUpdateSequence(data []byte) *events.UpdateSequence {
+	tableID := binary.LittleEndian.Uint64(data[0:8])
+	oldValue := binary.LittleEndian.Uint64(data[8:16])
+	newValue := binary.LittleEndian.Uint64(data[16:24])
+
+	return events.NewUpdateSequence(tableID, oldValue, newValue)
+}
+
+func encodeTruncateTable(event *events.TruncateTable) []byte {
+	out := make([]byte, 16)
+
+	binary.LittleEndian.PutUint64(out[0:8], event.TableID)
+	binary.LittleEndian.PutUint64(out[8:16], event.Root)
+
+	return out
+}
+
+func decodeTruncateTable(data []byte) *events.TruncateTable {
+	tableID := binary.LittleEndian.Uint64(data[0:8])
+	root := binary.LittleEndian.Uint64(data[8:16])
+
+	return events.NewTruncateTable(tableID, root)
+}
+
+func encodeRenameTable(event *events.RenameTable) []byte {
+	out := make([]byte, 16)
+
+	binary.LittleEndian.PutUint64(out[0:8], event.TableID)
+	binary.LittleEndian.PutUint64(out[8:16], uint64(len(event.OldName)))
+
+	out = append(out, event.OldName...)
+
+	return append(out, event.NewName...)
+}
+
+func decodeRenameTable(data []byte) *events.RenameTable {
+	tableID := binary.LittleEndian.Uint64(data[0:8])
+	oldNameLength := int(binary.LittleEndian.Uint64(data[8:16]))
+
+	return events.NewRenameTable(tableID, string(data[16:16+oldNameLength]), string(data[16+oldNameLength:]))
+}
+
+func encodeReclaimTable(event *events.ReclaimTable) []byte {
+	out := make([]byte, 8)
+
+	binary.LittleEndian.PutUint64(out, event.TableID)
+
+	return out
+}
+
+func decodeReclaimTable(data []byte) *events.ReclaimTable {
+	tableID := binary.LittleEndian.Uint64(data[:8])
+	return events.NewReclaimTable(tableID)
+}
+
+func encodePurgeTable(event *events.PurgeTable) []byte {
+	out := make([]byte, 8)
+
+	binary.LittleEndian.PutUint64(out, event.TableID)
+
+	return out
+}
+
+func decodePurgeTable(data []byte) *events.PurgeTable {
+	tableID := binary.LittleEndian.Uint64(data[:8])
+	return events.NewPurgeTable(tableID)
+}
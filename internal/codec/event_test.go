@@ -0,0 +1,65 @@
+package codec
+
+import (
+	"distributed-storage/internal/events"
+	"testing"
+)
+
+func TestEncodeEvent_UpdateSequence_RoundTrip(t *testing.T) {
+	decoded, err := DecodeEvent(EncodeEvent(events.NewUpdateSequence(7, 41, 42)))
+	if err != nil {
+		t.Fatalf("DecodeEvent failed: %v", err)
+	}
+
+	event, ok := decoded.(*events.UpdateSequence)
+	if !ok {
+		t.Fatalf("expected UpdateSequence, got %T", decoded)
+	}
+	if event.TableID != 7 || event.OldValue != 41 || event.NewValue != 42 {
+		t.Errorf("unexpected event %+v", event)
+	}
+}
+
+func TestEncodeEvent_TruncateTable_RoundTrip(t *testing.T) {
+	decoded, err := DecodeEvent(EncodeEvent(events.NewTruncateTable(7, 300)))
+	if err != nil {
+		t.Fatalf("DecodeEvent failed: %v", err)
+	}
+
+	if event, ok := decoded.(*events.TruncateTable); !ok || event.TableID != 7 || event.Root != 300 {
+		t.Errorf("unexpected event %+v", decoded)
+	}
+}
+
+func TestEncodeEvent_RenameTable_RoundTrip(t *testing.T) {
+	decoded, err := DecodeEvent(EncodeEvent(events.NewRenameTable(7, "orders", "archived_orders")))
+	if err != nil {
+		t.Fatalf("DecodeEvent failed: %v", err)
+	}
+
+	event, ok := decoded.(*events.RenameTable)
+	if !ok {
+		t.Fatalf("expected RenameTable, got %T", decoded)
+	}
+	if event.TableID != 7 || event.OldName != "orders" || event.NewName != "archived_orders" {
+		t.Errorf("unexpected event %+v", event)
+	}
+}
+
+func TestEncodeEvent_ReclaimAndPurgeTable_RoundTrip(t *testing.T) {
+	decoded, err := DecodeEvent(EncodeEvent(events.NewReclaimTable(7)))
+	if err != nil {
+		t.Fatalf("DecodeEvent failed: %v", err)
+	}
+	if event, ok := decoded.(*events.ReclaimTable); !ok || event.TableID != 7 {
+		t.Errorf("unexpected event %+v", decoded)
+	}
+
+	decoded, err = DecodeEvent(EncodeEvent(events.NewPurgeTable(8)))
+	if err != nil {
+		t.Fatalf("DecodeEvent failed: %v", err)
+	}
+	if event, ok := decoded.(*events.PurgeTable); !ok || event.TableID != 8 {
+		t.Errorf("unexpected event %+v", decoded)
+	}
+}
@@ -5,6 +5,11 @@ import (
 	"distributed-storage/internal/primitive"
 	"encoding/binary"
 	"fmt"
+	"maps"
+	"math"
+	"math/big"
+	"slices"
+	"strings"
 )
 
 func EncodeValue(value primitive.Primitive) []byte {
@@ -24,6 +29,24 @@ func EncodeValue(value primitive.Primitive) []byte {
 		encodedValue = append(encodedValue, encodeUint64(value)...)
 	case *primitive.String:
 		encodedValue = append(encodedValue, encodeString(value)...)
+	case *primitive.Bool:
+		encodedValue = append(encodedValue, encodeBool(value)...)
+	case *primitive.Float64:
+		encodedValue = append(encodedValue, encodeFloat64(value)...)
+	case *primitive.Bytes:
+		encodedValue = append(encodedValue, encodeBytes(value)...)
+	case *primitive.Timestamp:
+		encodedValue = append(encodedValue, encodeTimestamp(value)...)
+	case *primitive.Date:
+		encodedValue = append(encodedValue, encodeDate(value)...)
+	case *primitive.Array:
+		encodedValue = append(encodedValue, encodeArray(value)...)
+	case *primitive.Object:
+		encodedValue = append(encodedValue, encodeObject(value)...)
+	case *primitive.Decimal:
+		encodedValue = append(encodedValue, encodeDecimal(value)...)
+	case *primitive.UUID:
+		encodedValue = append(encodedValue, encodeUUID(value)...)
 	default:
 		panic(fmt.Sprintf("EncodeValue: couldn't encode value because of wrong type %d", valueType))
 	}
@@ -52,10 +75,32 @@ func DecodeValue(data []byte) (value primitive.Primitive, offset int, err error)
 		value, offset = decodeUint64(data)
 	case primitive.TYPE_STRING:
 		value, offset = decodeString(data)
+	case primitive.TYPE_BOOL:
+		value, offset = decodeBool(data)
+	case primitive.TYPE_FLOAT64:
+		value, offset = decodeFloat64(data)
+	case primitive.TYPE_BYTES:
+		value, offset = decodeBytes(data)
+	case primitive.TYPE_TIMESTAMP:
+		value, offset = decodeTimestamp(data)
+	case primitive.TYPE_DATE:
+		value, offset = decodeDate(data)
+	case primitive.TYPE_ARRAY:
+		value, offset, err = decodeArray(data)
+	case primitive.TYPE_OBJECT:
+		value, offset, err = decodeObject(data)
+	case primitive.TYPE_DECIMAL:
+		value, offset, err = decodeDecimal(data)
+	case primitive.TYPE_UUID:
+		value, offset = decodeUUID(data)
 	default:
 		panic(fmt.Sprintf("DecodeValue: couldn't parse value because of wrong type %d", valueType))
 	}
 
+	if err != nil {
+		return nil, 0, err
+	}
+
 	return value, offset + 1, nil // one byte is added for value type
 }
 
@@ -111,8 +156,298 @@ func decodeUint64(data []byte) (*primitive.Uint64, int) {
 	return primitive.NewUint64(binary.BigEndian.Uint64(data)), 8
 }
 
+func encodeBool(val *primitive.Bool) []byte {
+	if val.Value() {
+		return []byte{1}
+	}
+
+	return []byte{0}
+}
+
+func decodeBool(data []byte) (*primitive.Bool, int) {
+	return primitive.NewBool(data[0] != 0), 1
+}
+
+// encodeFloat64 maps IEEE 754 bits to unsigned integer with the same order: sign bit is flipped for positive numbers
+// and all bits are flipped for negative ones. Canonical bits are used, so -0 and +0 share a key and NaN sorts after +Inf.
+func encodeFloat64(val *primitive.Float64) []byte {
+	out := make([]byte, 8)
+	bits := val.Bits()
+
+	if bits&(1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits |= 1 << 63
+	}
+
+	binary.BigEndian.PutUint64(out, bits)
+	return out
+}
+
+func decodeFloat64(data []byte) (*primitive.Float64, int) {
+	bits := binary.BigEndian.Uint64(data)
+
+	if bits&(1<<63) != 0 {
+		bits &^= 1 << 63
+	} else {
+		bits = ^bits
+	}
+
+	return primitive.NewFloat64(math.Float64frombits(bits)), 8
+}
+
+// encodeTimestamp orders timestamps by instant first, so range scans don't depend on time zones.
+// Timestamps of the same instant are ordered by zone: not zoned first and then by offset.
+func encodeTimestamp(val *primitive.Timestamp) []byte {
+	out := make([]byte, 17)
+
+	binary.BigEndian.PutUint64(out[0:8], uint64(val.Seconds())+(1<<63))
+	binary.BigEndian.PutUint32(out[8:12], val.Nanos())
+	if val.Zoned() {
+		out[12] = 1
+	}
+	binary.BigEndian.PutUint32(out[13:17], uint32(val.Offset())+(1<<31))
+
+	return out
+}
+
+func decodeTimestamp(data []byte) (*primitive.Timestamp, int) {
+	seconds := int64(binary.BigEndian.Uint64(data[0:8]) + (1 << 63))
+	nanos := binary.BigEndian.Uint32(data[8:12])
+	zoned := data[12] != 0
+	offset := int32(binary.BigEndian.Uint32(data[13:17]) + (1 << 31))
+
+	return primitive.NewTimestampFromParts(seconds, nanos, zoned, offset), 17
+}
+
+func encodeDate(val *primitive.Date) []byte {
+	out := make([]byte, 4)
+
+	binary.BigEndian.PutUint32(out, uint32(val.Days())+(1<<31))
+	return out
+}
+
+func decodeDate(data []byte) (*primitive.Date, int) {
+	return primitive.NewDateFromDays(int32(binary.BigEndian.Uint32(data) + (1 << 31))), 4
+}
+
+// encodeUUID writes UUID bytes as is, so UUIDv7 keys are ordered by generation time
+func encodeUUID(val *primitive.UUID) []byte {
+	id := val.Value()
+
+	return id[:]
+}
+
+func decodeUUID(data []byte) (*primitive.UUID, int) {
+	return primitive.NewUUID([primitive.UUID_SIZE]byte(data[:primitive.UUID_SIZE])), primitive.UUID_SIZE
+}
+
+const (
+	DECIMAL_NEGATIVE = 0x01
+	DECIMAL_ZERO     = 0x02
+	DECIMAL_POSITIVE = 0x03
+)
+
+// encodeDecimal writes sign marker, exponent and significant digits of the number written as 0.ddd * 10^exponent,
+// so decimals are ordered numerically regardless of their scale. Negative numbers have exponent and digits inverted.
+// Trailing zeros aren't written, so numerically equal decimals such as 1.0 and 1.00 share the encoding and are decoded
+// with the least non-negative scale keeping their value.
+func encodeDecimal(val *primitive.Decimal) []byte {
+	out := make([]byte, 0, 16)
+	unscaled := val.Unscaled()
+
+	if unscaled.Sign() == 0 {
+		return append(out, DECIMAL_ZERO)
+	}
+
+	allDigits := unscaled.Abs(unscaled).String()
+	digits := strings.TrimRight(allDigits, "0")
+	exponent := uint64(int64(len(allDigits))-int64(val.Scale())) + (1 << 63)
+
+	if val.Sign() > 0 {
+		out = append(out, DECIMAL_POSITIVE)
+		out = binary.BigEndian.AppendUint64(out, exponent)
+		for _, digit := range []byte(digits) {
+			out = append(out, digit-'0'+1)
+		}
+		return append(out, 0x00)
+	}
+
+	out = append(out, DECIMAL_NEGATIVE)
+	out = binary.BigEndian.AppendUint64(out, ^exponent)
+	for _, digit := range []byte(digits) {
+		out = append(out, 0xFF-(digit-'0'+1))
+	}
+	return append(out, 0xFF)
+}
+
+func decodeDecimal(data []byte) (*primitive.Decimal, int, error) {
+	if len(data) == 0 {
+		return nil, 0, fmt.Errorf("DecodeValue: decimal is empty")
+	}
+
+	marker := data[0]
+	offset := 1
+
+	if marker == DECIMAL_ZERO {
+		return primitive.NewDecimal(new(big.Int), 0), offset, nil
+	}
+
+	if marker != DECIMAL_NEGATIVE && marker != DECIMAL_POSITIVE {
+		return nil, 0, fmt.Errorf("DecodeValue: wrong decimal sign marker %d", marker)
+	}
+
+	if len(data) < offset+8 {
+		return nil, 0, fmt.Errorf("DecodeValue: decimal is truncated")
+	}
+
+	encodedExponent := binary.BigEndian.Uint64(data[offset:])
+	if marker == DECIMAL_NEGATIVE {
+		encodedExponent = ^encodedExponent
+	}
+	exponent := int64(encodedExponent - (1 << 63))
+	offset += 8
+
+	var digits []byte
+	for {
+		if offset >= len(data) {
+			return nil, 0, fmt.Errorf("DecodeValue: decimal digits are not terminated")
+		}
+
+		digit := data[offset]
+		offset++
+
+		if marker == DECIMAL_NEGATIVE {
+			digit = 0xFF - digit
+		}
+
+		if digit == 0x00 {
+			break
+		}
+
+		digits = append(digits, digit-1+'0')
+	}
+
+	unscaled, ok := new(big.Int).SetString(string(digits), 10)
+	if !ok {
+		return nil, 0, fmt.Errorf("DecodeValue: decimal has wrong digits")
+	}
+
+	scale := max(int64(len(digits))-exponent, 0)
+	if scale > math.MaxInt32 {
+		return nil, 0, fmt.Errorf("DecodeValue: decimal scale overflows")
+	}
+
+	trailingZeros := exponent - int64(len(digits)) + scale
+	unscaled.Mul(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(trailingZeros), nil))
+
+	if marker == DECIMAL_NEGATIVE {
+		unscaled.Neg(unscaled)
+	}
+
+	return primitive.NewDecimal(unscaled, int32(scale)), offset, nil
+}
+
+// Every element of a container is preceded by CONTAINER_ELEMENT marker and the container ends with CONTAINER_END,
+// so containers are ordered element by element and a prefix sorts before a longer container.
+const CONTAINER_END = 0x00
+const CONTAINER_ELEMENT = 0x01
+
+func encodeArray(val *primitive.Array) []byte {
+	var out []byte
+
+	for _, element := range val.Value() {
+		out = append(out, CONTAINER_ELEMENT)
+		out = append(out, EncodeValue(element)...)
+	}
+
+	return append(out, CONTAINER_END)
+}
+
+func decodeArray(data []byte) (*primitive.Array, int, error) {
+	array := primitive.NewArray()
+	offset := 0
+
+	for {
+		if offset >= len(data) {
+			return nil, 0, fmt.Errorf("DecodeValue: array is not terminated")
+		}
+
+		if data[offset] == CONTAINER_END {
+			return array, offset + 1, nil
+		}
+
+		element, size, err := DecodeValue(data[offset+1:])
+		if err != nil {
+			return nil, 0, err
+		}
+
+		array.Append(element)
+		offset += 1 + size
+	}
+}
+
+// encodeObject writes fields sorted by name, so equal objects always have equal encodings
+func encodeObject(val *primitive.Object) []byte {
+	var out []byte
+
+	for _, field := range slices.Sorted(maps.Keys(val.Values())) {
+		out = append(out, CONTAINER_ELEMENT)
+		out = append(out, encodeEscaped([]byte(field))...)
+		out = append(out, EncodeValue(val.Get(field))...)
+	}
+
+	return append(out, CONTAINER_END)
+}
+
+func decodeObject(data []byte) (*primitive.Object, int, error) {
+	object := primitive.NewObject()
+	offset := 0
+
+	for {
+		if offset >= len(data) {
+			return nil, 0, fmt.Errorf("DecodeValue: object is not terminated")
+		}
+
+		if data[offset] == CONTAINER_END {
+			return object, offset + 1, nil
+		}
+
+		field, size := decodeEscaped(data[offset+1:])
+		offset += 1 + size
+
+		value, size, err := DecodeValue(data[offset:])
+		if err != nil {
+			return nil, 0, err
+		}
+
+		object.Set(string(field), value)
+		offset += size
+	}
+}
+
 func encodeString(val *primitive.String) []byte {
-	value := []byte(val.Value())
+	return encodeEscaped([]byte(val.Value()))
+}
+
+func decodeString(data []byte) (*primitive.String, int) {
+	value, size := decodeEscaped(data)
+
+	return primitive.NewString(string(value)), size
+}
+
+func encodeBytes(val *primitive.Bytes) []byte {
+	return encodeEscaped(val.Value())
+}
+
+func decodeBytes(data []byte) (*primitive.Bytes, int) {
+	value, size := decodeEscaped(data)
+
+	return primitive.NewBytes(value), size
+}
+
+// encodeEscaped writes null terminated byte sequence which keeps lexicographical order of the original one
+func encodeEscaped(value []byte) []byte {
 	escapeSymbolsCount := bytes.Count(value, []byte{0}) + bytes.Count(value, []byte{1})
 	out := make([]byte, len(value)+escapeSymbolsCount+1) // +1 for null terminator
 
@@ -132,7 +467,7 @@ func encodeString(val *primitive.String) []byte {
 	return out
 }
 
-func decodeString(data []byte) (*primitive.String, int) {
+func decodeEscaped(data []byte) ([]byte, int) {
 	nullIdx := bytes.Index(data, []byte{0})
 
 	result := make([]byte, nullIdx) // worst case: no masked symbols 0 and 1
@@ -150,5 +485,5 @@ func decodeString(data []byte) (*primitive.String, int) {
 		dstPos++
 	}
 
-	return primitive.NewString(string(result[:dstPos])), nullIdx + 1
+	return result[:dstPos], nullIdx + 1
 }
@@ -0,0 +1,309 @@
+package codec
+
+import (
+	"bytes"
+	"distributed-storage/internal/primitive"
+	"math"
+	"testing"
+	"time"
+)
+
+func roundTrip(t *testing.T, value primitive.Primitive) primitive.Primitive {
+	t.Helper()
+	encoded := EncodeValue(value)
+	decoded, offset, err := DecodeValue(encoded)
+	if err != nil {
+		t.Fatalf("DecodeValue failed: %v", err)
+	}
+	if offset != len(encoded) {
+		t.Errorf("expected offset %d, got %d", len(encoded), offset)
+	}
+	return decoded
+}
+
+func TestEncodeValue_Bool_RoundTrip(t *testing.T) {
+	for _, value := range []bool{false, true} {
+		decoded := roundTrip(t, primitive.NewBool(value))
+		if decoded.(*primitive.Bool).Value() != value {
+			t.Errorf("expected %v, got %v", value, decoded.(*primitive.Bool).Value())
+		}
+	}
+}
+
+func TestEncodeValue_Bool_FalseSortsBeforeTrue(t *testing.T) {
+	if bytes.Compare(EncodeValue(primitive.NewBool(false)), EncodeValue(primitive.NewBool(true))) >= 0 {
+		t.Error("expected false to sort before true")
+	}
+}
+
+func TestEncodeValue_Float64_RoundTrip(t *testing.T) {
+	for _, value := range []float64{0, 1.5, -1.5, math.MaxFloat64, -math.MaxFloat64, math.SmallestNonzeroFloat64, math.Inf(1), math.Inf(-1)} {
+		decoded := roundTrip(t, primitive.NewFloat64(value))
+		if decoded.(*primitive.Float64).Value() != value {
+			t.Errorf("expected %v, got %v", value, decoded.(*primitive.Float64).Value())
+		}
+	}
+}
+
+func TestEncodeValue_Float64_PreservesOrder(t *testing.T) {
+	ordered := []float64{
+		math.Inf(-1), -math.MaxFloat64, -1e10, -1, -math.SmallestNonzeroFloat64,
+		0, math.SmallestNonzeroFloat64, 1, 1e10, math.MaxFloat64, math.Inf(1), math.NaN(),
+	}
+
+	for idx := 1; idx < len(ordered); idx++ {
+		previous := EncodeValue(primitive.NewFloat64(ordered[idx-1]))
+		current := EncodeValue(primitive.NewFloat64(ordered[idx]))
+		if bytes.Compare(previous, current) >= 0 {
+			t.Errorf("expected %v to sort before %v", ordered[idx-1], ordered[idx])
+		}
+	}
+}
+
+func TestEncodeValue_Float64_NegativeZeroEqualsPositiveZero(t *testing.T) {
+	negativeZero := primitive.NewFloat64(math.Copysign(0, -1))
+	if !bytes.Equal(EncodeValue(negativeZero), EncodeValue(primitive.NewFloat64(0))) {
+		t.Error("expected -0 and +0 to have the same encoding")
+	}
+	if !negativeZero.Equal(primitive.NewFloat64(0)) {
+		t.Error("expected -0 to Equal +0")
+	}
+}
+
+func TestEncodeValue_Float64_NaNIsCanonical(t *testing.T) {
+	otherNaN := math.Float64frombits(0xFFF8000000000001)
+	if !bytes.Equal(EncodeValue(primitive.NewFloat64(otherNaN)), EncodeValue(primitive.NewFloat64(math.NaN()))) {
+		t.Error("expected all NaNs to have the same encoding")
+	}
+
+	decoded := roundTrip(t, primitive.NewFloat64(otherNaN))
+	if !math.IsNaN(decoded.(*primitive.Float64).Value()) {
+		t.Errorf("expected NaN, got %v", decoded.(*primitive.Float64).Value())
+	}
+}
+
+func TestEncodeValue_Bytes_RoundTrip(t *testing.T) {
+	for _, value := range [][]byte{{}, {0x00}, {0x01}, {0x00, 0x01, 0x02, 0xFF}, []byte("blob")} {
+		decoded := roundTrip(t, primitive.NewBytes(value))
+		if !bytes.Equal(decoded.(*primitive.Bytes).Value(), value) {
+			t.Errorf("expected %v, got %v", value, decoded.(*primitive.Bytes).Value())
+		}
+	}
+}
+
+func TestEncodeValue_Bytes_PreservesOrder(t *testing.T) {
+	ordered := [][]byte{{}, {0x00}, {0x00, 0x00}, {0x00, 0x01}, {0x01}, {0x01, 0x00}, {0x02}, {0xFF}}
+
+	for idx := 1; idx < len(ordered); idx++ {
+		previous := EncodeValue(primitive.NewBytes(ordered[idx-1]))
+		current := EncodeValue(primitive.NewBytes(ordered[idx]))
+		if bytes.Compare(previous, current) >= 0 {
+			t.Errorf("expected %v to sort before %v", ordered[idx-1], ordered[idx])
+		}
+	}
+}
+
+func TestEncodeValue_Bytes_SameEncodingAsString(t *testing.T) {
+	value := "a\x00b\x01c"
+	fromBytes := EncodeValue(primitive.NewBytes([]byte(value)))
+	fromString := EncodeValue(primitive.NewString(value))
+	if !bytes.Equal(fromBytes[1:], fromString[1:]) {
+		t.Errorf("expected the same escaped payload, got %v and %v", fromBytes[1:], fromString[1:])
+	}
+}
+
+func TestDecodeValue_CompositeKey(t *testing.T) {
+	key := append(EncodeValue(primitive.NewBytes([]byte{0x00, 0x01})), EncodeValue(primitive.NewFloat64(-2.5))...)
+	key = append(key, EncodeValue(primitive.NewBool(true))...)
+
+	expected := []primitive.Primitive{primitive.NewBytes([]byte{0x00, 0x01}), primitive.NewFloat64(-2.5), primitive.NewBool(true)}
+	for _, value := range expected {
+		decoded, offset, _ := DecodeValue(key)
+		if !decoded.Equal(value) {
+			t.Errorf("expected %v, got %v", value, decoded)
+		}
+		key = key[offset:]
+	}
+}
+
+func TestEncodeValue_Timestamp_RoundTrip(t *testing.T) {
+	instant := time.Date(2024, time.February, 29, 13, 45, 30, 123456789, time.UTC)
+	for _, value := range []*primitive.Timestamp{
+		primitive.NewTimestamp(instant),
+		primitive.NewZonedTimestamp(instant.In(time.FixedZone("", -5*60*60))),
+		primitive.NewTimestamp(time.Date(1500, time.January, 1, 0, 0, 0, 1, time.UTC)),
+	} {
+		decoded := roundTrip(t, value)
+		if !decoded.Equal(value) {
+			t.Errorf("expected %v, got %v", value.Value(), decoded.(*primitive.Timestamp).Value())
+		}
+	}
+}
+
+func TestEncodeValue_Timestamp_OrderedByInstant(t *testing.T) {
+	base := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	ordered := []*primitive.Timestamp{
+		primitive.NewTimestamp(time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC)),
+		primitive.NewTimestamp(base.Add(-time.Nanosecond)),
+		primitive.NewTimestamp(base),
+		primitive.NewZonedTimestamp(base.In(time.FixedZone("", 3*60*60))),
+		primitive.NewZonedTimestamp(base.Add(time.Nanosecond).In(time.FixedZone("", -8*60*60))),
+		primitive.NewTimestamp(base.Add(time.Second)),
+	}
+
+	for idx := 1; idx < len(ordered); idx++ {
+		previous := EncodeValue(ordered[idx-1])
+		current := EncodeValue(ordered[idx])
+		if bytes.Compare(previous, current) >= 0 {
+			t.Errorf("expected %v to sort before %v", ordered[idx-1].Value(), ordered[idx].Value())
+		}
+	}
+}
+
+func TestEncodeValue_Date_RoundTripAndOrder(t *testing.T) {
+	ordered := []*primitive.Date{
+		primitive.NewDate(1960, time.March, 1),
+		primitive.NewDate(1969, time.December, 31),
+		primitive.NewDate(1970, time.January, 1),
+		primitive.NewDate(2024, time.February, 29),
+	}
+
+	for idx, value := range ordered {
+		if decoded := roundTrip(t, value); !decoded.Equal(value) {
+			t.Errorf("expected %v, got %v", value.Value(), decoded.(*primitive.Date).Value())
+		}
+		if idx > 0 && bytes.Compare(EncodeValue(ordered[idx-1]), EncodeValue(value)) >= 0 {
+			t.Errorf("expected %v to sort before %v", ordered[idx-1].Value(), value.Value())
+		}
+	}
+}
+
+func TestEncodeValue_NestedObject_RoundTrip(t *testing.T) {
+	value := primitive.NewObject().
+		Set("name", primitive.NewString("order")).
+		Set("address", primitive.NewObject().Set("city", primitive.NewString("Berlin")).Set("zip", primitive.NewNull())).
+		Set("items", primitive.NewArray(
+			primitive.NewObject().Set("price", primitive.NewFloat64(9.99)),
+			primitive.NewArray(),
+			primitive.NewUint64(3),
+		))
+
+	decoded := roundTrip(t, value)
+	if !decoded.Equal(value) {
+		t.Error("expected decoded object to equal original one")
+	}
+}
+
+func TestEncodeValue_Object_DeterministicFieldOrder(t *testing.T) {
+	first := primitive.NewObject().Set("a", primitive.NewInt32(1)).Set("b", primitive.NewInt32(2))
+	second := primitive.NewObject().Set("b", primitive.NewInt32(2)).Set("a", primitive.NewInt32(1))
+
+	for range 10 { // Map iteration order is random, so repeat to catch nondeterminism
+		if !bytes.Equal(EncodeValue(first), EncodeValue(second)) {
+			t.Fatal("expected equal objects to have equal encodings")
+		}
+	}
+}
+
+func TestEncodeValue_Array_PreservesOrder(t *testing.T) {
+	ordered := []*primitive.Array{
+		primitive.NewArray(),
+		primitive.NewArray(primitive.NewInt64(-1)),
+		primitive.NewArray(primitive.NewInt64(1)),
+		primitive.NewArray(primitive.NewInt64(1), primitive.NewInt64(0)),
+		primitive.NewArray(primitive.NewInt64(2)),
+	}
+
+	for idx := 1; idx < len(ordered); idx++ {
+		if bytes.Compare(EncodeValue(ordered[idx-1]), EncodeValue(ordered[idx])) >= 0 {
+			t.Errorf("expected array %d to sort before array %d", idx-1, idx)
+		}
+	}
+}
+
+func TestDecodeValue_UnterminatedArray_ReturnsError(t *testing.T) {
+	encoded := EncodeValue(primitive.NewArray(primitive.NewInt32(1)))
+	if _, _, err := DecodeValue(encoded[:len(encoded)-1]); err == nil {
+		t.Error("expected error for unterminated array")
+	}
+}
+
+func mustParseDecimal(t *testing.T, formatted string) *primitive.Decimal {
+	t.Helper()
+	value, err := primitive.ParseDecimal(formatted)
+	if err != nil {
+		t.Fatalf("ParseDecimal(%q) failed: %v", formatted, err)
+	}
+	return value
+}
+
+func TestEncodeValue_Decimal_RoundTrip(t *testing.T) {
+	cases := map[string]string{
+		"0":      "0",
+		"0.00":   "0",
+		"1":      "1",
+		"-1":     "-1",
+		"12.50":  "12.5",
+		"-0.001": "-0.001",
+		"1e3":    "1000",
+		"-1000":  "-1000",
+		"123456789012345678901234567890.123456789": "123456789012345678901234567890.123456789",
+	}
+
+	for formatted, expected := range cases {
+		decoded := roundTrip(t, mustParseDecimal(t, formatted)).(*primitive.Decimal)
+		if decoded.String() != expected {
+			t.Errorf("expected %s to decode as %s, got %s", formatted, expected, decoded)
+		}
+	}
+}
+
+func TestEncodeValue_Decimal_PreservesOrder(t *testing.T) {
+	ordered := []string{
+		"-1000", "-999.99", "-12.5", "-12.05", "-1", "-0.5", "-0.123", "-0.12", "-0.001",
+		"0", "0.001", "0.12", "0.123", "0.5", "1", "12.05", "12.5", "999.99", "1e3", "1000.5",
+	}
+
+	for idx := 1; idx < len(ordered); idx++ {
+		previous := EncodeValue(mustParseDecimal(t, ordered[idx-1]))
+		current := EncodeValue(mustParseDecimal(t, ordered[idx]))
+		if bytes.Compare(previous, current) >= 0 {
+			t.Errorf("expected %s to sort before %s", ordered[idx-1], ordered[idx])
+		}
+	}
+}
+
+func TestEncodeValue_Decimal_EqualValuesShareEncoding(t *testing.T) {
+	for _, equal := range [][]string{{"1", "1.0", "1.00"}, {"-2.5", "-2.50"}, {"1000", "1e3", "1000.000"}, {"0", "0.000"}} {
+		for _, formatted := range equal[1:] {
+			if !bytes.Equal(EncodeValue(mustParseDecimal(t, equal[0])), EncodeValue(mustParseDecimal(t, formatted))) {
+				t.Errorf("expected %s and %s to share encoding", equal[0], formatted)
+			}
+		}
+	}
+}
+
+func TestDecodeValue_TruncatedDecimal_ReturnsError(t *testing.T) {
+	encoded := EncodeValue(mustParseDecimal(t, "-12.5"))
+	if _, _, err := DecodeValue(encoded[:len(encoded)-5]); err == nil {
+		t.Error("expected error for truncated decimal")
+	}
+}
+
+func TestEncodeValue_UUID_RoundTripAndTimeOrder(t *testing.T) {
+	previous := primitive.NewUUIDv7()
+	if decoded := roundTrip(t, previous); !decoded.Equal(previous) {
+		t.Errorf("expected %s, got %s", previous, decoded.(*primitive.UUID))
+	}
+	if len(EncodeValue(previous)) != 1+primitive.UUID_SIZE {
+		t.Errorf("expected compact %d byte encoding, got %d", 1+primitive.UUID_SIZE, len(EncodeValue(previous)))
+	}
+
+	for range 1000 {
+		current := primitive.NewUUIDv7()
+		if bytes.Compare(EncodeValue(previous), EncodeValue(current)) >= 0 {
+			t.Fatalf("expected %s to sort before %s", previous, current)
+		}
+		previous = current
+	}
+}
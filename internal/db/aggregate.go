@@ -0,0 +1,500 @@
+package db
+
+import (
+	"bytes"
+	"distributed-storage/internal/codec"
+	"distributed-storage/internal/primitive"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+const AVG_DECIMAL_SCALE_INCREASE int32 = 6 // Number of fractional digits decimal averages have on top of summed values
+
+const (
+	AGGREGATE_COUNT AggregateFunction = iota + 1
+	AGGREGATE_SUM
+	AGGREGATE_MIN
+	AGGREGATE_MAX
+	AGGREGATE_AVG
+)
+
+type AggregateFunction uint8
+
+var aggregateNames = map[AggregateFunction]string{
+	AGGREGATE_COUNT: "count",
+	AGGREGATE_SUM:   "sum",
+	AGGREGATE_MIN:   "min",
+	AGGREGATE_MAX:   "max",
+	AGGREGATE_AVG:   "avg",
+}
+
+// Aggregate computes Function over non null values of Column, COUNT without Column counts records.
+// SUM and AVG of empty set are null, as are MIN and MAX.
+type Aggregate struct {
+	Function AggregateFunction
+	Column   string
+	Alias    string // Name of the result field, e.g. "sum(amount)" if empty
+}
+
+type AggregateQuery struct {
+	Where      *Predicate // Nil aggregates all records
+	GroupBy    []string
+	Aggregates []Aggregate
+}
+
+// aggregateState accumulates a single aggregate of a single group
+type aggregateState struct {
+	count     uint64
+	valueType primitive.PrimitiveType
+	integer   *big.Int
+	float     float64
+	decimal   *primitive.Decimal
+	extreme   primitive.Primitive // Least value for MIN, greatest for MAX
+}
+
+type aggregateGroup struct {
+	values []primitive.Primitive
+	states []*aggregateState
+}
+
+func Count() Aggregate {
+	return Aggregate{Function: AGGREGATE_COUNT}
+}
+
+func CountOf(column string) Aggregate {
+	return Aggregate{Function: AGGREGATE_COUNT, Column: column}
+}
+
+func Sum(column string) Aggregate {
+	return Aggregate{Function: AGGREGATE_SUM, Column: column}
+}
+
+func Min(column string) Aggregate {
+	return Aggregate{Function: AGGREGATE_MIN, Column: column}
+}
+
+func Max(column string) Aggregate {
+	return Aggregate{Function: AGGREGATE_MAX, Column: column}
+}
+
+func Avg(column string) Aggregate {
+	return Aggregate{Function: AGGREGATE_AVG, Column: column}
+}
+
+func (aggregate Aggregate) As(alias string) Aggregate {
+	aggregate.Alias = alias
+
+	return aggregate
+}
+
+func (aggregate Aggregate) Name() string {
+	if aggregate.Alias != "" {
+		return aggregate.Alias
+	}
+
+	name, ok := aggregateNames[aggregate.Function]
+	if !ok {
+		name = fmt.Sprintf("aggregate%d", aggregate.Function)
+	}
+
+	if aggregate.Column == "" {
+		return name
+	}
+
+	return name + "(" + aggregate.Column + ")"
+}
+
+// Aggregate returns a record per group holding group by columns and aggregates named by Aggregate.Name, ordered by
+// group by columns. Query without group by returns a single record even if nothing matches. Queries reading only
+// columns of an index which contains all matching records are answered from index entries without fetching records.
+func (table *Table) Aggregate(query AggregateQuery) ([]*primitive.Object, error) {
+	predicate := query.Where
+	if predicate == nil {
+		predicate = And()
+	}
+
+	if err := query.validate(); err != nil {
+		return nil, fmt.Errorf("Table %q: %w", table.schema.Name, err)
+	}
+	if err := predicate.validate(); err != nil {
+		return nil, fmt.Errorf("Table %q: %w", table.schema.Name, err)
+	}
+
+	groups := map[string]*aggregateGroup{}
+	if len(query.GroupBy) == 0 {
+		groups[""] = newAggregateGroup(query, nil)
+	}
+
+	var failure error
+
+	accumulate := func(record *primitive.Object) bool {
+		values := record.GetMany(query.GroupBy)
+		key := encodeGroupKey(values)
+
+		group, ok := groups[key]
+		if !ok {
+			group = newAggregateGroup(query, values)
+			groups[key] = group
+		}
+
+		failure = group.add(query.Aggregates, record)
+
+		return failure == nil
+	}
+
+	var err error
+
+	if ranges, ok := table.indexOnlyRanges(query, predicate); ok {
+		extremeColumn, directions := table.extremeScans(query, ranges)
+
+		for rangeNumber := 0; rangeNumber < len(ranges) && failure == nil && err == nil; rangeNumber++ {
+			matches := func(record *primitive.Object) bool {
+				return !table.coveredByRanges(ranges[:rangeNumber], record) && predicate.Matches(record)
+			}
+
+			if directions == nil {
+				err = table.scanIndexEntries(ranges[rangeNumber], false, func(record *primitive.Object) bool {
+					return !matches(record) || accumulate(record)
+				})
+				continue
+			}
+
+			for idx := 0; idx < len(directions) && failure == nil && err == nil; idx++ {
+				err = table.scanIndexEntries(ranges[rangeNumber], directions[idx], func(record *primitive.Object) bool {
+					if !matches(record) || record.Get(extremeColumn).Empty() {
+						return true
+					}
+
+					accumulate(record)
+					return false
+				})
+			}
+		}
+	} else {
+		err = table.iterate(predicate, QueryOptions{Columns: query.columns()}, func(_ queryPosition, record *primitive.Object) bool {
+			return accumulate(record)
+		})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	if failure != nil {
+		return nil, fmt.Errorf("Table %q: %w", table.schema.Name, failure)
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	results := make([]*primitive.Object, len(keys))
+
+	for idx, key := range keys {
+		if results[idx], err = groups[key].result(query); err != nil {
+			return nil, fmt.Errorf("Table %q: %w", table.schema.Name, err)
+		}
+	}
+
+	return results, nil
+}
+
+// indexOnlyRanges returns ranges of an index whose entries contain every column the query reads and every record
+// the query matches. Ranges of the plan are kept if they are such index, full scans are replaced by the first such
+// secondary index.
+func (table *Table) indexOnlyRanges(query AggregateQuery, predicate *Predicate) ([]indexRange, bool) {
+	plan := table.plan(predicate)
+
+	if !slices.ContainsFunc(plan.ranges, func(indexRange indexRange) bool { return indexRange.indexID != plan.ranges[0].indexID }) &&
+		table.coversAggregate(plan.ranges[0].indexID, query, predicate) {
+		return plan.ranges, true
+	}
+
+	fullScan := len(plan.ranges) == 1 && plan.ranges[0].end == nil && bytes.Equal(plan.ranges[0].start, table.indexPrefix(PRIMARY_INDEX_ID))
+	if !fullScan {
+		return nil, false
+	}
+
+	for secondaryIndexNumber := range table.schema.SecondaryIndexes {
+		indexID := PRIMARY_INDEX_ID + secondaryIndexNumber + 1
+
+		if table.coversAggregate(indexID, query, predicate) {
+			prefix := table.indexPrefix(indexID)
+			return []indexRange{{indexID: indexID, start: prefix, prefix: prefix}}, true
+		}
+	}
+
+	return nil, false
+}
+
+// extremeScans returns the column of a query having only MIN and MAX aggregates of it, if entries of every range are
+// ordered by the column. Such aggregates are found by the first matching entry of each range scanned in the returned
+// directions, ascending for MIN and descending for MAX. Nil directions are returned for other queries.
+func (table *Table) extremeScans(query AggregateQuery, ranges []indexRange) (string, []bool) {
+	if len(query.GroupBy) > 0 || len(query.Aggregates) == 0 {
+		return "", nil
+	}
+
+	column := query.Aggregates[0].Column
+	var ascending, descending bool
+
+	for _, aggregate := range query.Aggregates {
+		switch {
+		case aggregate.Column != column:
+			return "", nil
+		case aggregate.Function == AGGREGATE_MIN:
+			ascending = true
+		case aggregate.Function == AGGREGATE_MAX:
+			descending = true
+		default:
+			return "", nil
+		}
+	}
+
+	for _, indexRange := range ranges {
+		columns := table.indexColumns(indexRange.indexID)
+		if indexRange.equalColumns >= len(columns) || columns[indexRange.equalColumns] != column {
+			return "", nil
+		}
+	}
+
+	var directions []bool
+	if ascending {
+		directions = append(directions, false)
+	}
+	if descending {
+		directions = append(directions, true)
+	}
+
+	return column, directions
+}
+
+// coversAggregate checks that the index entries contain all columns the query reads. Secondary indexes skip records
+// with null key columns, so such records must either fail the predicate or not affect any aggregate.
+func (table *Table) coversAggregate(indexID int, query AggregateQuery, predicate *Predicate) bool {
+	if !table.coversColumns(indexID, slices.Concat(query.columns(), predicate.columns())) {
+		return false
+	}
+
+	if indexID == PRIMARY_INDEX_ID {
+		return true
+	}
+
+	conditions := []*Predicate{predicate}
+	if predicate.Operator == PREDICATE_AND {
+		conditions = predicate.conjuncts()
+	}
+
+	var nullable []string
+
+	for _, column := range table.schema.SecondaryIndexes[indexID-PRIMARY_INDEX_ID-1].Columns {
+		if !slices.ContainsFunc(conditions, func(condition *Predicate) bool { return condition.rejectsNull(column) }) {
+			nullable = append(nullable, column)
+		}
+	}
+
+	switch len(nullable) {
+	case 0:
+		return true
+	case 1: // Records with the only nullable column null contribute nothing to aggregates over that column
+		return len(query.GroupBy) == 0 && !slices.ContainsFunc(query.Aggregates, func(aggregate Aggregate) bool {
+			return aggregate.Column != nullable[0]
+		})
+	}
+
+	return false
+}
+
+// columns returns columns read by group by and aggregates, never nil
+func (query AggregateQuery) columns() []string {
+	columns := slices.Clone(query.GroupBy)
+	if columns == nil {
+		columns = []string{}
+	}
+
+	for _, aggregate := range query.Aggregates {
+		if aggregate.Column != "" {
+			columns = append(columns, aggregate.Column)
+		}
+	}
+
+	return columns
+}
+
+func (query AggregateQuery) validate() error {
+	if len(query.Aggregates) == 0 && len(query.GroupBy) == 0 {
+		return fmt.Errorf("aggregation must have at least one aggregate or group by column")
+	}
+
+	for _, aggregate := range query.Aggregates {
+		if aggregate.Function < AGGREGATE_COUNT || aggregate.Function > AGGREGATE_AVG {
+			return fmt.Errorf("unknown aggregate function %d", aggregate.Function)
+		}
+		if aggregate.Column == "" && aggregate.Function != AGGREGATE_COUNT {
+			return fmt.Errorf("aggregate %s must have a column", aggregate.Name())
+		}
+		if slices.Contains(query.GroupBy, aggregate.Name()) {
+			return fmt.Errorf("aggregate %s has the same name as group by column", aggregate.Name())
+		}
+	}
+
+	return nil
+}
+
+func newAggregateGroup(query AggregateQuery, values []primitive.Primitive) *aggregateGroup {
+	group := &aggregateGroup{values: values, states: make([]*aggregateState, len(query.Aggregates))}
+
+	for idx := range group.states {
+		group.states[idx] = &aggregateState{integer: new(big.Int)}
+	}
+
+	return group
+}
+
+func (group *aggregateGroup) add(aggregates []Aggregate, record *primitive.Object) error {
+	for idx, aggregate := range aggregates {
+		if err := group.states[idx].add(aggregate, record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (group *aggregateGroup) result(query AggregateQuery) (*primitive.Object, error) {
+	result := primitive.NewObject()
+
+	for idx, column := range query.GroupBy {
+		result.Set(column, group.values[idx])
+	}
+
+	for idx, aggregate := range query.Aggregates {
+		value, err := group.states[idx].result(aggregate)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Set(aggregate.Name(), value)
+	}
+
+	return result, nil
+}
+
+func (state *aggregateState) add(aggregate Aggregate, record *primitive.Object) error {
+	if aggregate.Column == "" {
+		state.count++
+		return nil
+	}
+
+	value := record.Get(aggregate.Column)
+	if value.Empty() {
+		return nil
+	}
+
+	if state.count > 0 && value.Type() != state.valueType && aggregate.Function != AGGREGATE_COUNT {
+		return fmt.Errorf("aggregate %s got values of types %s and %s",
+			aggregate.Name(), primitive.TypeName(state.valueType), primitive.TypeName(value.Type()))
+	}
+
+	state.count++
+	state.valueType = value.Type()
+
+	switch aggregate.Function {
+	case AGGREGATE_MIN, AGGREGATE_MAX:
+		if state.extreme == nil {
+			state.extreme = value
+			return nil
+		}
+
+		cmp, _ := compareValues(value, state.extreme)
+		if (aggregate.Function == AGGREGATE_MIN && cmp < 0) || (aggregate.Function == AGGREGATE_MAX && cmp > 0) {
+			state.extreme = value
+		}
+
+	case AGGREGATE_SUM, AGGREGATE_AVG:
+		switch number := value.(type) {
+		case *primitive.Int32:
+			state.integer.Add(state.integer, big.NewInt(int64(number.Value())))
+		case *primitive.Int64:
+			state.integer.Add(state.integer, big.NewInt(number.Value()))
+		case *primitive.Uint32:
+			state.integer.Add(state.integer, new(big.Int).SetUint64(uint64(number.Value())))
+		case *primitive.Uint64:
+			state.integer.Add(state.integer, new(big.Int).SetUint64(number.Value()))
+		case *primitive.Float64:
+			state.float += number.Value()
+		case *primitive.Decimal:
+			if state.decimal == nil {
+				state.decimal = number
+			} else {
+				state.decimal = state.decimal.Add(number)
+			}
+		default:
+			return fmt.Errorf("aggregate %s can't sum values of type %s", aggregate.Name(), primitive.TypeName(value.Type()))
+		}
+	}
+
+	return nil
+}
+
+// result returns the aggregate value. Sums of signed integers are int64, of unsigned integers uint64, averages of
+// integers are float64 and of decimals are decimals with AVG_DECIMAL_SCALE_INCREASE more fractional digits.
+func (state *aggregateState) result(aggregate Aggregate) (primitive.Primitive, error) {
+	if aggregate.Function == AGGREGATE_COUNT {
+		return primitive.NewUint64(state.count), nil
+	}
+
+	if state.count == 0 {
+		return primitive.NewNull(), nil
+	}
+
+	switch aggregate.Function {
+	case AGGREGATE_MIN, AGGREGATE_MAX:
+		return state.extreme, nil
+
+	case AGGREGATE_AVG:
+		switch state.valueType {
+		case primitive.TYPE_FLOAT64:
+			return primitive.NewFloat64(state.float / float64(state.count)), nil
+		case primitive.TYPE_DECIMAL:
+			return state.decimal.Quo(primitive.NewDecimal(new(big.Int).SetUint64(state.count), 0), state.decimal.Scale()+AVG_DECIMAL_SCALE_INCREASE)
+		}
+
+		average, _ := new(big.Rat).SetFrac(state.integer, new(big.Int).SetUint64(state.count)).Float64()
+
+		return primitive.NewFloat64(average), nil
+	}
+
+	switch state.valueType {
+	case primitive.TYPE_FLOAT64:
+		return primitive.NewFloat64(state.float), nil
+	case primitive.TYPE_DECIMAL:
+		return state.decimal, nil
+	case primitive.TYPE_UINT32, primitive.TYPE_UINT64:
+		if !state.integer.IsUint64() {
+			return nil, fmt.Errorf("aggregate %s overflows uint64", aggregate.Name())
+		}
+		return primitive.NewUint64(state.integer.Uint64()), nil
+	}
+
+	if !state.integer.IsInt64() {
+		return nil, fmt.Errorf("aggregate %s overflows int64", aggregate.Name())
+	}
+
+	return primitive.NewInt64(state.integer.Int64()), nil
+}
+
+// encodeGroupKey encodes group by values so that keys sort in order of the values. Numerically equal decimals of
+// different scales share encoding, so they fall into the same group.
+func encodeGroupKey(values []primitive.Primitive) string {
+	var key strings.Builder
+
+	for _, value := range values {
+		key.Write(codec.EncodeValue(value))
+	}
+
+	return key.String()
+}
@@ -0,0 +1,204 @@
+package db
+
+import (
+	"distributed-storage/internal/events"
+	"distributed-storage/internal/kv"
+	"distributed-storage/internal/primitive"
+	"strings"
+	"testing"
+)
+
+func aggregate(t *testing.T, table *Table, query AggregateQuery) []*primitive.Object {
+	t.Helper()
+	results, err := table.Aggregate(query)
+	if err != nil {
+		t.Fatalf("Aggregate: %v", err)
+	}
+
+	return results
+}
+
+func TestTable_Aggregate_WithoutGroupBy(t *testing.T) {
+	table := newOrdersTable(t)
+
+	results := aggregate(t, table, AggregateQuery{
+		Aggregates: []Aggregate{Count(), CountOf("status"), Sum("amount"), Min("amount"), Max("customer"), Avg("amount").As("average")},
+	})
+
+	if len(results) != 1 {
+		t.Fatalf("expected a single result, got %d", len(results))
+	}
+
+	result := results[0]
+	if result.GetUint64("count") != 7 || result.GetUint64("count(status)") != 6 {
+		t.Errorf("expected counts 7 and 6, got %s", result)
+	}
+	if result.GetUint64("sum(amount)") != 1080 || result.GetUint32("min(amount)") != 10 || result.GetString("max(customer)") != "carol" {
+		t.Errorf("expected sum 1080, min 10 and max 'carol', got %s", result)
+	}
+	if result.GetFloat64("average") != 1080.0/7 {
+		t.Errorf("expected average %v, got %v", 1080.0/7, result.Get("average"))
+	}
+}
+
+func TestTable_Aggregate_GroupByWithPredicate(t *testing.T) {
+	table := newOrdersTable(t)
+
+	results := aggregate(t, table, AggregateQuery{
+		Where:      Ge("amount", primitive.NewUint32(100)),
+		GroupBy:    []string{"status"},
+		Aggregates: []Aggregate{Count(), Sum("amount"), Max("id")},
+	})
+
+	expected := []struct {
+		status string
+		count  uint64
+		sum    uint64
+		maxID  uint64
+	}{
+		{"new", 3, 600, 6},
+		{"paid", 1, 300, 4},
+		{"shipped", 1, 120, 5},
+	}
+
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d groups, got %d", len(expected), len(results))
+	}
+
+	for idx, group := range expected {
+		result := results[idx]
+		if result.GetString("status") != group.status || result.GetUint64("count") != group.count ||
+			result.GetUint64("sum(amount)") != group.sum || result.GetUint64("max(id)") != group.maxID {
+			t.Errorf("expected group %+v, got %s", group, result)
+		}
+	}
+}
+
+func TestTable_Aggregate_NullGroupAndEmptyAggregates(t *testing.T) {
+	table := newOrdersTable(t)
+
+	results := aggregate(t, table, AggregateQuery{GroupBy: []string{"customer"}, Aggregates: []Aggregate{Count()}})
+	if len(results) != 5 || !results[0].Get("customer").Empty() || results[0].GetUint64("count") != 1 {
+		t.Errorf("expected null customer group first, got %v", results)
+	}
+
+	results = aggregate(t, table, AggregateQuery{
+		Where:      Eq("status", primitive.NewString("cancelled")),
+		Aggregates: []Aggregate{Count(), Sum("amount"), Min("amount")},
+	})
+	if results[0].GetUint64("count") != 0 || !results[0].Get("sum(amount)").Empty() || !results[0].Get("min(amount)").Empty() {
+		t.Errorf("expected zero count and null aggregates, got %s", results[0])
+	}
+}
+
+func TestTable_Aggregate_SumsDecimalsAndRejectsStrings(t *testing.T) {
+	table := newOrdersTable(t)
+	price, _ := primitive.ParseDecimal("1.25")
+	records, _ := table.GetAll()
+	for _, record := range records {
+		table.Update(record.Set("price", price))
+	}
+
+	results := aggregate(t, table, AggregateQuery{Aggregates: []Aggregate{Sum("price"), Avg("price")}})
+	if results[0].GetDecimal("sum(price)").String() != "8.75" || results[0].GetDecimal("avg(price)").String() != "1.25000000" {
+		t.Errorf("expected decimal sum 8.75 and average 1.25000000, got %s", results[0])
+	}
+
+	if _, err := table.Aggregate(AggregateQuery{Aggregates: []Aggregate{Sum("status")}}); err == nil || !strings.Contains(err.Error(), "can't sum values of type") {
+		t.Errorf("expected sum of strings to be rejected, got %v", err)
+	}
+}
+
+func TestTable_Aggregate_IndexOnlyPlans(t *testing.T) {
+	table := newOrdersTable(t)
+
+	cases := map[string]struct {
+		query     AggregateQuery
+		indexOnly bool
+	}{
+		"count by equality on index":        {AggregateQuery{Where: Eq("customer", primitive.NewString("alice")), Aggregates: []Aggregate{Count()}}, true},
+		"count with all index columns set":  {AggregateQuery{Where: And(Eq("status", primitive.NewString("new")), Ge("amount", primitive.NewUint32(0))), Aggregates: []Aggregate{Count()}}, true},
+		"count with nullable index column":  {AggregateQuery{Where: Eq("status", primitive.NewString("new")), Aggregates: []Aggregate{Count()}}, false},
+		"min of second index column":        {AggregateQuery{Where: Eq("status", primitive.NewString("new")), Aggregates: []Aggregate{Min("amount")}}, true},
+		"count of primary keys":             {AggregateQuery{Aggregates: []Aggregate{Count(), Max("id")}}, true},
+		"max of single column index":        {AggregateQuery{Aggregates: []Aggregate{Max("customer"), CountOf("customer")}}, true},
+		"count of records missing in index": {AggregateQuery{Aggregates: []Aggregate{Count(), Max("customer")}}, false},
+		"column outside of index":           {AggregateQuery{Where: Eq("status", primitive.NewString("new")), Aggregates: []Aggregate{Max("customer")}}, false},
+		"nullable leading column":           {AggregateQuery{Aggregates: []Aggregate{Min("amount")}}, false},
+		"group by nullable column":          {AggregateQuery{GroupBy: []string{"customer"}, Aggregates: []Aggregate{CountOf("customer")}}, false},
+	}
+
+	for name, test := range cases {
+		predicate := test.query.Where
+		if predicate == nil {
+			predicate = And()
+		}
+
+		if _, indexOnly := table.indexOnlyRanges(test.query, predicate); indexOnly != test.indexOnly {
+			t.Errorf("%s: expected index only %v, got %v", name, test.indexOnly, indexOnly)
+		}
+	}
+}
+
+func TestTable_Aggregate_IndexOnlyDoesNotFetchRecords(t *testing.T) {
+	table := newOrdersTable(t)
+
+	missing := table.encodePrimaryIndex([]primitive.Primitive{primitive.NewUint64(2)})
+	if _, err := table.kv.Delete(&kv.DeleteRequest{Key: missing}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	results := aggregate(t, table, AggregateQuery{
+		Where:      And(Eq("status", primitive.NewString("new")), Ge("amount", primitive.NewUint32(0))),
+		Aggregates: []Aggregate{Count(), Min("amount"), Max("amount")},
+	})
+	if results[0].GetUint64("count") != 3 || results[0].GetUint32("min(amount)") != 100 || results[0].GetUint32("max(amount)") != 250 {
+		t.Errorf("expected count 3, min 100 and max 250 from index keys, got %s", results[0])
+	}
+
+	if _, err := table.Aggregate(AggregateQuery{Where: Eq("status", primitive.NewString("new")), Aggregates: []Aggregate{Max("customer")}}); err == nil {
+		t.Error("expected aggregate reading records to fail on index entry of missing record")
+	}
+}
+
+func TestTable_Aggregate_MinMaxReadOnlyEndsOfRanges(t *testing.T) {
+	table := newOrdersTable(t)
+	table.changeEvents = nil // Records of the setup count as committed, reads of own writes are recorded with values before them
+	table.trackReads = true
+
+	results := aggregate(t, table, AggregateQuery{
+		Where:      And(In("status", primitive.NewString("new"), primitive.NewString("paid")), Ge("amount", primitive.NewUint32(60))),
+		Aggregates: []Aggregate{Min("amount"), Max("amount")},
+	})
+	if results[0].GetUint32("min(amount)") != 100 || results[0].GetUint32("max(amount)") != 300 {
+		t.Errorf("expected min 100 and max 300, got %s", results[0])
+	}
+
+	if len(table.readEvents) != 4 {
+		t.Fatalf("expected both ends of 2 ranges to be read, got %v", table.readEvents)
+	}
+	for _, event := range table.readEvents {
+		if entries := event.(*events.ReadRange).Entries; entries != 1 {
+			t.Errorf("expected a single entry read from the end of range, got %d", entries)
+		}
+	}
+
+	results = aggregate(t, table, AggregateQuery{Aggregates: []Aggregate{Max("id")}})
+	if results[0].GetUint64("max(id)") != 7 {
+		t.Errorf("expected max id 7, got %s", results[0])
+	}
+}
+
+func TestTable_Aggregate_GroupsEqualDecimalsOfDifferentScales(t *testing.T) {
+	table := newOrdersTable(t)
+	records, _ := table.GetAll()
+	for idx, record := range records {
+		price, _ := primitive.ParseDecimal([]string{"1.5", "1.50", "1.500"}[idx%3])
+		table.Update(record.Set("price", price))
+	}
+
+	results := aggregate(t, table, AggregateQuery{GroupBy: []string{"price"}, Aggregates: []Aggregate{Count()}})
+	if len(results) != 1 || results[0].GetUint64("count") != 7 {
+		t.Errorf("expected a single group of 7 records, got %v", results)
+	}
+}
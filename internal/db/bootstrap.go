@@ -1,9 +1,12 @@
 package db
 
 import (
+	"distributed-storage/internal/metrics"
 	"distributed-storage/internal/store"
 	"fmt"
+	"log/slog"
 	"os"
+	"time"
 )
 
 func setupFS(config DatabaseConfig) (err error) {
@@ -44,6 +47,15 @@ const DEFAULT_WAL_ARCHIVE_DIRECTORY = "archive"
 const DEFAULT_PAGE_SIZE = 16 * 1024               // 16KB
 const DEFAULT_WAL_SEGMENT_SIZE = 10 * 1024 * 1024 // 10MB
 
+const DEFAULT_DURABILITY = DURABILITY_GROUP_COMMIT
+const DEFAULT_COMMIT_INTERVAL = 1 * time.Millisecond
+const DEFAULT_COMMIT_BATCH_SIZE = 256
+const DEFAULT_ASYNC_SYNC_INTERVAL = 10 * time.Millisecond
+const DEFAULT_SYNC_INTERVAL = 100 * time.Millisecond
+const DEFAULT_RECLAIM_INTERVAL = 1 * time.Second
+const DEFAULT_EXPIRY_INTERVAL = 1 * time.Second
+const DEFAULT_EXPIRY_BATCH_SIZE = 100
+
 func applyDefaults(config DatabaseConfig) DatabaseConfig {
 	if config.Directory == "" {
 		config.Directory = DEFAULT_DIRECTORY
@@ -65,5 +77,45 @@ func applyDefaults(config DatabaseConfig) DatabaseConfig {
 		config.WALArchiveDirectory = DEFAULT_WAL_ARCHIVE_DIRECTORY
 	}
 
+	if config.Durability == DURABILITY_DEFAULT {
+		config.Durability = DEFAULT_DURABILITY
+	}
+
+	if config.CommitInterval == 0 {
+		config.CommitInterval = DEFAULT_COMMIT_INTERVAL
+	}
+
+	if config.CommitBatchSize == 0 {
+		config.CommitBatchSize = DEFAULT_COMMIT_BATCH_SIZE
+	}
+
+	if config.AsyncSyncInterval == 0 {
+		config.AsyncSyncInterval = DEFAULT_ASYNC_SYNC_INTERVAL
+	}
+
+	if config.SyncInterval == 0 {
+		config.SyncInterval = DEFAULT_SYNC_INTERVAL
+	}
+
+	if config.ReclaimInterval == 0 {
+		config.ReclaimInterval = DEFAULT_RECLAIM_INTERVAL
+	}
+
+	if config.ExpiryInterval == 0 {
+		config.ExpiryInterval = DEFAULT_EXPIRY_INTERVAL
+	}
+
+	if config.ExpiryBatchSize == 0 {
+		config.ExpiryBatchSize = DEFAULT_EXPIRY_BATCH_SIZE
+	}
+
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	if config.Metrics == nil {
+		config.Metrics = metrics.NewNoop()
+	}
+
 	return config
 }
@@ -1,8 +1,10 @@
 package db
 
 import (
+	"distributed-storage/internal/metrics"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestApplyDefaults_FillsEmptyValues(t *testing.T) {
@@ -22,6 +24,30 @@ func TestApplyDefaults_FillsEmptyValues(t *testing.T) {
 	if cfg.WALArchiveDirectory != DEFAULT_WAL_ARCHIVE_DIRECTORY {
 		t.Errorf("expected WALArchiveDirectory=%q, got %q", DEFAULT_WAL_ARCHIVE_DIRECTORY, cfg.WALArchiveDirectory)
 	}
+	if cfg.Durability != DEFAULT_DURABILITY {
+		t.Errorf("expected Durability=%d, got %d", DEFAULT_DURABILITY, cfg.Durability)
+	}
+	if cfg.CommitInterval != DEFAULT_COMMIT_INTERVAL {
+		t.Errorf("expected CommitInterval=%v, got %v", DEFAULT_COMMIT_INTERVAL, cfg.CommitInterval)
+	}
+	if cfg.CommitBatchSize != DEFAULT_COMMIT_BATCH_SIZE {
+		t.Errorf("expected CommitBatchSize=%d, got %d", DEFAULT_COMMIT_BATCH_SIZE, cfg.CommitBatchSize)
+	}
+	if cfg.AsyncSyncInterval != DEFAULT_ASYNC_SYNC_INTERVAL {
+		t.Errorf("expected AsyncSyncInterval=%v, got %v", DEFAULT_ASYNC_SYNC_INTERVAL, cfg.AsyncSyncInterval)
+	}
+	if cfg.SyncInterval != DEFAULT_SYNC_INTERVAL {
+		t.Errorf("expected SyncInterval=%v, got %v", DEFAULT_SYNC_INTERVAL, cfg.SyncInterval)
+	}
+	if cfg.ReclaimInterval != DEFAULT_RECLAIM_INTERVAL {
+		t.Errorf("expected ReclaimInterval=%v, got %v", DEFAULT_RECLAIM_INTERVAL, cfg.ReclaimInterval)
+	}
+	if cfg.ExpiryInterval != DEFAULT_EXPIRY_INTERVAL {
+		t.Errorf("expected ExpiryInterval=%v, got %v", DEFAULT_EXPIRY_INTERVAL, cfg.ExpiryInterval)
+	}
+	if cfg.ExpiryBatchSize != DEFAULT_EXPIRY_BATCH_SIZE {
+		t.Errorf("expected ExpiryBatchSize=%v, got %v", DEFAULT_EXPIRY_BATCH_SIZE, cfg.ExpiryBatchSize)
+	}
 }
 
 func TestApplyDefaults_DoesNotOverwriteSetValues(t *testing.T) {
@@ -49,6 +75,47 @@ func TestApplyDefaults_DoesNotOverwriteSetValues(t *testing.T) {
 	}
 }
 
+func TestApplyDefaults_SetsLoggerAndMetrics(t *testing.T) {
+	cfg := applyDefaults(DatabaseConfig{})
+	if cfg.Logger == nil {
+		t.Error("expected default Logger to be set")
+	}
+	if cfg.Metrics == nil {
+		t.Error("expected default Metrics to be set")
+	}
+
+	registry := metrics.NewRegistry()
+	cfg = applyDefaults(DatabaseConfig{Metrics: registry})
+	if cfg.Metrics != registry {
+		t.Error("expected custom Metrics to be kept")
+	}
+}
+
+func TestApplyDefaults_KeepsDurabilitySettings(t *testing.T) {
+	cfg := applyDefaults(DatabaseConfig{
+		Durability:        DURABILITY_ASYNC,
+		CommitInterval:    5 * time.Millisecond,
+		CommitBatchSize:   16,
+		AsyncSyncInterval: time.Second,
+		SyncInterval:      2 * time.Second,
+	})
+	if cfg.Durability != DURABILITY_ASYNC {
+		t.Errorf("expected Durability=%d, got %d", DURABILITY_ASYNC, cfg.Durability)
+	}
+	if cfg.CommitInterval != 5*time.Millisecond {
+		t.Errorf("expected CommitInterval=5ms, got %v", cfg.CommitInterval)
+	}
+	if cfg.CommitBatchSize != 16 {
+		t.Errorf("expected CommitBatchSize=16, got %d", cfg.CommitBatchSize)
+	}
+	if cfg.AsyncSyncInterval != time.Second {
+		t.Errorf("expected AsyncSyncInterval=1s, got %v", cfg.AsyncSyncInterval)
+	}
+	if cfg.SyncInterval != 2*time.Second {
+		t.Errorf("expected SyncInterval=2s, got %v", cfg.SyncInterval)
+	}
+}
+
 func TestSetupFS_CreatesDirectories(t *testing.T) {
 	dir := t.TempDir()
 	walDir := filepath.Join(dir, "wal")
@@ -4,11 +4,13 @@ import (
 	"context"
 	"distributed-storage/internal/events"
 	"distributed-storage/internal/helpers"
+	"distributed-storage/internal/metrics"
 	"distributed-storage/internal/pager"
 	"distributed-storage/internal/store"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"log/slog"
 	"slices"
 	"sync"
 	"sync/atomic"
@@ -20,13 +22,40 @@ const INITIAL_DB_VERSION DatabaseVersion = 1
 
 // Bytes
 const NUMBER_OF_PARALLEL_TRANSACTIONS = 1024 // Max number of parallel transactions
-const COMMIT_BATCH_SIZE = 256                // Number of transactions to commit in a single batch
 
-const TRANSACTION_TIMEOUT = 30 * time.Minute
-const COMMIT_INTERVAL = 1 * time.Millisecond
-const SYNC_INTERVAL = 100 * time.Millisecond
+const TRANSACTION_TIMEOUT = 30 * time.Minute // Default timeout for transactions which don't set their own
+
+const (
+	METRIC_COMMIT_BATCH_SIZE      = "db_commit_batch_size"
+	METRIC_COMMIT_DURATION        = "db_commit_duration_seconds"
+	METRIC_COMMITTED_TRANSACTIONS = "db_committed_transactions_total"
+	METRIC_ABORTED_TRANSACTIONS   = "db_aborted_transactions_total"
+	METRIC_ACTIVE_TRANSACTIONS    = "db_active_transactions"
+	METRIC_COMMIT_QUEUE_DEPTH     = "db_commit_queue_depth"
+	METRIC_HOOK_QUEUE_DEPTH       = "db_hook_queue_depth"
+	METRIC_WAL_SYNC_DURATION      = "db_wal_sync_duration_seconds"
+	METRIC_STORAGE_FLUSH_DURATION = "db_storage_flush_duration_seconds"
+	METRIC_PAGES_ALLOCATED        = "db_pages_allocated_total"
+	METRIC_PAGES_REUSED           = "db_pages_reused_total"
+	METRIC_PAGES_RETIRED          = "db_pages_retired_total"
+	METRIC_FILE_SIZE              = "db_file_size_bytes"
+
+	METRIC_LABEL_ABORT_REASON    = "reason"
+	ABORT_REASON_CONFLICT        = "conflict"
+	ABORT_REASON_ERROR           = "error"
+	ABORT_REASON_COMMIT_FAILED   = "commit_failed"
+	ABORT_REASON_WAL_SYNC_FAILED = "wal_sync_failed"
+)
 
 type DatabaseVersion uint64
+type DurabilityMode int32
+
+const (
+	DURABILITY_DEFAULT      DurabilityMode = iota // Transaction inherits durability mode from database config
+	DURABILITY_SYNC                               // Commit is flushed right away and acknowledged after WAL fsync
+	DURABILITY_GROUP_COMMIT                       // Commit waits for a batch of transactions and is acknowledged after WAL fsync of the whole batch
+	DURABILITY_ASYNC                              // Commit is acknowledged before WAL fsync, so it can be lost within AsyncSyncInterval after crash
+)
 
 type DatabaseHeader struct {
 	root        pager.PagePointer
@@ -50,6 +79,12 @@ type Database struct {
 	pagePool     *helpers.MinMap[DatabaseVersion, pager.PageList]
 	transactions *helpers.MinMap[DatabaseVersion, *Transaction]
 	commitQueue  chan TransactionCommit
+	walUnsynced  bool // There are asynchronously acknowledged commits which are not synced to WAL yet
+
+	hooks     *hookRegistry
+	hookQueue *hookQueue // Committed changes waiting for after-commit hooks, in commit order
+
+	activeTransactions atomic.Int64
 
 	mu sync.RWMutex
 }
@@ -61,11 +96,31 @@ type DatabaseConfig struct {
 	WALSegmentSize      int
 	WALDirectory        string
 	WALArchiveDirectory string
+
+	Durability        DurabilityMode // Durability mode of transactions which don't set their own
+	CommitInterval    time.Duration  // Max time a group commit waits for the batch to fill up
+	CommitBatchSize   int            // Max number of transactions committed in a single batch
+	AsyncSyncInterval time.Duration  // Max time asynchronously acknowledged commits can stay not synced to WAL
+	SyncInterval      time.Duration  // Interval of flushing database storage
+	ReclaimInterval   time.Duration  // Interval of freeing pages of dropped tables which aren't visible to transactions
+	ExpiryInterval    time.Duration  // Interval of deleting expired rows of tables with TTL
+	ExpiryBatchSize   int            // Max number of expired rows deleted in a single transaction
+
+	Logger  *slog.Logger    // Logger for background failures which can't be returned to the caller
+	Metrics metrics.Metrics // Receiver of commit pipeline, WAL and pager metrics, e.g. *metrics.Registry
 }
 
 func NewDatabase(config DatabaseConfig) (*Database, error) {
 	config = applyDefaults(config)
 
+	if config.Durability < DURABILITY_SYNC || config.Durability > DURABILITY_ASYNC {
+		return nil, fmt.Errorf("Database: unknown durability mode %d", config.Durability)
+	}
+
+	if describer, ok := config.Metrics.(metrics.Describer); ok { // Batch size is a count, so default duration buckets don't fit it
+		describer.Describe(METRIC_COMMIT_BATCH_SIZE, "Number of transactions in commit batch", metrics.COUNT_BUCKETS...)
+	}
+
 	db := &Database{
 		config: config,
 
@@ -73,6 +128,8 @@ func NewDatabase(config DatabaseConfig) (*Database, error) {
 		transactions: helpers.NewMinMap[DatabaseVersion, *Transaction](func(i, j DatabaseVersion) bool { return i < j }),
 
 		commitQueue: make(chan TransactionCommit, NUMBER_OF_PARALLEL_TRANSACTIONS),
+		hooks:       newHookRegistry(),
+		hookQueue:   newHookQueue(),
 	}
 
 	var err error
@@ -101,19 +158,54 @@ func NewDatabase(config DatabaseConfig) (*Database, error) {
 
 	go db.runCommitLoop()
 	go db.runSyncLoop()
+	go db.runReclaimLoop()
+	go db.runExpiryLoop()
+	go db.runHookLoop()
 
 	return db, nil
 }
 
-func (db *Database) StartTransaction(request func(*Transaction)) error {
-	ctx, cancel := context.WithTimeout(context.Background(), TRANSACTION_TIMEOUT)
-	defer cancel()
+func (db *Database) Begin(ctx context.Context, options TransactionOptions) (*Transaction, error) {
+	if options.Isolation != ISOLATION_SNAPSHOT && options.Isolation != ISOLATION_SERIALIZABLE {
+		return nil, fmt.Errorf("Database: couldn't begin transaction because of unknown isolation level %d", options.Isolation)
+	}
+
+	if options.Durability < DURABILITY_DEFAULT || options.Durability > DURABILITY_ASYNC {
+		return nil, fmt.Errorf("Database: couldn't begin transaction because of unknown durability mode %d", options.Durability)
+	}
+
+	if options.Timeout == 0 {
+		options.Timeout = TRANSACTION_TIMEOUT
+	}
 
-	transaction, err := db.createTransaction(ctx)
+	if options.Durability == DURABILITY_DEFAULT {
+		options.Durability = db.config.Durability
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, options.Timeout)
+
+	transaction, err := db.createTransaction(ctx, cancel, options)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("Database: couldn't begin transaction: %w", err)
+	}
+
+	return transaction, nil
+}
+
+func (db *Database) StartTransaction(request func(*Transaction)) error {
+	transaction, err := db.Begin(context.Background(), TransactionOptions{})
 	if err != nil {
 		return err
 	}
 
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			transaction.Rollback()
+			panic(recovered)
+		}
+	}()
+
 	request(transaction)
 
 	if err := transaction.Commit(); err != nil {
@@ -123,78 +215,210 @@ func (db *Database) StartTransaction(request func(*Transaction)) error {
 	return nil
 }
 
+// RunInTransaction executes request in a new transaction and commits it. If transaction is aborted because of
+// conflict with concurrent transaction, request is re-executed on a fresh snapshot until retry policy gives up.
+func (db *Database) RunInTransaction(ctx context.Context, request func(*Transaction) error, policy RetryPolicy, options ...TransactionOptions) error {
+	policy = applyRetryPolicyDefaults(policy)
+
+	var transactionOptions TransactionOptions
+	if len(options) > 0 {
+		transactionOptions = options[0]
+	}
+
+	// Deadline bounds retrying only, attempts run with the caller context so an attempt isn't cut off by the deadline
+	retryCtx, cancel := context.WithTimeout(ctx, policy.Deadline)
+	defer cancel()
+
+	for attempt := 1; ; attempt++ {
+		err := db.runTransaction(ctx, request, transactionOptions)
+		if err == nil || !IsConflict(err) {
+			return err
+		}
+
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return fmt.Errorf("Database: transaction failed after %d attempts: %w", attempt, err)
+		}
+
+		timer := time.NewTimer(policy.backoff(attempt))
+
+		select {
+		case <-timer.C:
+		case <-retryCtx.Done():
+			timer.Stop()
+			return fmt.Errorf("Database: transaction retry deadline exceeded after %d attempts: %w", attempt, err)
+		}
+	}
+}
+
+func (db *Database) runTransaction(ctx context.Context, request func(*Transaction) error, options TransactionOptions) error {
+	transaction, err := db.Begin(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			transaction.Rollback()
+			panic(recovered)
+		}
+	}()
+
+	if err := request(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	return transaction.Commit()
+}
+
 func (db *Database) runCommitLoop() {
-	transactions := make([]TransactionCommit, 0, COMMIT_BATCH_SIZE)
-	ticker := time.NewTicker(COMMIT_INTERVAL)
+	transactions := make([]TransactionCommit, 0, db.config.CommitBatchSize)
+	ticker := time.NewTicker(db.config.CommitInterval)
+	walTicker := time.NewTicker(db.config.AsyncSyncInterval)
 
 	for {
 		select {
 		case commit := <-db.commitQueue:
 			transactions = append(transactions, commit)
 
-			if len(transactions) == COMMIT_BATCH_SIZE {
+			// Synchronous commit doesn't wait for the batch to fill up and takes already collected transactions along
+			if len(transactions) == db.config.CommitBatchSize || commit.Durability == DURABILITY_SYNC {
 				ticker.Stop()
 				db.commitBatch(transactions)
-				transactions = make([]TransactionCommit, 0, COMMIT_BATCH_SIZE)
-				ticker.Reset(COMMIT_INTERVAL)
+				transactions = make([]TransactionCommit, 0, db.config.CommitBatchSize)
+				ticker.Reset(db.config.CommitInterval)
 			}
 
 		case <-ticker.C:
 			if len(transactions) > 0 {
 				ticker.Stop()
 				db.commitBatch(transactions)
-				transactions = make([]TransactionCommit, 0, COMMIT_BATCH_SIZE)
-				ticker.Reset(COMMIT_INTERVAL)
+				transactions = make([]TransactionCommit, 0, db.config.CommitBatchSize)
+				ticker.Reset(db.config.CommitInterval)
+			}
+
+		case <-walTicker.C:
+			if db.walUnsynced {
+				if err := db.syncWAL(); err != nil {
+					db.config.Logger.Error("Database: failed to sync WAL, later commits are rejected", "error", err)
+				} else {
+					db.walUnsynced = false
+				}
 			}
 		}
 	}
 }
 
 func (db *Database) runSyncLoop() {
-	ticker := time.NewTicker(SYNC_INTERVAL)
+	ticker := time.NewTicker(db.config.SyncInterval)
 
 	for range ticker.C {
 		db.mu.Lock()
 
 		ticker.Stop()
 
-		if err := db.storage.Flush(); err != nil {
-			fmt.Printf("Database: failed to flush storage: %s\n", err)
+		// Storage must never be ahead of WAL, otherwise asynchronously acknowledged commits couldn't be recovered
+		if err := db.syncWAL(); err != nil {
+			db.config.Logger.Error("Database: failed to sync WAL, later commits are rejected", "error", err)
+		} else if err := db.flushStorage(); err != nil {
+			db.config.Logger.Error("Database: failed to flush storage", "error", err)
 		} else {
 			db.syncedVersion = db.header.version
 		}
 
 		db.mu.Unlock()
 
-		ticker.Reset(SYNC_INTERVAL)
+		ticker.Reset(db.config.SyncInterval)
+	}
+}
+
+func (db *Database) runReclaimLoop() {
+	ticker := time.NewTicker(db.config.ReclaimInterval)
+
+	for range ticker.C {
+		if err := db.reclaimDroppedTables(); err != nil {
+			db.config.Logger.Error("Database: failed to reclaim dropped tables", "error", err)
+		}
+	}
+}
+
+// reclaimDroppedTables commits events freeing pages of dropped tables and purging their catalog entries once no
+// active transaction can see them. Every step is written to WAL and recorded in the catalog state of the table,
+// so reclaim resumes from the last committed step after crash.
+func (db *Database) reclaimDroppedTables() error {
+	unreachableVersion := db.latestUnreachableVersion()
+
+	tx, err := db.Begin(context.Background(), TransactionOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+
+	reclaimEvents, err := tx.manager.ReclaimEvents(unreachableVersion)
+	tx.Rollback()
+
+	if err != nil || len(reclaimEvents) == 0 {
+		return err
+	}
+
+	responseChannel := make(chan TransactionCommitResponse, 1)
+	db.commitQueue <- TransactionCommit{ChangeEvents: reclaimEvents, Durability: db.config.Durability, Response: responseChannel}
+
+	if response := <-responseChannel; !response.Success {
+		return response.Error
 	}
+
+	return nil
 }
 
 func (db *Database) commitBatch(transactions []TransactionCommit) {
+	startedAt := time.Now()
+	db.config.Metrics.ObserveHistogram(METRIC_COMMIT_BATCH_SIZE, float64(len(transactions)))
+	db.config.Metrics.SetGauge(METRIC_COMMIT_QUEUE_DEPTH, float64(len(db.commitQueue)))
+
+	if err := db.wal.failure(); err != nil { // Commits acknowledged asynchronously could be lost, so nothing is accepted
+		db.rejectTransactions(transactions, fmt.Errorf("Database: WAL failed: %w", err))
+		db.recordAborts(ABORT_REASON_WAL_SYNC_FAILED, len(transactions))
+		return
+	}
+
 	latestUnreachableVersion := db.latestUnreachableVersion()
 	manager := db.tableManager(db.collectReleasedPages(latestUnreachableVersion))
 
 	var abortedTransactions []TransactionCommit
+	var abortReasons []error
 	var approvedTransactions []TransactionCommit
+	var committedChanges [][]CommittedChange
 
-	var (
-		applyResult ApplyResult
-		err         error
-	)
 	for _, transaction := range transactions {
-		if applyResult, err = manager.ApplyChangeEvents(transaction.ChangeEvents); err != nil {
+		if err := manager.ValidateReadEvents(transaction.ReadEvents); err != nil {
+			abortedTransactions = append(abortedTransactions, transaction)
+			abortReasons = append(abortReasons, err)
+		} else if err := manager.ValidateChangeEvents(transaction.ChangeEvents); err != nil {
 			abortedTransactions = append(abortedTransactions, transaction)
+			abortReasons = append(abortReasons, err)
+		} else if _, err := manager.ApplyChangeEvents(transaction.ChangeEvents); err != nil {
+			abortedTransactions = append(abortedTransactions, transaction)
+			abortReasons = append(abortReasons, err)
 		} else {
 			approvedTransactions = append(approvedTransactions, transaction)
+
+			changes, err := manager.committedChanges(transaction.ChangeEvents)
+			if err != nil {
+				db.config.Logger.Error("Database: failed to collect changes for after-commit hooks", "error", err)
+			}
+			committedChanges = append(committedChanges, changes)
 		}
 	}
 
-	retiredPages := applyResult.PageChanges.RetiredPages
-	reusablePages := applyResult.PageChanges.ReusablePages
-	pagesCount := applyResult.PageChanges.PagesCount
+	// Taken from the manager, as the batch can end with aborted transactions or consist of them only
+	root, pageChanges := manager.pageChanges()
+
+	retiredPages := pageChanges.RetiredPages
+	reusablePages := pageChanges.ReusablePages
+	pagesCount := pageChanges.PagesCount
 
 	newHeader := &DatabaseHeader{
-		root:        applyResult.Root,
+		root:        root,
 		version:     db.header.version + 1,
 		tablesCount: db.nextTableID.Load(),
 		pagesCount:  pagesCount,
@@ -202,6 +426,7 @@ func (db *Database) commitBatch(transactions []TransactionCommit) {
 
 	if err := manager.Commit(db.serializeHeader(newHeader)); err != nil {
 		db.rejectTransactions(transactions, fmt.Errorf("Database: failed to commit changes: %w", err))
+		db.recordAborts(ABORT_REASON_COMMIT_FAILED, len(transactions))
 		return
 	}
 
@@ -211,20 +436,90 @@ func (db *Database) commitBatch(transactions []TransactionCommit) {
 	db.wal.appendFreePages(latestUnreachableVersion, reusablePages) // These pages can be reused because they are not used by any active transaction (e.g. they were allocated and released in the same version)
 	db.wal.appendFreePages(db.header.version, retiredPages)         // These pages will be ready to safely reused only since next db version because they can be still used by active transactions in the current version
 
-	if err := db.wal.sync(); err != nil {
-		db.rejectTransactions(transactions, fmt.Errorf("Database: WAL flush failed: %w", err))
-		return
+	if db.requiresSync(approvedTransactions) {
+		if err := db.syncWAL(); err != nil {
+			db.rejectTransactions(transactions, fmt.Errorf("Database: WAL flush failed: %w", err))
+			db.recordAborts(ABORT_REASON_WAL_SYNC_FAILED, len(transactions))
+			return
+		}
+
+		db.walUnsynced = false
+	} else {
+		db.walUnsynced = true
 	}
 
 	db.approveTransactions(approvedTransactions)
-	db.rejectTransactions(abortedTransactions, errors.New("Database: transaction aborted due to conflicts with other transactions"))
+	for idx, transaction := range abortedTransactions {
+		db.rejectTransactions([]TransactionCommit{transaction}, fmt.Errorf("Database: transaction aborted: %w", abortReasons[idx]))
+		db.recordAborts(abortReason(abortReasons[idx]), 1)
+	}
 
 	db.releasePages(latestUnreachableVersion, reusablePages)
 	db.releasePages(db.header.version, retiredPages)
 
 	db.mu.Lock()
-	defer db.mu.Unlock()
 	db.header = newHeader
+	db.mu.Unlock()
+
+	pageStats := pageChanges.Stats
+	db.config.Metrics.AddCounter(METRIC_COMMITTED_TRANSACTIONS, float64(len(approvedTransactions)))
+	db.config.Metrics.AddCounter(METRIC_PAGES_ALLOCATED, float64(pageStats.AllocatedPages))
+	db.config.Metrics.AddCounter(METRIC_PAGES_REUSED, float64(pageStats.ReusedPages))
+	db.config.Metrics.AddCounter(METRIC_PAGES_RETIRED, float64(pageStats.RetiredPages))
+	db.config.Metrics.SetGauge(METRIC_FILE_SIZE, float64(db.storage.Size()))
+	db.config.Metrics.ObserveHistogram(METRIC_COMMIT_DURATION, time.Since(startedAt).Seconds())
+
+	// Hooks are queued after the header update, so transactions they begin see the committed changes
+	for _, changes := range committedChanges {
+		if len(changes) > 0 {
+			db.hookQueue.push(committedTransaction{version: newHeader.version, changes: changes})
+		}
+	}
+	db.config.Metrics.SetGauge(METRIC_HOOK_QUEUE_DEPTH, float64(db.hookQueue.len()))
+}
+
+func (db *Database) syncWAL() error {
+	startedAt := time.Now()
+	if err := db.wal.sync(); err != nil {
+		return err
+	}
+
+	db.config.Metrics.ObserveHistogram(METRIC_WAL_SYNC_DURATION, time.Since(startedAt).Seconds())
+	return nil
+}
+
+func (db *Database) flushStorage() error {
+	startedAt := time.Now()
+	if err := db.storage.Flush(); err != nil {
+		return err
+	}
+
+	db.config.Metrics.ObserveHistogram(METRIC_STORAGE_FLUSH_DURATION, time.Since(startedAt).Seconds())
+	return nil
+}
+
+func (db *Database) recordAborts(reason string, count int) {
+	db.config.Metrics.AddCounter(METRIC_ABORTED_TRANSACTIONS, float64(count), metrics.Label{Name: METRIC_LABEL_ABORT_REASON, Value: reason})
+}
+
+// abortReason classifies transaction abort error into a low cardinality metric label
+func abortReason(err error) string {
+	if IsConflict(err) {
+		return ABORT_REASON_CONFLICT
+	}
+
+	return ABORT_REASON_ERROR
+}
+
+// requiresSync reports whether any of transactions must be synced to WAL before acknowledgement
+func (db *Database) requiresSync(transactions []TransactionCommit) bool {
+	for _, transaction := range transactions {
+		if transaction.Durability != DURABILITY_ASYNC {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (db *Database) rejectTransactions(transactions []TransactionCommit, err error) {
@@ -245,24 +540,40 @@ func (db *Database) approveTransactions(transactions []TransactionCommit) {
 	}
 }
 
-func (db *Database) createTransaction(ctx context.Context) (*Transaction, error) {
+func (db *Database) createTransaction(ctx context.Context, cancel context.CancelFunc, options TransactionOptions) (*Transaction, error) {
 	manager := db.tableManager()
+	manager.readOnly = options.ReadOnly
+	manager.trackReads = options.Isolation == ISOLATION_SERIALIZABLE
 
 	tx := &Transaction{
+		options:     options,
 		manager:     manager,
 		commitQueue: db.commitQueue,
 		ctx:         ctx,
+		cancel:      cancel,
 	}
 
+	tx.release = func() { db.releaseTransaction(manager.state.Version, tx) }
 	tx.state.Store(int32(TRANSACTION_PROCESSING))
 
 	db.mu.Lock()
 	db.transactions.Add(manager.state.Version, tx)
 	db.mu.Unlock()
 
+	db.config.Metrics.SetGauge(METRIC_ACTIVE_TRANSACTIONS, float64(db.activeTransactions.Add(1)))
+
 	return tx, nil
 }
 
+func (db *Database) releaseTransaction(version DatabaseVersion, tx *Transaction) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.transactions.RemoveFunc(version, func(transaction *Transaction) bool { return transaction == tx }) {
+		db.config.Metrics.SetGauge(METRIC_ACTIVE_TRANSACTIONS, float64(db.activeTransactions.Add(-1)))
+	}
+}
+
 func (db *Database) collectReleasedPages(target DatabaseVersion) pager.PageList {
 	pageList := pager.NewPageList()
 
@@ -417,11 +728,14 @@ func (db *Database) tableManager(freePages ...pager.PageList) *TableManager {
 
 	state := TableManagerState{Root: db.header.root, Version: db.header.version}
 
-	return newTableManager(
+	manager := newTableManager(
 		state,
 		func() TableID { return TableID(db.nextTableID.Add(1) - 1) },
 		db.pager.Fork(db.header.pagesCount, freePages...),
 	)
+	manager.hooks = db.hooks
+
+	return manager
 }
 
 func (db *Database) empty() bool {
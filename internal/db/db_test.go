@@ -1,9 +1,16 @@
 package db
 
 import (
-	"distributed-storage/internal/vals"
+	"bytes"
+	"context"
+	"distributed-storage/internal/metrics"
+	"distributed-storage/internal/primitive"
+	"distributed-storage/internal/wal"
+	"errors"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func newTestDatabaseConfig(t *testing.T) DatabaseConfig {
@@ -68,8 +75,8 @@ func TestDatabase_StartTransaction_CreateTable(t *testing.T) {
 	schema := &TableSchema{
 		Name:         "users",
 		PrimaryIndex: []string{"id"},
-		IndexedColumns: map[string]vals.ValueType{
-			"id": vals.TYPE_UINT64,
+		IndexedColumns: map[string]primitive.PrimitiveType{
+			"id": primitive.TYPE_UINT64,
 		},
 	}
 	if err := db.StartTransaction(func(tx *Transaction) {
@@ -90,8 +97,8 @@ func TestDatabase_StartTransaction_TablePersistsAcrossTransactions(t *testing.T)
 	schema := &TableSchema{
 		Name:         "users",
 		PrimaryIndex: []string{"id"},
-		IndexedColumns: map[string]vals.ValueType{
-			"id": vals.TYPE_UINT64,
+		IndexedColumns: map[string]primitive.PrimitiveType{
+			"id": primitive.TYPE_UINT64,
 		},
 	}
 
@@ -128,8 +135,8 @@ func TestDatabase_StartTransaction_InsertAndFind(t *testing.T) {
 	schema := &TableSchema{
 		Name:         "records",
 		PrimaryIndex: []string{"id"},
-		IndexedColumns: map[string]vals.ValueType{
-			"id": vals.TYPE_UINT64,
+		IndexedColumns: map[string]primitive.PrimitiveType{
+			"id": primitive.TYPE_UINT64,
 		},
 	}
 
@@ -147,8 +154,8 @@ func TestDatabase_StartTransaction_InsertAndFind(t *testing.T) {
 			t.Errorf("Table failed: err=%v", err)
 			return
 		}
-		record := vals.NewObject().Set("id", vals.NewUint64(42))
-		if err := table.Insert(record); err != nil {
+		record := primitive.NewObject().Set("id", primitive.NewUint64(42))
+		if _, err := table.Insert(record); err != nil {
 			t.Errorf("Insert failed: %v", err)
 		}
 	}); err != nil {
@@ -161,7 +168,7 @@ func TestDatabase_StartTransaction_InsertAndFind(t *testing.T) {
 			t.Errorf("Table failed: err=%v", err)
 			return
 		}
-		record, err := table.Get(vals.NewObject().Set("id", vals.NewUint64(42)))
+		record, err := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(42)))
 		if err != nil {
 			t.Errorf("Get failed: %v", err)
 			return
@@ -174,6 +181,553 @@ func TestDatabase_StartTransaction_InsertAndFind(t *testing.T) {
 	}
 }
 
+func newTestDatabaseWithRecords(t *testing.T) *Database {
+	t.Helper()
+	db, err := NewDatabase(newTestDatabaseConfig(t))
+	if err != nil {
+		t.Fatalf("NewDatabase failed: %v", err)
+	}
+	if err := db.StartTransaction(func(tx *Transaction) {
+		if _, err := tx.CreateTable(basicSchema()); err != nil {
+			t.Errorf("CreateTable failed: %v", err)
+		}
+	}); err != nil {
+		t.Fatalf("create table tx failed: %v", err)
+	}
+	if err := db.StartTransaction(func(tx *Transaction) {
+		table, _ := tx.Table("users")
+		if _, err := table.Insert(userRecord(1, "alice")); err != nil {
+			t.Errorf("Insert failed: %v", err)
+		}
+	}); err != nil {
+		t.Fatalf("insert tx failed: %v", err)
+	}
+	return db
+}
+
+func TestDatabase_Begin_CommitMakesChangesVisible(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	tx, err := db.Begin(context.Background(), TransactionOptions{})
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	table, _ := tx.Table("users")
+	if _, err := table.Insert(userRecord(2, "bob")); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	reader, _ := db.Begin(context.Background(), TransactionOptions{ReadOnly: true})
+	defer reader.Rollback()
+	table, _ = reader.Table("users")
+	record, err := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(2)))
+	if err != nil || record == nil {
+		t.Errorf("expected committed record to be visible, err=%v", err)
+	}
+}
+
+func TestDatabase_Begin_RollbackDiscardsChanges(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	tx, _ := db.Begin(context.Background(), TransactionOptions{})
+	table, _ := tx.Table("users")
+	if _, err := table.Insert(userRecord(2, "bob")); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if len(tx.manager.ChangeEvents()) != 0 {
+		t.Error("expected change events to be discarded after Rollback")
+	}
+	if err := tx.Commit(); err == nil {
+		t.Error("expected Commit after Rollback to fail")
+	}
+
+	reader, _ := db.Begin(context.Background(), TransactionOptions{ReadOnly: true})
+	defer reader.Rollback()
+	table, _ = reader.Table("users")
+	record, _ := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(2)))
+	if record != nil {
+		t.Error("expected rolled back record to be invisible")
+	}
+}
+
+func TestDatabase_Begin_RollbackReleasesSnapshot(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	tx, _ := db.Begin(context.Background(), TransactionOptions{})
+	if db.transactions.Len() != 1 {
+		t.Fatalf("expected 1 registered transaction, got %d", db.transactions.Len())
+	}
+	tx.Rollback()
+	if db.transactions.Len() != 0 {
+		t.Errorf("expected snapshot to be released after Rollback, got %d transactions", db.transactions.Len())
+	}
+}
+
+func TestDatabase_Begin_ReadOnlyRejectsWrites(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	tx, _ := db.Begin(context.Background(), TransactionOptions{ReadOnly: true})
+	defer tx.Rollback()
+
+	table, _ := tx.Table("users")
+	if _, err := table.Insert(userRecord(2, "bob")); err == nil {
+		t.Error("expected Insert to fail in read-only transaction")
+	}
+	if _, err := table.Delete(userRecord(1, "alice")); err == nil {
+		t.Error("expected Delete to fail in read-only transaction")
+	}
+	if _, err := tx.CreateTable(schemaWithSecondaryIndex()); err == nil {
+		t.Error("expected CreateTable to fail in read-only transaction")
+	}
+}
+
+func TestDatabase_Begin_TimeoutAbortsCommit(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	tx, _ := db.Begin(context.Background(), TransactionOptions{Timeout: time.Millisecond})
+	table, _ := tx.Table("users")
+	table.Insert(userRecord(2, "bob"))
+
+	time.Sleep(5 * time.Millisecond)
+
+	if err := tx.Commit(); err == nil {
+		t.Error("expected Commit to fail after transaction timeout")
+	}
+}
+
+func TestDatabase_Begin_UnknownIsolationLevel_ReturnsError(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	if _, err := db.Begin(context.Background(), TransactionOptions{Isolation: IsolationLevel(42)}); err == nil {
+		t.Error("expected error for unknown isolation level")
+	}
+}
+
+func TestDatabase_Begin_SerializableAbortsOnChangedRead(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	reader, _ := db.Begin(context.Background(), TransactionOptions{Isolation: ISOLATION_SERIALIZABLE})
+	readerTable, _ := reader.Table("users")
+	if record, _ := readerTable.Get(primitive.NewObject().Set("id", primitive.NewUint64(1))); record == nil {
+		t.Fatal("expected record to be found")
+	}
+
+	writer, _ := db.Begin(context.Background(), TransactionOptions{})
+	writerTable, _ := writer.Table("users")
+	if _, err := writerTable.Update(userRecord(1, "alicia")); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := writer.Commit(); err != nil {
+		t.Fatalf("writer Commit failed: %v", err)
+	}
+
+	if _, err := readerTable.Insert(userRecord(2, "bob")); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if err := reader.Commit(); err == nil {
+		t.Error("expected serializable transaction to abort because its read was changed")
+	}
+}
+
+func TestDatabase_Begin_SerializableAbortsOnPhantom(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+	scan := Gt("id", primitive.NewUint64(5))
+
+	for _, insertedID := range []uint64{6, 3} {
+		reader, _ := db.Begin(context.Background(), TransactionOptions{Isolation: ISOLATION_SERIALIZABLE})
+		readerTable, _ := reader.Table("users")
+		if records, _ := readerTable.FindWhere(scan); insertedID == 6 && len(records) != 0 {
+			t.Fatalf("expected empty scan, got %v", records)
+		}
+
+		writer, _ := db.Begin(context.Background(), TransactionOptions{})
+		writerTable, _ := writer.Table("users")
+		writerTable.Insert(userRecord(insertedID, "phantom"))
+		if err := writer.Commit(); err != nil {
+			t.Fatalf("writer Commit failed: %v", err)
+		}
+
+		readerTable.Insert(userRecord(insertedID+100, "reader"))
+		err := reader.Commit()
+		if insertedID == 6 && !IsConflict(err) {
+			t.Errorf("expected conflict for row inserted into scanned range, got %v", err)
+		}
+		if insertedID == 3 && err != nil {
+			t.Errorf("expected row inserted outside of scanned range not to conflict, got %v", err)
+		}
+	}
+}
+
+func TestDatabase_Begin_SerializableReadsOwnWrites(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	tx, _ := db.Begin(context.Background(), TransactionOptions{Isolation: ISOLATION_SERIALIZABLE})
+	table, _ := tx.Table("users")
+	if _, err := table.Insert(userRecord(7, "grace")); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if _, err := table.Update(userRecord(1, "alicia")); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	if record, _ := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(7))); record == nil {
+		t.Fatal("expected inserted record to be found")
+	}
+	if record, _ := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(1))); record.GetString("name") != "alicia" {
+		t.Fatalf("expected updated record, got %v", record)
+	}
+	if records, _ := table.FindWhere(Gt("id", primitive.NewUint64(0))); len(records) == 0 {
+		t.Fatal("expected scan to find records")
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Errorf("expected transaction reading its own writes to commit, got %v", err)
+	}
+}
+
+func TestDatabase_Begin_SnapshotIgnoresChangedRead(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	reader, _ := db.Begin(context.Background(), TransactionOptions{})
+	readerTable, _ := reader.Table("users")
+	readerTable.Get(primitive.NewObject().Set("id", primitive.NewUint64(1)))
+
+	writer, _ := db.Begin(context.Background(), TransactionOptions{})
+	writerTable, _ := writer.Table("users")
+	writerTable.Update(userRecord(1, "alicia"))
+	if err := writer.Commit(); err != nil {
+		t.Fatalf("writer Commit failed: %v", err)
+	}
+
+	readerTable.Insert(userRecord(2, "bob"))
+	if err := reader.Commit(); err != nil {
+		t.Errorf("expected snapshot transaction to commit, got %v", err)
+	}
+}
+
+func TestDatabase_Commit_ConflictReturnsConflictError(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	first, _ := db.Begin(context.Background(), TransactionOptions{})
+	second, _ := db.Begin(context.Background(), TransactionOptions{})
+
+	firstTable, _ := first.Table("users")
+	secondTable, _ := second.Table("users")
+	firstTable.Update(userRecord(1, "alicia"))
+	secondTable.Update(userRecord(1, "alison"))
+
+	if err := first.Commit(); err != nil {
+		t.Fatalf("first Commit failed: %v", err)
+	}
+
+	err := second.Commit()
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected ConflictError, got %v", err)
+	}
+	if conflict.Table != "users" {
+		t.Errorf("expected conflict in table 'users', got %q", conflict.Table)
+	}
+}
+
+func TestDatabase_Commit_RecordsMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	cfg := newTestDatabaseConfig(t)
+	cfg.Metrics = registry
+	db, err := NewDatabase(cfg)
+	if err != nil {
+		t.Fatalf("NewDatabase failed: %v", err)
+	}
+
+	if err := db.StartTransaction(func(tx *Transaction) { tx.CreateTable(basicSchema()) }); err != nil {
+		t.Fatalf("StartTransaction failed: %v", err)
+	}
+
+	first, _ := db.Begin(context.Background(), TransactionOptions{})
+	second, _ := db.Begin(context.Background(), TransactionOptions{})
+	firstTable, _ := first.Table("users")
+	secondTable, _ := second.Table("users")
+	firstTable.Insert(userRecord(1, "alice"))
+	secondTable.Insert(userRecord(1, "alison"))
+	first.Commit()
+	second.Commit()
+
+	var output bytes.Buffer
+	if err := registry.Write(&output); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		METRIC_COMMITTED_TRANSACTIONS + " 2",
+		METRIC_ABORTED_TRANSACTIONS + `{reason="conflict"} 1`,
+		METRIC_ACTIVE_TRANSACTIONS + " 0",
+		METRIC_COMMIT_DURATION + "_count 3",
+		METRIC_WAL_SYNC_DURATION + "_count",
+		METRIC_COMMIT_BATCH_SIZE + `_bucket{le="2"}`,
+		wal.METRIC_APPENDED_BYTES,
+		METRIC_PAGES_ALLOCATED,
+		METRIC_FILE_SIZE,
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("expected metrics output to contain %q, got:\n%s", expected, output.String())
+		}
+	}
+}
+
+func TestDatabase_SequenceKeys_PersistedInCatalog(t *testing.T) {
+	db, err := NewDatabase(newTestDatabaseConfig(t))
+	if err != nil {
+		t.Fatalf("NewDatabase failed: %v", err)
+	}
+	db.StartTransaction(func(tx *Transaction) { tx.CreateTable(sequenceSchema()) })
+
+	for expected := uint64(1); expected <= 2; expected++ {
+		var key *primitive.Object
+		err := db.StartTransaction(func(tx *Transaction) {
+			table, _ := tx.Table("invoices")
+			key, _ = table.Insert(primitive.NewObject().Set("amount", primitive.NewUint32(10)))
+		})
+		if err != nil {
+			t.Fatalf("StartTransaction failed: %v", err)
+		}
+		if key.GetUint64("id") != expected {
+			t.Errorf("expected key %d, got %d", expected, key.GetUint64("id"))
+		}
+	}
+}
+
+func TestDatabase_SequenceKeys_ConcurrentAllocationConflicts(t *testing.T) {
+	db, err := NewDatabase(newTestDatabaseConfig(t))
+	if err != nil {
+		t.Fatalf("NewDatabase failed: %v", err)
+	}
+	db.StartTransaction(func(tx *Transaction) { tx.CreateTable(sequenceSchema()) })
+
+	first, _ := db.Begin(context.Background(), TransactionOptions{})
+	second, _ := db.Begin(context.Background(), TransactionOptions{})
+	firstTable, _ := first.Table("invoices")
+	secondTable, _ := second.Table("invoices")
+	firstTable.Insert(primitive.NewObject().Set("amount", primitive.NewUint32(10)))
+	secondTable.Insert(primitive.NewObject().Set("amount", primitive.NewUint32(20)))
+
+	if err := first.Commit(); err != nil {
+		t.Fatalf("first Commit failed: %v", err)
+	}
+	if err := second.Commit(); !IsConflict(err) {
+		t.Errorf("expected conflict for concurrent sequence allocation, got %v", err)
+	}
+}
+
+func TestDatabase_RunInTransaction_RetriesOnConflict(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	attempts := 0
+	err := db.RunInTransaction(context.Background(), func(tx *Transaction) error {
+		attempts++
+		table, _ := tx.Table("users")
+		if _, err := table.Update(userRecord(1, "alicia")); err != nil {
+			return err
+		}
+
+		if attempts == 1 { // Commit concurrent change to the same row, so the first attempt conflicts
+			return db.StartTransaction(func(concurrent *Transaction) {
+				concurrentTable, _ := concurrent.Table("users")
+				concurrentTable.Update(userRecord(1, "alison"))
+			})
+		}
+
+		return nil
+	}, RetryPolicy{})
+
+	if err != nil {
+		t.Fatalf("RunInTransaction failed: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestDatabase_RunInTransaction_DoesNotRetryOtherErrors(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+	requestErr := errors.New("validation failed")
+
+	attempts := 0
+	err := db.RunInTransaction(context.Background(), func(tx *Transaction) error {
+		attempts++
+		return requestErr
+	}, RetryPolicy{})
+
+	if !errors.Is(err, requestErr) {
+		t.Errorf("expected request error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestDatabase_RunInTransaction_GivesUpAfterMaxAttempts(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	attempts := 0
+	err := db.RunInTransaction(context.Background(), func(tx *Transaction) error {
+		attempts++
+		return &ConflictError{Table: "users", Reason: "always conflicts"}
+	}, RetryPolicy{MaxAttempts: 3})
+
+	if !IsConflict(err) {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestDatabase_RunInTransaction_GivesUpAfterDeadline(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	err := db.RunInTransaction(context.Background(), func(tx *Transaction) error {
+		return &ConflictError{Table: "users", Reason: "always conflicts"}
+	}, RetryPolicy{Deadline: 20 * time.Millisecond})
+
+	if !IsConflict(err) {
+		t.Errorf("expected conflict error after deadline, got %v", err)
+	}
+}
+
+func TestDatabase_RunInTransaction_DeadlineDoesntCancelAttempt(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	err := db.RunInTransaction(context.Background(), func(tx *Transaction) error {
+		time.Sleep(30 * time.Millisecond)
+		table, _ := tx.Table("users")
+		_, err := table.Insert(userRecord(2, "bob"))
+		return err
+	}, RetryPolicy{Deadline: 10 * time.Millisecond})
+
+	if err != nil {
+		t.Errorf("expected attempt running past the retry deadline to commit, got %v", err)
+	}
+}
+
+func TestDatabase_Commit_AfterRollbackToSavepoint(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	tx, _ := db.Begin(context.Background(), TransactionOptions{})
+	table, _ := tx.Table("users")
+	table.Insert(userRecord(2, "bob"))
+	tx.Savepoint("batch")
+	table.Insert(userRecord(3, "carol"))
+	if err := tx.RollbackTo("batch"); err != nil {
+		t.Fatalf("RollbackTo failed: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	reader, _ := db.Begin(context.Background(), TransactionOptions{ReadOnly: true})
+	defer reader.Rollback()
+	table, _ = reader.Table("users")
+	if records, _ := table.GetAll(); len(records) != 2 {
+		t.Errorf("expected 2 committed records, got %d", len(records))
+	}
+}
+
+func TestNewDatabase_UnknownDurability_ReturnsError(t *testing.T) {
+	cfg := newTestDatabaseConfig(t)
+	cfg.Durability = DurabilityMode(42)
+	if _, err := NewDatabase(cfg); err == nil {
+		t.Error("expected error for unknown durability mode")
+	}
+}
+
+func TestDatabase_Begin_InheritsDurabilityFromConfig(t *testing.T) {
+	cfg := newTestDatabaseConfig(t)
+	cfg.Durability = DURABILITY_ASYNC
+	db, err := NewDatabase(cfg)
+	if err != nil {
+		t.Fatalf("NewDatabase failed: %v", err)
+	}
+
+	tx, _ := db.Begin(context.Background(), TransactionOptions{})
+	defer tx.Rollback()
+	if tx.Options().Durability != DURABILITY_ASYNC {
+		t.Errorf("expected DURABILITY_ASYNC, got %d", tx.Options().Durability)
+	}
+
+	tx, _ = db.Begin(context.Background(), TransactionOptions{Durability: DURABILITY_SYNC})
+	defer tx.Rollback()
+	if tx.Options().Durability != DURABILITY_SYNC {
+		t.Errorf("expected transaction to override durability with DURABILITY_SYNC, got %d", tx.Options().Durability)
+	}
+}
+
+func TestDatabase_Commit_AllDurabilityModes(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	for id, durability := range []DurabilityMode{DURABILITY_SYNC, DURABILITY_GROUP_COMMIT, DURABILITY_ASYNC} {
+		tx, err := db.Begin(context.Background(), TransactionOptions{Durability: durability})
+		if err != nil {
+			t.Fatalf("Begin failed: %v", err)
+		}
+		table, _ := tx.Table("users")
+		table.Insert(userRecord(uint64(id+10), "user"))
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("Commit with durability %d failed: %v", durability, err)
+		}
+	}
+
+	reader, _ := db.Begin(context.Background(), TransactionOptions{ReadOnly: true})
+	defer reader.Rollback()
+	table, _ := reader.Table("users")
+	if records, _ := table.GetAll(); len(records) != 4 {
+		t.Errorf("expected 4 records, got %d", len(records))
+	}
+}
+
+func TestDatabase_Commit_SyncDoesNotWaitForCommitInterval(t *testing.T) {
+	cfg := newTestDatabaseConfig(t)
+	cfg.CommitInterval = time.Hour
+	db, err := NewDatabase(cfg)
+	if err != nil {
+		t.Fatalf("NewDatabase failed: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		tx, _ := db.Begin(context.Background(), TransactionOptions{Durability: DURABILITY_SYNC})
+		tx.CreateTable(basicSchema())
+		done <- tx.Commit()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Commit failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected synchronous commit to be flushed without waiting for commit interval")
+	}
+}
+
+func TestDatabase_RequiresSync(t *testing.T) {
+	db := &Database{}
+	if db.requiresSync([]TransactionCommit{{Durability: DURABILITY_ASYNC}, {Durability: DURABILITY_ASYNC}}) {
+		t.Error("expected batch of async commits not to require sync")
+	}
+	if !db.requiresSync([]TransactionCommit{{Durability: DURABILITY_ASYNC}, {Durability: DURABILITY_GROUP_COMMIT}}) {
+		t.Error("expected batch with group commit to require sync")
+	}
+	if !db.requiresSync([]TransactionCommit{{Durability: DURABILITY_SYNC}}) {
+		t.Error("expected batch with sync commit to require sync")
+	}
+}
+
 func TestDatabase_SerializeHeader_ContainsSignature(t *testing.T) {
 	db, err := NewDatabase(newTestDatabaseConfig(t))
 	if err != nil {
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrTransactionNotActive = errors.New("transaction is not active")
+var ErrReadOnlyTransaction = errors.New("transaction is read-only")
+var ErrForeignKeyViolation = errors.New("foreign key violation")
+
+// ConflictError is returned when transaction is aborted because a concurrent transaction has changed the entry it depends on.
+// Such transactions can be safely re-executed on a fresh snapshot.
+type ConflictError struct {
+	TableID TableID
+	Table   string
+	Key     []byte
+	Reason  string
+}
+
+func (err *ConflictError) Error() string {
+	return fmt.Sprintf("Conflict: %s (table %q, key %x)", err.Reason, err.Table, err.Key)
+}
+
+func newConflictError(table *Table, key []byte, reason string) *ConflictError {
+	return &ConflictError{
+		TableID: table.id,
+		Table:   table.schema.Name,
+		Key:     key,
+		Reason:  reason,
+	}
+}
+
+func IsConflict(err error) bool {
+	var conflict *ConflictError
+
+	return errors.As(err, &conflict)
+}
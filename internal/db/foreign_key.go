@@ -0,0 +1,372 @@
+package db
+
+import (
+	"distributed-storage/internal/events"
+	"distributed-storage/internal/kv"
+	"distributed-storage/internal/primitive"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+const (
+	FOREIGN_KEY_RESTRICT ForeignKeyAction = iota // Parent record can't be deleted while records reference it
+	FOREIGN_KEY_CASCADE                          // Referencing records are deleted together with the parent record
+	FOREIGN_KEY_SET_NULL                         // Foreign key columns of referencing records are set to null
+)
+
+type ForeignKeyAction uint8
+
+// ForeignKey references primary index of the parent table. Records with null in any of the columns don't reference
+// anything, so they aren't checked. The columns must be indexed, so deletes of parent records find referencing
+// records without a full scan.
+type ForeignKey struct {
+	Columns  []string         // Columns holding the parent primary key, in order of the parent primary index
+	Table    string           // Name of the parent table
+	OnDelete ForeignKeyAction // What happens to referencing records when the parent record is deleted
+}
+
+// foreignKeyReference is a foreign key of another table referencing the table
+type foreignKeyReference struct {
+	tableID    TableID
+	foreignKey ForeignKey
+}
+
+// validateForeignKeys checks foreign key definitions which don't depend on the parent table
+func (table *Table) validateForeignKeys() error {
+	for _, foreignKey := range table.schema.ForeignKeys {
+		if foreignKey.Table == "" || len(foreignKey.Columns) == 0 {
+			return fmt.Errorf("Table: foreign key %v must have columns and a parent table", foreignKey.Columns)
+		}
+
+		switch foreignKey.OnDelete {
+		case FOREIGN_KEY_RESTRICT, FOREIGN_KEY_CASCADE:
+		case FOREIGN_KEY_SET_NULL:
+			for _, name := range foreignKey.Columns {
+				if slices.Contains(table.schema.PrimaryIndex, name) {
+					return fmt.Errorf("Table: foreign key column %q can't be set to null because it is in the primary index", name)
+				}
+
+				idx := slices.IndexFunc(table.schema.Columns, func(column Column) bool { return column.Name == name })
+				if idx >= 0 && !table.schema.Columns[idx].Nullable {
+					return fmt.Errorf("Table: foreign key column %q can't be set to null because it isn't nullable", name)
+				}
+			}
+		default:
+			return fmt.Errorf("Table: unknown foreign key action %d", foreignKey.OnDelete)
+		}
+
+		if !table.indexesColumns(foreignKey.Columns) {
+			return fmt.Errorf("Table: foreign key %v must be the primary index or leading columns of a secondary index", foreignKey.Columns)
+		}
+	}
+
+	return nil
+}
+
+// indexesColumns reports whether records can be found by the columns without a full scan, they must be the whole
+// primary index or leading columns of a secondary index in any order
+func (table *Table) indexesColumns(columns []string) bool {
+	leadingColumns := func(index []string) bool {
+		return len(index) >= len(columns) && !slices.ContainsFunc(index[:len(columns)], func(column string) bool {
+			return !slices.Contains(columns, column)
+		})
+	}
+
+	if len(columns) == len(table.schema.PrimaryIndex) && leadingColumns(table.schema.PrimaryIndex) {
+		return true
+	}
+
+	return slices.ContainsFunc(table.schema.SecondaryIndexes, func(secondaryIndex SecondaryIndex) bool {
+		return leadingColumns(secondaryIndex.Columns)
+	})
+}
+
+// validateParentTables checks that tables referenced by foreign keys exist and match their primary indexes,
+// a table can reference itself
+func (manager *TableManager) validateParentTables(table *Table) error {
+	for _, foreignKey := range table.schema.ForeignKeys {
+		parent := table
+
+		if foreignKey.Table != table.schema.Name {
+			var err error
+			if parent, err = manager.Table(foreignKey.Table); err != nil {
+				return err
+			}
+		}
+
+		if parent == nil {
+			return fmt.Errorf("foreign key %v references missing table %q", foreignKey.Columns, foreignKey.Table)
+		}
+		if len(foreignKey.Columns) != len(parent.schema.PrimaryIndex) {
+			return fmt.Errorf("foreign key %v doesn't match primary index %v of table %q",
+				foreignKey.Columns, parent.schema.PrimaryIndex, foreignKey.Table)
+		}
+
+		for idx, name := range foreignKey.Columns {
+			columnType, ok := table.schema.IndexedColumns[name]
+			parentType := parent.schema.IndexedColumns[parent.schema.PrimaryIndex[idx]]
+
+			if ok && columnType != parentType {
+				return fmt.Errorf("foreign key column %q has type %s, but referenced column %q has type %s",
+					name, primitive.TypeName(columnType), parent.schema.PrimaryIndex[idx], primitive.TypeName(parentType))
+			}
+		}
+	}
+
+	return nil
+}
+
+// foreignKeyReferences returns foreign keys of other active tables referencing the table with the name. References
+// are collected from the catalog once and cached until the set of tables changes.
+func (manager *TableManager) foreignKeyReferences(name string) ([]foreignKeyReference, error) {
+	if manager.references == nil {
+		records, err := manager.catalog.GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("foreignKeyReferences: %w", err)
+		}
+
+		manager.references = make(map[string][]foreignKeyReference)
+
+		for _, record := range records {
+			table, err := manager.decodeTable(record)
+			if err != nil {
+				manager.references = nil
+				return nil, fmt.Errorf("foreignKeyReferences: %w", err)
+			}
+			if table.state != TABLE_ACTIVE {
+				continue
+			}
+
+			if loaded, ok := manager.loadedTables[table.id]; ok {
+				table = loaded
+			}
+
+			for _, foreignKey := range table.schema.ForeignKeys {
+				manager.references[foreignKey.Table] = append(manager.references[foreignKey.Table], foreignKeyReference{tableID: table.id, foreignKey: foreignKey})
+			}
+		}
+	}
+
+	return manager.references[name], nil
+}
+
+// checkReferencingTables returns error if other tables reference the table with the name, so it can't be dropped,
+// truncated or renamed
+func (manager *TableManager) checkReferencingTables(name string) error {
+	references, err := manager.foreignKeyReferences(name)
+	if err != nil {
+		return err
+	}
+
+	for _, reference := range references {
+		table, err := manager.TableByID(reference.tableID)
+		if err != nil {
+			return err
+		}
+		if table != nil && table.schema.Name != name {
+			return fmt.Errorf("table is referenced by foreign key %v of table %q", reference.foreignKey.Columns, table.schema.Name)
+		}
+	}
+
+	return nil
+}
+
+// checkParents returns ErrForeignKeyViolation if the record references a missing parent record. Foreign keys
+// holding the same values as in the old record aren't checked.
+func (table *Table) checkParents(record *primitive.Object, oldRecord *primitive.Object) error {
+	for _, foreignKey := range table.schema.ForeignKeys {
+		values := record.GetMany(foreignKey.Columns)
+		if table.containsEmptyValues(values) {
+			continue
+		}
+		if oldRecord != nil && slices.EqualFunc(values, oldRecord.GetMany(foreignKey.Columns), primitive.Primitive.Equal) {
+			continue
+		}
+
+		parent, err := table.parentTable(foreignKey)
+		if err != nil {
+			return err
+		}
+
+		query := primitive.NewObject()
+		for idx, column := range parent.schema.PrimaryIndex {
+			query.Set(column, values[idx])
+		}
+
+		parentRecord, err := parent.Get(query)
+		if err != nil {
+			return err
+		}
+		if parentRecord == nil {
+			return fmt.Errorf("Table %q: %w: record %s doesn't exist in table %q",
+				table.schema.Name, ErrForeignKeyViolation, query, foreignKey.Table)
+		}
+	}
+
+	return nil
+}
+
+// checkReferences returns ErrForeignKeyViolation if records reference the record through foreign keys with
+// FOREIGN_KEY_RESTRICT action, or through any foreign key when anyAction is set
+func (table *Table) checkReferences(record *primitive.Object, anyAction bool) error {
+	return table.visitReferences(record, func(child *Table, foreignKey ForeignKey, children []*primitive.Object) error {
+		if len(children) > 0 && (anyAction || foreignKey.OnDelete == FOREIGN_KEY_RESTRICT) {
+			return fmt.Errorf("Table %q: %w: record %s is referenced by %d records of table %q",
+				table.schema.Name, ErrForeignKeyViolation, table.primaryKey(record), len(children), child.schema.Name)
+		}
+
+		return nil
+	})
+}
+
+// deleteReferences applies delete actions of foreign keys referencing the deleted record
+func (table *Table) deleteReferences(record *primitive.Object) error {
+	return table.visitReferences(record, func(child *Table, foreignKey ForeignKey, children []*primitive.Object) error {
+		for _, childRecord := range children {
+			switch foreignKey.OnDelete {
+			case FOREIGN_KEY_CASCADE:
+				if _, err := child.Delete(childRecord); err != nil {
+					return err
+				}
+			case FOREIGN_KEY_SET_NULL:
+				update := child.primaryKey(childRecord)
+				for _, column := range foreignKey.Columns {
+					update.Set(column, primitive.NewNull())
+				}
+
+				if _, err := child.Update(update); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// visitReferences visits records of every foreign key referencing the record
+func (table *Table) visitReferences(record *primitive.Object, visit func(child *Table, foreignKey ForeignKey, children []*primitive.Object) error) error {
+	if table.manager == nil {
+		return nil
+	}
+
+	references, err := table.manager.foreignKeyReferences(table.schema.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, reference := range references {
+		child, err := table.manager.TableByID(reference.tableID)
+		if err != nil {
+			return err
+		}
+		if child == nil {
+			continue
+		}
+
+		query := primitive.NewObject()
+		for idx, column := range reference.foreignKey.Columns {
+			query.Set(column, record.Get(table.schema.PrimaryIndex[idx]))
+		}
+
+		children, err := child.Find(query)
+		if err != nil {
+			return err
+		}
+		if child == table { // Record referencing itself doesn't prevent its deletion
+			key := table.primaryKey(record)
+			children = slices.DeleteFunc(children, key.Matches)
+		}
+
+		if err := visit(child, reference.foreignKey, children); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (table *Table) parentTable(foreignKey ForeignKey) (*Table, error) {
+	if foreignKey.Table == table.schema.Name {
+		return table, nil
+	}
+
+	parent, err := table.manager.Table(foreignKey.Table)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, fmt.Errorf("Table %q: %w: referenced table %q doesn't exist", table.schema.Name, ErrForeignKeyViolation, foreignKey.Table)
+	}
+
+	return parent, nil
+}
+
+// validateForeignKeys re-checks foreign keys of records changed by the events against the state with all of them
+// applied. Concurrent transactions committed earlier could delete parent records the changes reference or insert
+// records referencing parent records the changes delete.
+func (manager *TableManager) validateForeignKeys(changeEvents []TableEvent) error {
+	type changedEntry struct {
+		tableID  TableID
+		key      []byte
+		oldValue []byte
+	}
+
+	var changedEntries []*changedEntry
+	entriesByKey := make(map[string]*changedEntry)
+
+	for _, changeEvent := range changeEvents {
+		entry := &changedEntry{}
+
+		switch event := changeEvent.(type) {
+		case *events.InsertEntry:
+			entry.tableID, entry.key = TableID(event.TableID), event.Key
+		case *events.UpdateEntry:
+			entry.tableID, entry.key, entry.oldValue = TableID(event.TableID), event.Key, event.OldValue
+		case *events.DeleteEntry:
+			entry.tableID, entry.key, entry.oldValue = TableID(event.TableID), event.Key, event.Value
+		default:
+			continue
+		}
+
+		entryKey := fmt.Sprintf("%d/%x", entry.tableID, entry.key)
+		if _, ok := entriesByKey[entryKey]; ok { // The first event holds the value before the transaction
+			continue
+		}
+
+		entriesByKey[entryKey] = entry
+		changedEntries = append(changedEntries, entry)
+	}
+
+	for _, entry := range changedEntries {
+		table, err := manager.TableByID(entry.tableID)
+		if err != nil {
+			return err
+		}
+		if table == nil || !table.matchPrimaryIndex(entry.key) {
+			continue
+		}
+
+		current, err := table.kv.Get(&kv.GetRequest{Key: entry.key})
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case current.Value != nil:
+			err = table.checkParents(table.decodePayload(current.Value), nil)
+		case entry.oldValue != nil: // Records inserted and deleted by the transaction couldn't be referenced by others
+			err = table.checkReferences(table.decodePayload(entry.oldValue), true)
+		}
+
+		if errors.Is(err, ErrForeignKeyViolation) {
+			return newConflictError(table, entry.key, err.Error())
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
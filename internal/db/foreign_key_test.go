@@ -0,0 +1,206 @@
+package db
+
+import (
+	"context"
+	"distributed-storage/internal/events"
+	"distributed-storage/internal/kv"
+	"distributed-storage/internal/primitive"
+	"errors"
+	"testing"
+)
+
+func orderReferencesSchema(onDelete ForeignKeyAction) *TableSchema {
+	return &TableSchema{
+		Name:             "orders",
+		PrimaryIndex:     []string{"id"},
+		SecondaryIndexes: []SecondaryIndex{{Columns: []string{"user_id"}}},
+		IndexedColumns:   map[string]primitive.PrimitiveType{"id": primitive.TYPE_UINT64, "user_id": primitive.TYPE_UINT64},
+		ForeignKeys:      []ForeignKey{{Columns: []string{"user_id"}, Table: "users", OnDelete: onDelete}},
+	}
+}
+
+func userOrderRecord(id uint64, userID uint64) *primitive.Object {
+	return primitive.NewObject().Set("id", primitive.NewUint64(id)).Set("user_id", primitive.NewUint64(userID))
+}
+
+func newForeignKeyManager(t *testing.T, onDelete ForeignKeyAction) (*TableManager, *Table, *Table) {
+	t.Helper()
+	m := newTestManager(t)
+	users, _ := m.CreateTable(basicSchema())
+	orders, err := m.CreateTable(orderReferencesSchema(onDelete))
+	if err != nil {
+		t.Fatalf("CreateTable failed: %v", err)
+	}
+
+	users.Insert(userRecord(1, "alice"))
+	users.Insert(userRecord(2, "bob"))
+	orders.Insert(userOrderRecord(10, 1))
+	orders.Insert(userOrderRecord(11, 1))
+	orders.Insert(userOrderRecord(12, 2))
+
+	return m, users, orders
+}
+
+func TestTable_Insert_ForeignKey_RequiresParent(t *testing.T) {
+	_, _, orders := newForeignKeyManager(t, FOREIGN_KEY_RESTRICT)
+
+	if _, err := orders.Insert(userOrderRecord(20, 3)); !errors.Is(err, ErrForeignKeyViolation) {
+		t.Errorf("expected foreign key violation for missing parent, got %v", err)
+	}
+	if _, err := orders.Update(userOrderRecord(10, 3)); !errors.Is(err, ErrForeignKeyViolation) {
+		t.Errorf("expected foreign key violation for update to missing parent, got %v", err)
+	}
+	if _, err := orders.Insert(primitive.NewObject().Set("id", primitive.NewUint64(21))); err != nil {
+		t.Errorf("expected record with null foreign key to be inserted, got %v", err)
+	}
+}
+
+func TestTable_Delete_ForeignKeyRestrict(t *testing.T) {
+	_, users, orders := newForeignKeyManager(t, FOREIGN_KEY_RESTRICT)
+
+	if _, err := users.Delete(userRecord(1, "")); !errors.Is(err, ErrForeignKeyViolation) {
+		t.Errorf("expected foreign key violation, got %v", err)
+	}
+	if record, _ := users.Get(userRecord(1, "alice")); record == nil {
+		t.Error("expected referenced record to stay")
+	}
+
+	orders.DeleteMany(primitive.NewObject().Set("user_id", primitive.NewUint64(1)))
+	if _, err := users.Delete(userRecord(1, "")); err != nil {
+		t.Errorf("expected record without references to be deleted, got %v", err)
+	}
+}
+
+func TestTable_Delete_ForeignKeyCascade(t *testing.T) {
+	_, users, orders := newForeignKeyManager(t, FOREIGN_KEY_CASCADE)
+
+	if _, err := users.Delete(userRecord(1, "")); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if remaining, _ := orders.GetAll(); len(remaining) != 1 || remaining[0].GetUint64("id") != 12 {
+		t.Errorf("expected only order of other user to remain, got %v", remaining)
+	}
+}
+
+func TestTable_Delete_ForeignKeySetNull(t *testing.T) {
+	_, users, orders := newForeignKeyManager(t, FOREIGN_KEY_SET_NULL)
+
+	if _, err := users.Delete(userRecord(1, "")); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	order, _ := orders.Get(primitive.NewObject().Set("id", primitive.NewUint64(10)))
+	if order == nil || !order.Get("user_id").Empty() {
+		t.Errorf("expected foreign key to be set to null, got %v", order)
+	}
+}
+
+func TestTableManager_ForeignKey_ValidatesParentTable(t *testing.T) {
+	m := newTestManager(t)
+
+	if _, err := m.CreateTable(orderReferencesSchema(FOREIGN_KEY_RESTRICT)); err == nil {
+		t.Error("expected foreign key to missing table to be rejected")
+	}
+
+	m.CreateTable(basicSchema())
+	if _, err := m.CreateTable(orderReferencesSchema(FOREIGN_KEY_RESTRICT)); err != nil {
+		t.Fatalf("CreateTable failed: %v", err)
+	}
+
+	if err := m.DropTable("users"); err == nil {
+		t.Error("expected drop of referenced table to be rejected")
+	}
+	if err := m.RenameTable("users", "accounts"); err == nil {
+		t.Error("expected rename of referenced table to be rejected")
+	}
+
+	invalid := orderReferencesSchema(FOREIGN_KEY_SET_NULL)
+	invalid.Name = "invoices"
+	invalid.ForeignKeys[0].Columns = []string{"id"}
+	if _, err := m.CreateTable(invalid); err == nil {
+		t.Error("expected set null foreign key on primary index column to be rejected")
+	}
+}
+
+func TestTableManager_ForeignKey_RequiresIndex(t *testing.T) {
+	m := newTestManager(t)
+	m.CreateTable(basicSchema())
+
+	unindexed := orderReferencesSchema(FOREIGN_KEY_RESTRICT)
+	unindexed.SecondaryIndexes = nil
+	if _, err := m.CreateTable(unindexed); err == nil {
+		t.Error("expected foreign key without index to be rejected")
+	}
+
+	composite := orderReferencesSchema(FOREIGN_KEY_RESTRICT)
+	composite.SecondaryIndexes = []SecondaryIndex{{Columns: []string{"user_id", "id"}}}
+	if _, err := m.CreateTable(composite); err != nil {
+		t.Errorf("expected foreign key on leading columns of an index to be accepted, got %v", err)
+	}
+}
+
+func TestTableManager_ValidateForeignKeys_IgnoresRecordsInsertedAndDeleted(t *testing.T) {
+	m, users, _ := newForeignKeyManager(t, FOREIGN_KEY_RESTRICT)
+
+	// Record which didn't exist before the transaction, while other records reference its key
+	key := users.getPrimaryIndex(userRecord(1, ""))
+	response, _ := users.kv.Get(&kv.GetRequest{Key: key})
+	users.kv.Delete(&kv.DeleteRequest{Key: key})
+
+	changeEvents := []TableEvent{
+		events.NewInsertEntry(uint64(users.id), key, response.Value),
+		events.NewDeleteEntry(uint64(users.id), key, response.Value),
+	}
+	if err := m.validateForeignKeys(changeEvents); err != nil {
+		t.Errorf("expected record inserted and deleted by the transaction not to be checked, got %v", err)
+	}
+}
+
+func TestDatabase_ForeignKey_RevalidatedAtCommit(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+	if err := db.StartTransaction(func(tx *Transaction) {
+		if _, err := tx.CreateTable(orderReferencesSchema(FOREIGN_KEY_RESTRICT)); err != nil {
+			t.Errorf("CreateTable failed: %v", err)
+		}
+	}); err != nil {
+		t.Fatalf("create table tx failed: %v", err)
+	}
+
+	child, _ := db.Begin(context.Background(), TransactionOptions{})
+	parent, _ := db.Begin(context.Background(), TransactionOptions{})
+
+	orders, _ := child.Table("orders")
+	if _, err := orders.Insert(userOrderRecord(10, 1)); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	users, _ := parent.Table("users")
+	if _, err := users.Delete(userRecord(1, "")); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	if err := parent.Commit(); err != nil {
+		t.Fatalf("parent Commit failed: %v", err)
+	}
+	if err := child.Commit(); !IsConflict(err) {
+		t.Errorf("expected conflict for reference to concurrently deleted record, got %v", err)
+	}
+
+	db.StartTransaction(func(tx *Transaction) {
+		users, _ := tx.Table("users")
+		users.Insert(userRecord(1, "alice"))
+	})
+
+	child, _ = db.Begin(context.Background(), TransactionOptions{})
+	parent, _ = db.Begin(context.Background(), TransactionOptions{})
+
+	orders, _ = child.Table("orders")
+	orders.Insert(userOrderRecord(10, 1))
+	users, _ = parent.Table("users")
+	users.Delete(userRecord(1, ""))
+
+	if err := child.Commit(); err != nil {
+		t.Fatalf("child Commit failed: %v", err)
+	}
+	if err := parent.Commit(); !IsConflict(err) {
+		t.Errorf("expected conflict for delete of concurrently referenced record, got %v", err)
+	}
+}
@@ -0,0 +1,276 @@
+package db
+
+import (
+	"bytes"
+	"distributed-storage/internal/events"
+	"distributed-storage/internal/primitive"
+	"fmt"
+	"sync"
+)
+
+type WriteOperation uint8
+
+const (
+	WRITE_INSERT WriteOperation = iota
+	WRITE_UPDATE
+	WRITE_DELETE
+	WRITE_TRUNCATE // All rows of the table were removed, passed only to after-commit hooks
+)
+
+// RowWrite describes a write of a single row passed to before-write hooks
+type RowWrite struct {
+	Table     *Table
+	Manager   *TableManager // Manager of the transaction, hooks write other tables through it
+	Operation WriteOperation
+	Record    *primitive.Object // Row to be written, nil for WRITE_DELETE
+	OldRecord *primitive.Object // Row before the write, nil for WRITE_INSERT
+}
+
+// BeforeWriteHook runs inside the transaction before the row is written. It returns the row to write, which can be
+// modified, or an error to reject the write. The row returned for WRITE_DELETE is ignored.
+type BeforeWriteHook func(write *RowWrite) (*primitive.Object, error)
+
+// CommittedChange is a decoded InsertEntry, UpdateEntry or DeleteEntry event of a committed transaction, or
+// a TruncateTable event without rows
+type CommittedChange struct {
+	Table     string
+	Operation WriteOperation
+	Record    *primitive.Object // Row after the change, nil for WRITE_DELETE and WRITE_TRUNCATE
+	OldRecord *primitive.Object // Row before the change, nil for WRITE_INSERT and WRITE_TRUNCATE
+	Event     TableEvent
+	Err       error // Error of decoding rows of the event, Record and OldRecord are nil if set
+}
+
+// AfterCommitHook receives changes of the table made by a committed transaction, in the order they were made.
+// Hooks are called in commit order outside of the commit loop, so they can run their own transactions.
+type AfterCommitHook func(version DatabaseVersion, changes []CommittedChange)
+
+type committedTransaction struct {
+	version DatabaseVersion
+	changes []CommittedChange
+}
+
+// hookQueue passes committed transactions from the commit loop to the hook loop. Push never blocks, so the commit loop
+// isn't held back by slow hooks or by hooks waiting for their own transactions to commit.
+type hookQueue struct {
+	transactions []committedTransaction
+	signal       chan struct{} // Notifies the hook loop that transactions were pushed
+
+	mu sync.Mutex
+}
+
+func newHookQueue() *hookQueue {
+	return &hookQueue{signal: make(chan struct{}, 1)}
+}
+
+func (queue *hookQueue) push(transaction committedTransaction) {
+	queue.mu.Lock()
+	queue.transactions = append(queue.transactions, transaction)
+	queue.mu.Unlock()
+
+	select {
+	case queue.signal <- struct{}{}:
+	default: // Hook loop is already notified
+	}
+}
+
+// take removes and returns all queued transactions in commit order
+func (queue *hookQueue) take() []committedTransaction {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	transactions := queue.transactions
+	queue.transactions = nil
+
+	return transactions
+}
+
+func (queue *hookQueue) len() int {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	return len(queue.transactions)
+}
+
+// hookRegistry holds hooks by table name, renamed tables keep hooks registered under the old name
+type hookRegistry struct {
+	beforeWrite map[string][]BeforeWriteHook
+	afterCommit map[string][]AfterCommitHook
+
+	mu sync.RWMutex
+}
+
+func newHookRegistry() *hookRegistry {
+	return &hookRegistry{
+		beforeWrite: make(map[string][]BeforeWriteHook),
+		afterCommit: make(map[string][]AfterCommitHook),
+	}
+}
+
+// BeforeWrite registers hook called on every insert, update and delete of rows of the table
+func (db *Database) BeforeWrite(table string, hook BeforeWriteHook) {
+	db.hooks.mu.Lock()
+	defer db.hooks.mu.Unlock()
+
+	db.hooks.beforeWrite[table] = append(db.hooks.beforeWrite[table], hook)
+}
+
+// AfterCommit registers hook called with changes of the table made by every committed transaction
+func (db *Database) AfterCommit(table string, hook AfterCommitHook) {
+	db.hooks.mu.Lock()
+	defer db.hooks.mu.Unlock()
+
+	db.hooks.afterCommit[table] = append(db.hooks.afterCommit[table], hook)
+}
+
+func (registry *hookRegistry) beforeWriteHooks(table string) []BeforeWriteHook {
+	if registry == nil {
+		return nil
+	}
+
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	return registry.beforeWrite[table]
+}
+
+func (registry *hookRegistry) afterCommitHooks(table string) []AfterCommitHook {
+	if registry == nil {
+		return nil
+	}
+
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	return registry.afterCommit[table]
+}
+
+func (table *Table) hasBeforeWriteHooks() bool {
+	return table.manager != nil && len(table.manager.hooks.beforeWriteHooks(table.schema.Name)) > 0
+}
+
+// runBeforeWriteHooks passes the row through before-write hooks of the table and returns the row to write. Hooks
+// can't change the primary key of the row.
+func (table *Table) runBeforeWriteHooks(operation WriteOperation, record *primitive.Object, oldRecord *primitive.Object) (*primitive.Object, error) {
+	if !table.hasBeforeWriteHooks() {
+		return record, nil
+	}
+
+	var index []byte
+	if operation != WRITE_DELETE {
+		index = table.getPrimaryIndex(record)
+	}
+
+	for _, hook := range table.manager.hooks.beforeWriteHooks(table.schema.Name) {
+		write := &RowWrite{Table: table, Manager: table.manager, Operation: operation, Record: record, OldRecord: oldRecord}
+
+		hooked, err := hook(write)
+		if err != nil {
+			return nil, fmt.Errorf("Table %q: write rejected by hook: %w", table.schema.Name, err)
+		}
+		if operation != WRITE_DELETE {
+			if hooked == nil {
+				return nil, fmt.Errorf("Table %q: before-write hook returned no record", table.schema.Name)
+			}
+			if !bytes.Equal(table.getPrimaryIndex(hooked), index) {
+				return nil, fmt.Errorf("Table %q: before-write hook can't change primary key of record %s", table.schema.Name, table.primaryKey(record))
+			}
+
+			record = hooked
+		}
+	}
+
+	return record, nil
+}
+
+// committedChanges decodes row changes of tables with after-commit hooks, the events must be already applied. Rows
+// which can't be decoded are passed to hooks with the decoding error.
+func (manager *TableManager) committedChanges(changeEvents []TableEvent) ([]CommittedChange, error) {
+	var changes []CommittedChange
+
+	for _, changeEvent := range changeEvents {
+		var (
+			tableID   TableID
+			key       []byte
+			operation WriteOperation
+			value     []byte
+			oldValue  []byte
+		)
+
+		switch event := changeEvent.(type) {
+		case *events.InsertEntry:
+			tableID, key, operation, value = TableID(event.TableID), event.Key, WRITE_INSERT, event.Value
+		case *events.UpdateEntry:
+			tableID, key, operation, value, oldValue = TableID(event.TableID), event.Key, WRITE_UPDATE, event.NewValue, event.OldValue
+		case *events.DeleteEntry:
+			tableID, key, operation, oldValue = TableID(event.TableID), event.Key, WRITE_DELETE, event.Value
+		case *events.TruncateTable:
+			tableID, operation = TableID(event.TableID), WRITE_TRUNCATE
+		default:
+			continue
+		}
+
+		table, err := manager.TableByID(tableID)
+		if err != nil {
+			return nil, err
+		}
+		if table == nil || len(manager.hooks.afterCommitHooks(table.schema.Name)) == 0 {
+			continue
+		}
+		if operation != WRITE_TRUNCATE && !table.matchPrimaryIndex(key) {
+			continue
+		}
+
+		change := CommittedChange{Table: table.schema.Name, Operation: operation, Event: changeEvent}
+		if value != nil {
+			change.Record, change.Err = table.decodeRecord(value)
+		}
+		if oldValue != nil && change.Err == nil {
+			change.OldRecord, change.Err = table.decodeRecord(oldValue)
+		}
+		if change.Err != nil {
+			change.Record, change.OldRecord = nil, nil
+			change.Err = fmt.Errorf("Table %q: couldn't decode committed row %x: %w", table.schema.Name, key, change.Err)
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+func (db *Database) runHookLoop() {
+	for range db.hookQueue.signal {
+		for _, transaction := range db.hookQueue.take() {
+			db.runAfterCommitHooks(transaction)
+		}
+	}
+}
+
+// runAfterCommitHooks calls hooks of every changed table with its changes, panicking hook doesn't stop the loop
+func (db *Database) runAfterCommitHooks(transaction committedTransaction) {
+	var tables []string
+	changesByTable := make(map[string][]CommittedChange)
+
+	for _, change := range transaction.changes {
+		if _, ok := changesByTable[change.Table]; !ok {
+			tables = append(tables, change.Table)
+		}
+
+		changesByTable[change.Table] = append(changesByTable[change.Table], change)
+	}
+
+	for _, table := range tables {
+		for _, hook := range db.hooks.afterCommitHooks(table) {
+			func() {
+				defer func() {
+					if panic := recover(); panic != nil {
+						db.config.Logger.Error("Database: after-commit hook panicked", "table", table, "panic", panic)
+					}
+				}()
+
+				hook(transaction.version, changesByTable[table])
+			}()
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package db
+
+import (
+	"context"
+	"distributed-storage/internal/events"
+	"distributed-storage/internal/primitive"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+var errNameRequired = errors.New("name is required")
+
+func countersSchema() *TableSchema {
+	return &TableSchema{
+		Name:           "counters",
+		PrimaryIndex:   []string{"id"},
+		IndexedColumns: map[string]primitive.PrimitiveType{"id": primitive.TYPE_UINT64},
+	}
+}
+
+func TestDatabase_BeforeWriteHook_ModifiesAndRejectsRows(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+	db.BeforeWrite("users", func(write *RowWrite) (*primitive.Object, error) {
+		if write.Operation == WRITE_DELETE {
+			return nil, nil
+		}
+		if write.Record.GetString("name") == "" {
+			return nil, errNameRequired
+		}
+		return write.Record.Set("checked", primitive.NewBool(true)), nil
+	})
+
+	db.StartTransaction(func(tx *Transaction) {
+		users, _ := tx.Table("users")
+		if _, err := users.Insert(userRecord(2, "")); !errors.Is(err, errNameRequired) {
+			t.Errorf("expected insert to be rejected by hook, got %v", err)
+		}
+		if _, err := users.Update(userRecord(1, "")); !errors.Is(err, errNameRequired) {
+			t.Errorf("expected update to be rejected by hook, got %v", err)
+		}
+		if _, err := users.Insert(userRecord(2, "bob")); err != nil {
+			t.Errorf("Insert failed: %v", err)
+		}
+	})
+
+	db.StartTransaction(func(tx *Transaction) {
+		users, _ := tx.Table("users")
+		record, _ := users.Get(primitive.NewObject().Set("id", primitive.NewUint64(2)))
+		if record == nil || !record.Get("checked").Equal(primitive.NewBool(true)) {
+			t.Errorf("expected row modified by hook to be committed, got %v", record)
+		}
+		if record, _ := users.Get(primitive.NewObject().Set("id", primitive.NewUint64(1))); record.GetString("name") != "alice" {
+			t.Errorf("expected rejected update to leave row unchanged, got %v", record)
+		}
+	})
+
+	db.BeforeWrite("users", func(write *RowWrite) (*primitive.Object, error) {
+		return write.Record.Set("id", primitive.NewUint64(100)), nil
+	})
+	db.StartTransaction(func(tx *Transaction) {
+		users, _ := tx.Table("users")
+		if _, err := users.Insert(userRecord(3, "carol")); err == nil {
+			t.Error("expected hook changing primary key to be rejected")
+		}
+	})
+}
+
+func TestDatabase_BeforeWriteHook_WritesOtherTables(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+	db.StartTransaction(func(tx *Transaction) {
+		tx.CreateTable(countersSchema())
+	})
+
+	db.BeforeWrite("users", func(write *RowWrite) (*primitive.Object, error) {
+		counters, err := write.Manager.Table("counters")
+		if err != nil {
+			return nil, err
+		}
+
+		count := uint64(0)
+		if counter, _ := counters.Get(primitive.NewObject().Set("id", primitive.NewUint64(1))); counter != nil {
+			count = counter.GetUint64("count")
+		}
+		switch write.Operation {
+		case WRITE_INSERT:
+			count++
+		case WRITE_DELETE:
+			count--
+		}
+
+		_, err = counters.Upsert(primitive.NewObject().Set("id", primitive.NewUint64(1)).Set("count", primitive.NewUint64(count)))
+		return write.Record, err
+	})
+
+	db.StartTransaction(func(tx *Transaction) {
+		users, _ := tx.Table("users")
+		users.Insert(userRecord(2, "bob"))
+		users.Insert(userRecord(3, "carol"))
+		users.Delete(userRecord(1, ""))
+	})
+
+	db.StartTransaction(func(tx *Transaction) {
+		counters, _ := tx.Table("counters")
+		counter, _ := counters.Get(primitive.NewObject().Set("id", primitive.NewUint64(1)))
+		if counter == nil || counter.GetUint64("count") != 1 {
+			t.Errorf("expected counter maintained by hook to be 1, got %v", counter)
+		}
+	})
+}
+
+func TestDatabase_AfterCommitHook_ReceivesCommittedChanges(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+	committed := make(chan []CommittedChange, 10)
+	db.AfterCommit("users", func(version DatabaseVersion, changes []CommittedChange) {
+		committed <- changes
+	})
+
+	db.StartTransaction(func(tx *Transaction) {
+		users, _ := tx.Table("users")
+		users.Insert(userRecord(2, "bob"))
+		users.Update(userRecord(1, "alicia"))
+		users.Delete(userRecord(2, ""))
+	})
+
+	var changes []CommittedChange
+	select {
+	case changes = <-committed:
+	case <-time.After(time.Second):
+		t.Fatal("expected after-commit hook to be called")
+	}
+
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %d", len(changes))
+	}
+	if changes[0].Operation != WRITE_INSERT || changes[0].Record.GetString("name") != "bob" || changes[0].OldRecord != nil {
+		t.Errorf("expected decoded insert of bob, got %+v", changes[0])
+	}
+	if changes[1].Operation != WRITE_UPDATE || changes[1].Record.GetString("name") != "alicia" || changes[1].OldRecord.GetString("name") != "alice" {
+		t.Errorf("expected decoded update of alice, got %+v", changes[1])
+	}
+	if changes[2].Operation != WRITE_DELETE || changes[2].Record != nil || changes[2].OldRecord.GetString("name") != "bob" {
+		t.Errorf("expected decoded delete of bob, got %+v", changes[2])
+	}
+
+	first, _ := db.Begin(context.Background(), TransactionOptions{})
+	second, _ := db.Begin(context.Background(), TransactionOptions{})
+	for _, tx := range []*Transaction{first, second} {
+		users, _ := tx.Table("users")
+		users.Update(userRecord(1, "conflict"))
+	}
+	first.Commit()
+	if err := second.Commit(); !IsConflict(err) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+
+	<-committed
+	select {
+	case changes := <-committed:
+		t.Errorf("expected aborted transaction not to reach hooks, got %+v", changes)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDatabase_AfterCommitHook_CommitsOwnTransactions(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+	db.StartTransaction(func(tx *Transaction) {
+		tx.CreateTable(countersSchema())
+	})
+
+	release := make(chan struct{})
+	db.AfterCommit("users", func(version DatabaseVersion, changes []CommittedChange) {
+		<-release
+		db.StartTransaction(func(tx *Transaction) {
+			counters, _ := tx.Table("counters")
+			counters.Insert(primitive.NewObject().Set("id", primitive.NewUint64(uint64(version))))
+		})
+	})
+
+	commits := NUMBER_OF_PARALLEL_TRANSACTIONS + 1 // More than the commit loop could queue if pushing to hooks blocked
+	for id := 0; id < commits; id++ {
+		if err := db.StartTransaction(func(tx *Transaction) {
+			users, _ := tx.Table("users")
+			users.Upsert(userRecord(1, fmt.Sprint(id)))
+		}); err != nil {
+			t.Fatalf("commit %d failed: %v", id, err)
+		}
+	}
+	close(release)
+
+	deadline := time.Now().Add(30 * time.Second) // Hooks commit one by one, which is slow when tests run in parallel
+	for count := 0; count < commits; {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected hooks to commit %d transactions, got %d", commits, count)
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		db.StartTransaction(func(tx *Transaction) {
+			counters, _ := tx.Table("counters")
+			records, _ := counters.GetAll()
+			count = len(records)
+		})
+	}
+}
+
+func TestDatabase_AfterCommitHook_ReceivesTruncate(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+	committed := make(chan []CommittedChange, 10)
+	db.AfterCommit("users", func(version DatabaseVersion, changes []CommittedChange) {
+		committed <- changes
+	})
+
+	db.StartTransaction(func(tx *Transaction) {
+		if err := tx.TruncateTable("users"); err != nil {
+			t.Errorf("TruncateTable failed: %v", err)
+		}
+	})
+
+	select {
+	case changes := <-committed:
+		if len(changes) != 1 || changes[0].Operation != WRITE_TRUNCATE || changes[0].Record != nil || changes[0].OldRecord != nil {
+			t.Errorf("expected single truncate change, got %+v", changes)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected after-commit hook to be called for truncate")
+	}
+}
+
+func TestTableManager_CommittedChanges_ReportsDecodeErrors(t *testing.T) {
+	m := newTestManager(t)
+	m.hooks = newHookRegistry()
+	m.hooks.afterCommit["users"] = []AfterCommitHook{func(DatabaseVersion, []CommittedChange) {}}
+	users, _ := m.CreateTable(basicSchema())
+
+	key := users.getPrimaryIndex(userRecord(1, "alice"))
+	changes, err := m.committedChanges([]TableEvent{
+		events.NewInsertEntry(uint64(users.id), key, users.encodePayload(userRecord(1, "alice"))),
+		events.NewInsertEntry(uint64(users.id), key, []byte{0xFF, 0x01}),
+	})
+	if err != nil {
+		t.Fatalf("committedChanges failed: %v", err)
+	}
+
+	if len(changes) != 2 || changes[0].Err != nil || changes[0].Record == nil {
+		t.Fatalf("expected decoded insert followed by the broken one, got %+v", changes)
+	}
+	if changes[1].Err == nil || changes[1].Record != nil {
+		t.Errorf("expected decoding error of the broken row, got %+v", changes[1])
+	}
+}
@@ -5,9 +5,10 @@ import (
 	"distributed-storage/internal/events"
 	"distributed-storage/internal/kv"
 	"distributed-storage/internal/pager"
-	"distributed-storage/internal/vals"
+	"distributed-storage/internal/primitive"
 	"encoding/json"
 	"fmt"
+	"slices"
 )
 
 const HEADER_SIZE = len(DB_STORAGE_SIGNATURE) + 32
@@ -23,6 +24,7 @@ type PageChanges struct {
 	PagesCount    pager.PagesCount
 	ReusablePages pager.PageList
 	RetiredPages  pager.PageList
+	Stats         pager.PagerStats
 }
 
 type ApplyResult struct {
@@ -38,12 +40,23 @@ type TableManagerState struct {
 	Version DatabaseVersion
 }
 
+type TableManagerSavepoint struct {
+	pagerState pager.PagerState
+	catalog    tableSavepoint
+	tables     map[*Table]tableSavepoint
+}
+
 type TableManager struct {
 	state   TableManagerState
 	tableID TableIDAllocator
 
 	catalog      *Table
 	loadedTables map[TableID]*Table
+	references   map[string][]foreignKeyReference // Foreign keys by referenced table name, nil until collected
+	hooks        *hookRegistry                    // Hooks of the database, nil if tables don't run hooks
+
+	readOnly   bool // Tables loaded by manager reject any writes
+	trackReads bool // Tables loaded by manager record read entries for commit validation
 
 	pager *pager.Pager
 }
@@ -52,7 +65,7 @@ var catalogSchema = TableSchema{
 	Name:             "@catalog",
 	PrimaryIndex:     []string{"id"},
 	SecondaryIndexes: []SecondaryIndex{{Columns: []string{"name", "state"}}},
-	IndexedColumns:   map[string]vals.ValueType{"id": vals.TYPE_UINT64, "name": vals.TYPE_STRING, "state": vals.TYPE_UINT32},
+	IndexedColumns:   map[string]primitive.PrimitiveType{"id": primitive.TYPE_UINT64, "name": primitive.TYPE_STRING, "state": primitive.TYPE_UINT32},
 }
 
 func newTableManager(state TableManagerState, tableID TableIDAllocator, pager *pager.Pager) *TableManager {
@@ -74,7 +87,7 @@ func newTableManager(state TableManagerState, tableID TableIDAllocator, pager *p
 
 func (manager *TableManager) Table(name string) (*Table, error) {
 	for _, table := range manager.loadedTables {
-		if table.schema.Name == name {
+		if table.schema.Name == name && table.state == TABLE_ACTIVE {
 			return table, nil
 		}
 	}
@@ -97,24 +110,24 @@ func (manager *TableManager) Table(name string) (*Table, error) {
 	return table, nil
 }
 
+// TableByID returns active table with the id, dropped tables are visible only to the reclaimer
 func (manager *TableManager) TableByID(id TableID) (*Table, error) {
 	if table, ok := manager.loadedTables[id]; ok {
+		if table.state != TABLE_ACTIVE {
+			return nil, nil
+		}
+
 		return table, nil
 	}
 
-	records, err := manager.catalog.Find(manager.buildTableQueryByID(id))
+	table, err := manager.catalogTable(id)
 	if err != nil {
-		return nil, fmt.Errorf("Table ID %d: couldn't read schema from catalog: %w", id, err)
+		return nil, err
 	}
 
-	if len(records) == 0 {
+	if table == nil || table.state != TABLE_ACTIVE {
 		return nil, nil
 	}
-
-	table, err := manager.decodeTable(records[0])
-	if err != nil {
-		return nil, fmt.Errorf("TableByID %d: couldn't decode schema from catalog: %w", id, err)
-	}
 	manager.loadedTables[id] = table
 
 	return table, nil
@@ -153,12 +166,20 @@ func (manager *TableManager) CreateTable(schema *TableSchema) (*Table, error) {
 		return nil, fmt.Errorf("CreateTable %q: couldn't initialize table: %w", schema.Name, err)
 	}
 
+	if err := manager.validateParentTables(table); err != nil {
+		return nil, fmt.Errorf("CreateTable %q: %w", schema.Name, err)
+	}
+
 	record := manager.encodeTable(table)
-	if err := manager.catalog.Insert(record); err != nil {
+	if _, err := manager.catalog.Insert(record); err != nil {
 		return nil, fmt.Errorf("CreateTable %q: couldn't insert into catalog: %w", schema.Name, err)
 	}
 
+	table.readOnly = manager.readOnly
+	table.trackReads = manager.trackReads
+	table.manager = manager
 	manager.loadedTables[table.id] = table
+	manager.references = nil
 
 	table.changeEvents = append(table.changeEvents, events.NewCreateTable(
 		record.GetUint64("id"),
@@ -168,6 +189,8 @@ func (manager *TableManager) CreateTable(schema *TableSchema) (*Table, error) {
 	return table, nil
 }
 
+// DropTable marks the table TABLE_DROPPING, its pages are freed by the reclaimer later. Dropped table stays loaded,
+// so its events are committed, but lookups by name and id don't return it.
 func (manager *TableManager) DropTable(name string) error {
 	table, err := manager.Table(name)
 	if err != nil {
@@ -177,19 +200,112 @@ func (manager *TableManager) DropTable(name string) error {
 		return nil
 	}
 
+	if err := manager.checkReferencingTables(name); err != nil {
+		return fmt.Errorf("DropTable %q: %w", name, err)
+	}
+
 	table.state = TABLE_DROPPING
+	table.stateVersion = manager.state.Version
 
 	if _, err := manager.catalog.Update(manager.encodeTable(table)); err != nil {
 		return fmt.Errorf("DropTable %q: couldn't update catalog entry: %w", name, err)
 	}
-
-	delete(manager.loadedTables, table.id)
+	manager.references = nil
 
 	table.changeEvents = append(table.changeEvents, events.NewDropTable(uint64(table.id)))
 
 	return nil
 }
 
+// TruncateTable removes all records of the table. Pages of the table tree are freed at once instead of deleting
+// entries one by one, so truncation is written to WAL as a single event.
+func (manager *TableManager) TruncateTable(name string) error {
+	table, err := manager.Table(name)
+	if err != nil {
+		return fmt.Errorf("TruncateTable %q: couldn't load table: %w", name, err)
+	}
+	if table == nil {
+		return fmt.Errorf("TruncateTable %q: table doesn't exist", name)
+	}
+	if table.readOnly {
+		return fmt.Errorf("TruncateTable %q: %w", name, ErrReadOnlyTransaction)
+	}
+	if err := manager.checkReferencingTables(name); err != nil {
+		return fmt.Errorf("TruncateTable %q: %w", name, err)
+	}
+
+	table.kv.Clear()
+
+	table.changeEvents = append(table.changeEvents, events.NewTruncateTable(uint64(table.id), table.loaded.root))
+
+	return nil
+}
+
+// RenameTable changes the table name in the catalog, the table keeps its id, records and schema version
+func (manager *TableManager) RenameTable(name string, newName string) error {
+	table, err := manager.Table(name)
+	if err != nil {
+		return fmt.Errorf("RenameTable %q: couldn't load table: %w", name, err)
+	}
+	if table == nil {
+		return fmt.Errorf("RenameTable %q: table doesn't exist", name)
+	}
+	if table.readOnly {
+		return fmt.Errorf("RenameTable %q: %w", name, ErrReadOnlyTransaction)
+	}
+	if err := manager.checkReferencingTables(name); err != nil {
+		return fmt.Errorf("RenameTable %q: %w", name, err)
+	}
+
+	existing, err := manager.Table(newName)
+	if err != nil {
+		return fmt.Errorf("RenameTable %q: couldn't check if table %q already exists: %w", name, newName, err)
+	}
+	if existing != nil {
+		return fmt.Errorf("RenameTable %q: table %q already exists", name, newName)
+	}
+
+	if err := manager.renameTable(table, newName); err != nil {
+		return fmt.Errorf("RenameTable %q: %w", name, err)
+	}
+
+	table.changeEvents = append(table.changeEvents, events.NewRenameTable(uint64(table.id), name, newName))
+
+	return nil
+}
+
+// ReclaimEvents returns events advancing dropped tables whose state was changed at or before the version, so no
+// active transaction can see them. Pages of TABLE_DROPPING tables are freed and they become TABLE_DROPPED, catalog
+// entries of TABLE_DROPPED tables are purged once their freed pages are no longer visible either.
+func (manager *TableManager) ReclaimEvents(version DatabaseVersion) ([]TableEvent, error) {
+	var reclaimEvents []TableEvent
+
+	records, err := manager.catalog.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("ReclaimEvents: %w", err)
+	}
+
+	for _, record := range records {
+		table, err := manager.decodeTable(record)
+		if err != nil {
+			return nil, fmt.Errorf("ReclaimEvents: %w", err)
+		}
+
+		if table.state == TABLE_ACTIVE || table.stateVersion > version {
+			continue
+		}
+
+		switch table.state {
+		case TABLE_DROPPING:
+			reclaimEvents = append(reclaimEvents, events.NewReclaimTable(uint64(table.id)))
+		case TABLE_DROPPED:
+			reclaimEvents = append(reclaimEvents, events.NewPurgeTable(uint64(table.id)))
+		}
+	}
+
+	return reclaimEvents, nil
+}
+
 func (manager *TableManager) ChangeEvents() []TableEvent {
 	var events []TableEvent
 
@@ -203,6 +319,137 @@ func (manager *TableManager) ChangeEvents() []TableEvent {
 	return events
 }
 
+func (manager *TableManager) ReadEvents() []TableEvent {
+	var events []TableEvent
+
+	for _, table := range manager.loadedTables {
+		events = append(events, table.readEvents...)
+	}
+
+	return events
+}
+
+// Discard drops all uncommitted changes, so the manager sees the same state as at the moment of its creation
+func (manager *TableManager) Discard() {
+	for _, table := range manager.loadedTables {
+		table.changeEvents = nil
+		table.readEvents = nil
+		table.writes = ownWrites{}
+	}
+
+	manager.loadedTables = make(map[TableID]*Table)
+	manager.references = nil
+	manager.catalog, _ = newTable(CATALOG_TABLE_ID, manager.state.Root, manager.pager, &catalogSchema)
+}
+
+func (manager *TableManager) Savepoint() TableManagerSavepoint {
+	savepoint := TableManagerSavepoint{
+		pagerState: manager.pager.Snapshot(),
+		catalog:    manager.catalog.savepoint(),
+		tables:     make(map[*Table]tableSavepoint, len(manager.loadedTables)),
+	}
+
+	for _, table := range manager.loadedTables {
+		savepoint.tables[table] = table.savepoint()
+	}
+
+	return savepoint
+}
+
+// RestoreSavepoint reverts tree state and recorded events of every table to the savepoint. Tables loaded after the
+// savepoint stay loaded with the state they were loaded with, so their handles remain usable. Tables created after
+// the savepoint are unloaded and emptied, tables dropped after the savepoint become active again.
+func (manager *TableManager) RestoreSavepoint(savepoint TableManagerSavepoint) {
+	manager.pager.Restore(savepoint.pagerState)
+	manager.catalog.restoreSavepoint(savepoint.catalog, manager.pager)
+	manager.references = nil
+
+	for id, table := range manager.loadedTables {
+		if _, ok := savepoint.tables[table]; ok {
+			continue
+		}
+
+		if table.loaded.schema != nil {
+			table.restoreSavepoint(table.loaded, manager.pager)
+		} else { // Pages of the created table could be reused, so its handle doesn't keep them
+			table.restoreSavepoint(tableSavepoint{root: pager.NULL_PAGE, state: table.state, schema: table.schema}, manager.pager)
+			delete(manager.loadedTables, id)
+		}
+	}
+
+	for table, tableSavepoint := range savepoint.tables {
+		table.restoreSavepoint(tableSavepoint, manager.pager)
+		manager.loadedTables[table.id] = table
+	}
+}
+
+func (manager *TableManager) ValidateReadEvents(readEvents []TableEvent) error {
+	for _, readEvent := range readEvents {
+		var tableID uint64
+		switch event := readEvent.(type) {
+		case *events.ReadEntry:
+			tableID = event.TableID
+		case *events.ReadRange:
+			tableID = event.TableID
+		default:
+			return fmt.Errorf("ValidateReadEvents: unexpected event %d", readEvent.Type())
+		}
+
+		table, err := manager.TableByID(TableID(tableID))
+		if err != nil {
+			return fmt.Errorf("ValidateReadEvents: %w", err)
+		}
+		if table == nil {
+			return &ConflictError{TableID: TableID(tableID), Reason: "table was dropped by concurrent transaction"}
+		}
+
+		switch event := readEvent.(type) {
+		case *events.ReadEntry:
+			response, err := table.kv.Get(&kv.GetRequest{Key: event.Key})
+			if err != nil {
+				return fmt.Errorf("ValidateReadEvents: %w", err)
+			}
+			if !bytes.Equal(response.Value, event.Value) {
+				return newConflictError(table, event.Key, "read entry was changed by concurrent transaction")
+			}
+		case *events.ReadRange:
+			var read rangeRead
+
+			cursor := table.kv.Scan(&kv.ScanRequest{Key: event.Low})
+			for key, value := cursor.Current(); key != nil && (event.High == nil || bytes.Compare(key, event.High) < 0); key, value = cursor.Next() {
+				read.visit(key, value)
+			}
+
+			if read.entries != event.Entries || read.checksum != event.Checksum {
+				return newConflictError(table, event.Low, "scanned range was changed by concurrent transaction")
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateChangeEvents checks that tables truncated by the transaction weren't changed since it started. Truncate
+// keeps only the root of the table, which matches the current state only, so it isn't checked on WAL replay.
+func (manager *TableManager) ValidateChangeEvents(changeEvents []TableEvent) error {
+	for _, changeEvent := range changeEvents {
+		event, ok := changeEvent.(*events.TruncateTable)
+		if !ok {
+			continue
+		}
+
+		table, err := manager.TableByID(TableID(event.TableID))
+		if err != nil {
+			return fmt.Errorf("ValidateChangeEvents: %w", err)
+		}
+		if table != nil && table.Root() != event.Root { // Tables created by the transaction don't exist yet
+			return newConflictError(table, nil, "truncated table was changed by concurrent transaction")
+		}
+	}
+
+	return nil
+}
+
 func (manager *TableManager) ApplyChangeEvents(changeEvents []TableEvent) (res ApplyResult, err error) {
 	root := manager.catalog.Root()
 	snapshot := manager.pager.Snapshot()
@@ -212,12 +459,10 @@ func (manager *TableManager) ApplyChangeEvents(changeEvents []TableEvent) (res A
 			manager.pager.Restore(snapshot)
 			manager.catalog, _ = newTable(CATALOG_TABLE_ID, root, manager.pager, &catalogSchema) // In case of error we restore previous catalog state
 			manager.loadedTables = make(map[TableID]*Table)
+			manager.references = nil
 		}
 
-		res.Root = manager.catalog.Root()
-		res.PageChanges.PagesCount = manager.pager.PagesCount()
-		res.PageChanges.ReusablePages = manager.pager.ReusablePages()
-		res.PageChanges.RetiredPages = manager.pager.RetiredPages()
+		res.Root, res.PageChanges = manager.pageChanges()
 	}()
 
 	for _, changeEvent := range changeEvents {
@@ -252,6 +497,36 @@ func (manager *TableManager) ApplyChangeEvents(changeEvents []TableEvent) (res A
 				return
 			}
 
+		case *events.UpdateSequence:
+			if err = manager.applyUpdateSequenceEvent(event); err != nil {
+				return
+			}
+
+		case *events.UpdateTable:
+			if err = manager.applyUpdateTableEvent(event); err != nil {
+				return
+			}
+
+		case *events.TruncateTable:
+			if err = manager.applyTruncateTableEvent(event); err != nil {
+				return
+			}
+
+		case *events.RenameTable:
+			if err = manager.applyRenameTableEvent(event); err != nil {
+				return
+			}
+
+		case *events.ReclaimTable:
+			if err = manager.applyReclaimTableEvent(event); err != nil {
+				return
+			}
+
+		case *events.PurgeTable:
+			if err = manager.applyPurgeTableEvent(event); err != nil {
+				return
+			}
+
 		case *events.StartTransaction,
 			*events.CommitTransaction,
 			*events.FreePages:
@@ -263,11 +538,25 @@ func (manager *TableManager) ApplyChangeEvents(changeEvents []TableEvent) (res A
 		}
 	}
 
+	if err = manager.validateForeignKeys(changeEvents); err != nil {
+		return
+	}
+
 	err = manager.saveChanges()
 
 	return
 }
 
+// pageChanges returns catalog root and page changes of events applied so far
+func (manager *TableManager) pageChanges() (pager.PagePointer, PageChanges) {
+	return manager.catalog.Root(), PageChanges{
+		PagesCount:    manager.pager.PagesCount(),
+		ReusablePages: manager.pager.ReusablePages(),
+		RetiredPages:  manager.pager.RetiredPages(),
+		Stats:         manager.pager.Stats(),
+	}
+}
+
 func (manager *TableManager) Commit(headerData []byte) error {
 	if err := manager.pager.UpdatePage(HEADER_PAGE, headerData); err != nil {
 		return fmt.Errorf("Commit: couldn't update header page: %w", err)
@@ -308,11 +597,13 @@ func (manager *TableManager) applyCreateTableEvent(event *events.CreateTable) er
 		return fmt.Errorf("CreateTable Apply: couldn't create table %q: %w", schema.Name, err)
 	}
 
-	if err := manager.catalog.Insert(manager.encodeTable(table)); err != nil {
+	if _, err := manager.catalog.Insert(manager.encodeTable(table)); err != nil {
 		return fmt.Errorf("CreateTable Apply: couldn't insert table %q into catalog: %w", schema.Name, err)
 	}
 
+	table.manager = manager
 	manager.loadedTables[table.id] = table
+	manager.references = nil
 
 	return nil
 }
@@ -333,18 +624,109 @@ func (manager *TableManager) applyDropTableEvent(event *events.DropTable) error
 	return nil
 }
 
+func (manager *TableManager) applyTruncateTableEvent(event *events.TruncateTable) error {
+	table, err := manager.TableByID(TableID(event.TableID))
+	if err != nil {
+		return err
+	}
+	if table == nil {
+		return &ConflictError{TableID: TableID(event.TableID), Reason: "table was dropped by concurrent transaction"}
+	}
+
+	table.kv.Clear()
+
+	return nil
+}
+
+func (manager *TableManager) applyRenameTableEvent(event *events.RenameTable) error {
+	table, err := manager.TableByID(TableID(event.TableID))
+	if err != nil {
+		return err
+	}
+	if table == nil {
+		return &ConflictError{TableID: TableID(event.TableID), Reason: "table was dropped by concurrent transaction"}
+	}
+
+	if table.schema.Name != event.OldName {
+		return newConflictError(table, nil, "table was renamed by concurrent transaction")
+	}
+
+	existing, err := manager.Table(event.NewName)
+	if err != nil {
+		return fmt.Errorf("RenameTable Apply: couldn't check if table %q already exists: %w", event.NewName, err)
+	}
+	if existing != nil {
+		return newConflictError(table, nil, fmt.Sprintf("table %q was created by concurrent transaction", event.NewName))
+	}
+
+	if err := manager.renameTable(table, event.NewName); err != nil {
+		return fmt.Errorf("RenameTable Apply: %w", err)
+	}
+
+	return nil
+}
+
+// applyReclaimTableEvent frees every page of the dropped table tree, pages are retired through the pager,
+// so they are reused once transactions which could read them are finished. Replay of reclaimed table does nothing.
+func (manager *TableManager) applyReclaimTableEvent(event *events.ReclaimTable) error {
+	table, err := manager.droppedTable(TableID(event.TableID))
+	if err != nil {
+		return err
+	}
+	if table == nil || table.state == TABLE_DROPPED {
+		return nil
+	}
+	if table.state != TABLE_DROPPING {
+		return fmt.Errorf("ReclaimTable Apply: table %q isn't dropped", table.schema.Name)
+	}
+
+	table.kv.Clear()
+	table.state = TABLE_DROPPED
+	table.stateVersion = manager.state.Version
+
+	if _, err := manager.catalog.Update(manager.encodeTable(table)); err != nil {
+		return fmt.Errorf("ReclaimTable Apply: couldn't update catalog entry of table %q: %w", table.schema.Name, err)
+	}
+
+	return nil
+}
+
+func (manager *TableManager) applyPurgeTableEvent(event *events.PurgeTable) error {
+	table, err := manager.droppedTable(TableID(event.TableID))
+	if err != nil {
+		return err
+	}
+	if table == nil {
+		return nil
+	}
+	if table.state != TABLE_DROPPED {
+		return fmt.Errorf("PurgeTable Apply: pages of table %q aren't reclaimed", table.schema.Name)
+	}
+
+	if _, err := manager.catalog.Delete(manager.buildTableQueryByID(table.id)); err != nil {
+		return fmt.Errorf("PurgeTable Apply: couldn't delete catalog entry of table %q: %w", table.schema.Name, err)
+	}
+
+	delete(manager.loadedTables, table.id)
+
+	return nil
+}
+
 func (manager *TableManager) applyDeleteEntryEvent(event *events.DeleteEntry) error {
 	table, err := manager.TableByID(TableID(event.TableID))
 	if err != nil {
 		return err
 	}
+	if table == nil {
+		return &ConflictError{TableID: TableID(event.TableID), Key: event.Key, Reason: "table was dropped by concurrent transaction"}
+	}
 
 	response, err := table.kv.Delete(&kv.DeleteRequest{Key: event.Key})
 	if err != nil {
 		return fmt.Errorf("DeleteEntry Apply: %w", err)
 	}
 	if !bytes.Equal(response.OldValue, event.Value) {
-		return fmt.Errorf("DeleteEntry Apply: old value does not match expected value")
+		return newConflictError(table, event.Key, "deleted entry was changed by concurrent transaction")
 	}
 
 	return nil
@@ -356,7 +738,7 @@ func (manager *TableManager) applyUpdateEntryEvent(event *events.UpdateEntry) er
 		return err
 	}
 	if table == nil {
-		return fmt.Errorf("UpdateEntry Apply: table with ID %d not found", event.TableID)
+		return &ConflictError{TableID: TableID(event.TableID), Key: event.Key, Reason: "table was dropped by concurrent transaction"}
 	}
 
 	response, err := table.kv.Set(&kv.SetRequest{Key: event.Key, Value: event.NewValue})
@@ -364,7 +746,7 @@ func (manager *TableManager) applyUpdateEntryEvent(event *events.UpdateEntry) er
 		return fmt.Errorf("UpdateEntry Apply: %w", err)
 	}
 	if !bytes.Equal(response.OldValue, event.OldValue) {
-		return fmt.Errorf("UpdateEntry Apply: old value does not match expected value")
+		return newConflictError(table, event.Key, "updated entry was changed by concurrent transaction")
 	}
 
 	return nil
@@ -376,7 +758,7 @@ func (manager *TableManager) applyInsertEntryEvent(event *events.InsertEntry) er
 		return err
 	}
 	if table == nil {
-		return fmt.Errorf("InsertEntry Apply: table with ID %d not found", event.TableID)
+		return &ConflictError{TableID: TableID(event.TableID), Key: event.Key, Reason: "table was dropped by concurrent transaction"}
 	}
 
 	response, err := table.kv.Set(&kv.SetRequest{Key: event.Key, Value: event.Value})
@@ -384,21 +766,92 @@ func (manager *TableManager) applyInsertEntryEvent(event *events.InsertEntry) er
 		return fmt.Errorf("InsertEntry Apply: %w", err)
 	}
 	if response.Updated {
-		return fmt.Errorf("InsertEntry Apply: expected insert but key already existed")
+		return newConflictError(table, event.Key, "inserted entry was created by concurrent transaction")
+	}
+
+	return nil
+}
+
+func (manager *TableManager) applyUpdateSequenceEvent(event *events.UpdateSequence) error {
+	table, err := manager.TableByID(TableID(event.TableID))
+	if err != nil {
+		return err
+	}
+	if table == nil {
+		return &ConflictError{TableID: TableID(event.TableID), Reason: "table was dropped by concurrent transaction"}
+	}
+
+	if table.sequence != event.OldValue {
+		return newConflictError(table, nil, "key sequence was advanced by concurrent transaction")
+	}
+
+	table.sequence = event.NewValue
+
+	return nil
+}
+
+// renameTable replaces the schema with a renamed copy, so savepoints keep the previous name, and updates the catalog
+// entry together with its name index
+func (manager *TableManager) renameTable(table *Table, name string) error {
+	schema := *table.schema
+	schema.Name = name
+	schema.ForeignKeys = slices.Clone(schema.ForeignKeys)
+
+	for idx := range schema.ForeignKeys { // Foreign keys referencing the table itself follow the new name
+		if schema.ForeignKeys[idx].Table == table.schema.Name {
+			schema.ForeignKeys[idx].Table = name
+		}
 	}
 
+	if err := table.setSchema(&schema); err != nil {
+		return err
+	}
+
+	if _, err := manager.catalog.Update(manager.encodeTable(table)); err != nil {
+		return fmt.Errorf("couldn't update catalog entry: %w", err)
+	}
+	manager.references = nil
+
 	return nil
 }
 
-func (manager *TableManager) buildTableQueryByName(name string) *vals.Object {
-	return vals.NewObject().Set("name", vals.NewString(name)).Set("state", vals.NewUint32(uint32(TABLE_ACTIVE)))
+// catalogTable decodes catalog entry of the table in any state without loading it, nil if there is no entry
+func (manager *TableManager) catalogTable(id TableID) (*Table, error) {
+	records, err := manager.catalog.Find(manager.buildTableQueryByID(id))
+	if err != nil {
+		return nil, fmt.Errorf("Table ID %d: couldn't read schema from catalog: %w", id, err)
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	table, err := manager.decodeTable(records[0])
+	if err != nil {
+		return nil, fmt.Errorf("TableByID %d: couldn't decode schema from catalog: %w", id, err)
+	}
+
+	return table, nil
+}
+
+// droppedTable returns loaded table with the id in any state, otherwise decodes it from the catalog
+func (manager *TableManager) droppedTable(id TableID) (*Table, error) {
+	if table, ok := manager.loadedTables[id]; ok {
+		return table, nil
+	}
+
+	return manager.catalogTable(id)
+}
+
+func (manager *TableManager) buildTableQueryByName(name string) *primitive.Object {
+	return primitive.NewObject().Set("name", primitive.NewString(name)).Set("state", primitive.NewUint32(uint32(TABLE_ACTIVE)))
 }
 
-func (manager *TableManager) buildTableQueryByID(id TableID) *vals.Object {
-	return vals.NewObject().Set("id", vals.NewUint64(uint64(id)))
+func (manager *TableManager) buildTableQueryByID(id TableID) *primitive.Object {
+	return primitive.NewObject().Set("id", primitive.NewUint64(uint64(id)))
 }
 
-func (manager *TableManager) decodeTable(record *vals.Object) (*Table, error) {
+func (manager *TableManager) decodeTable(record *primitive.Object) (*Table, error) {
 	id := TableID(record.GetUint64("id"))
 	state := TableState(record.GetUint32("state"))
 	definition := record.GetString("definition")
@@ -414,17 +867,30 @@ func (manager *TableManager) decodeTable(record *vals.Object) (*Table, error) {
 		return nil, fmt.Errorf("decodeTable: couldn't initialize table ID %d: %w", id, err)
 	}
 	table.state = state
+	table.readOnly = manager.readOnly
+
+	if record.Has("sequence") { // Catalogs created before key generators don't have sequences
+		table.sequence = record.GetUint64("sequence")
+	}
+	if record.Has("state_version") {
+		table.stateVersion = DatabaseVersion(record.GetUint64("state_version"))
+	}
+	table.trackReads = manager.trackReads
+	table.manager = manager
+	table.loaded = table.savepoint()
 
 	return table, nil
 }
 
-func (manager *TableManager) encodeTable(table *Table) *vals.Object {
+func (manager *TableManager) encodeTable(table *Table) *primitive.Object {
 	stringifiedSchema, _ := json.Marshal(table.schema)
 
-	return vals.NewObject().
-		Set("id", vals.NewUint64(uint64(table.id))).
-		Set("name", vals.NewString(table.schema.Name)).
-		Set("state", vals.NewUint32(uint32(TABLE_ACTIVE))).
-		Set("definition", vals.NewString(string(stringifiedSchema))).
-		Set("root", vals.NewUint64(table.Root()))
+	return primitive.NewObject().
+		Set("id", primitive.NewUint64(uint64(table.id))).
+		Set("name", primitive.NewString(table.schema.Name)).
+		Set("state", primitive.NewUint32(uint32(table.state))).
+		Set("state_version", primitive.NewUint64(uint64(table.stateVersion))).
+		Set("definition", primitive.NewString(string(stringifiedSchema))).
+		Set("root", primitive.NewUint64(table.Root())).
+		Set("sequence", primitive.NewUint64(table.sequence))
 }
@@ -1,9 +1,12 @@
 package db
 
 import (
+	"context"
+	"distributed-storage/internal/events"
 	"distributed-storage/internal/pager"
-	"distributed-storage/internal/vals"
+	"distributed-storage/internal/primitive"
 	"testing"
+	"time"
 )
 
 func newTestManager(t *testing.T) *TableManager {
@@ -40,8 +43,8 @@ func TestTableManager_CreateTable_Success(t *testing.T) {
 	schema := &TableSchema{
 		Name:         "orders",
 		PrimaryIndex: []string{"id"},
-		IndexedColumns: map[string]vals.ValueType{
-			"id": vals.TYPE_UINT64,
+		IndexedColumns: map[string]primitive.PrimitiveType{
+			"id": primitive.TYPE_UINT64,
 		},
 	}
 	table, err := m.CreateTable(schema)
@@ -61,8 +64,8 @@ func TestTableManager_CreateTable_DuplicateName_ReturnsError(t *testing.T) {
 	schema := &TableSchema{
 		Name:         "users",
 		PrimaryIndex: []string{"id"},
-		IndexedColumns: map[string]vals.ValueType{
-			"id": vals.TYPE_UINT64,
+		IndexedColumns: map[string]primitive.PrimitiveType{
+			"id": primitive.TYPE_UINT64,
 		},
 	}
 	if _, err := m.CreateTable(schema); err != nil {
@@ -78,8 +81,8 @@ func TestTableManager_Table_FindByName_AfterCacheClear(t *testing.T) {
 	schema := &TableSchema{
 		Name:         "products",
 		PrimaryIndex: []string{"id"},
-		IndexedColumns: map[string]vals.ValueType{
-			"id": vals.TYPE_UINT64,
+		IndexedColumns: map[string]primitive.PrimitiveType{
+			"id": primitive.TYPE_UINT64,
 		},
 	}
 	if _, err := m.CreateTable(schema); err != nil {
@@ -105,8 +108,8 @@ func TestTableManager_DropTable_Existing(t *testing.T) {
 	schema := &TableSchema{
 		Name:         "temp",
 		PrimaryIndex: []string{"id"},
-		IndexedColumns: map[string]vals.ValueType{
-			"id": vals.TYPE_UINT64,
+		IndexedColumns: map[string]primitive.PrimitiveType{
+			"id": primitive.TYPE_UINT64,
 		},
 	}
 	if _, err := m.CreateTable(schema); err != nil {
@@ -115,8 +118,8 @@ func TestTableManager_DropTable_Existing(t *testing.T) {
 	if err := m.DropTable("temp"); err != nil {
 		t.Fatalf("DropTable failed: %v", err)
 	}
-	if len(m.loadedTables) != 0 {
-		t.Errorf("expected 0 loaded tables after drop, got %d", len(m.loadedTables))
+	if table, _ := m.Table("temp"); table != nil {
+		t.Error("expected dropped table to be hidden")
 	}
 }
 
@@ -139,8 +142,8 @@ func TestTableManager_ChangeEvents_AfterCreateTable(t *testing.T) {
 	schema := &TableSchema{
 		Name:         "events_test",
 		PrimaryIndex: []string{"id"},
-		IndexedColumns: map[string]vals.ValueType{
-			"id": vals.TYPE_UINT64,
+		IndexedColumns: map[string]primitive.PrimitiveType{
+			"id": primitive.TYPE_UINT64,
 		},
 	}
 	if _, err := m.CreateTable(schema); err != nil {
@@ -167,8 +170,8 @@ func TestTableManager_UpdateTable(t *testing.T) {
 	schema := &TableSchema{
 		Name:         "updatable",
 		PrimaryIndex: []string{"id"},
-		IndexedColumns: map[string]vals.ValueType{
-			"id": vals.TYPE_UINT64,
+		IndexedColumns: map[string]primitive.PrimitiveType{
+			"id": primitive.TYPE_UINT64,
 		},
 	}
 	table, err := m.CreateTable(schema)
@@ -180,3 +183,269 @@ func TestTableManager_UpdateTable(t *testing.T) {
 		t.Errorf("UpdateTable failed: %v", err)
 	}
 }
+
+func TestTableManager_ApplyChangeEvents_ReplaysTruncateAndRename(t *testing.T) {
+	testPager := newTestPager()
+	manager := newTableManager(TableManagerState{}, func() TableID { return 1 }, testPager)
+	table, _ := manager.CreateTable(basicSchema())
+	for id := uint64(1); id <= 300; id++ {
+		table.Insert(userRecord(id, "user"))
+	}
+
+	changes := []TableEvent{events.NewTruncateTable(uint64(table.id), table.Root()), events.NewRenameTable(uint64(table.id), "users", "accounts")}
+	if _, err := manager.ApplyChangeEvents(changes); err != nil {
+		t.Fatalf("ApplyChangeEvents: %v", err)
+	}
+
+	replica := newTableManager(TableManagerState{Root: manager.catalog.Root()}, func() TableID { return 2 }, testPager)
+	if old, _ := replica.Table("users"); old != nil {
+		t.Error("expected old name to be released")
+	}
+
+	renamed, _ := replica.Table("accounts")
+	if renamed == nil || renamed.id != table.id {
+		t.Fatalf("expected table %d under the new name, got %v", table.id, renamed)
+	}
+	if records, _ := renamed.GetAll(); renamed.Root() != pager.NULL_PAGE || len(records) != 0 {
+		t.Errorf("expected empty truncated table, got root %d", renamed.Root())
+	}
+
+	if _, err := replica.ApplyChangeEvents(changes[1:]); !IsConflict(err) {
+		t.Errorf("expected conflict for rename of outdated name, got %v", err)
+	}
+}
+
+func TestTableManager_RenameTable_RejectsTakenName(t *testing.T) {
+	m := newTestManager(t)
+	m.CreateTable(basicSchema())
+	m.CreateTable(typedSchema())
+
+	if err := m.RenameTable("users", "accounts"); err == nil {
+		t.Error("expected rename to existing table name to fail")
+	}
+	if err := m.RenameTable("missing", "other"); err == nil {
+		t.Error("expected rename of missing table to fail")
+	}
+}
+
+func TestTransaction_TruncateTable_WritesSingleEvent(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	tx, _ := db.Begin(context.Background(), TransactionOptions{})
+	table, _ := tx.Table("users")
+	if err := tx.Savepoint("before"); err != nil {
+		t.Fatalf("Savepoint: %v", err)
+	}
+	if err := tx.TruncateTable("users"); err != nil {
+		t.Fatalf("TruncateTable: %v", err)
+	}
+
+	if records, _ := table.GetAll(); len(records) != 0 {
+		t.Error("expected truncated table to be empty within the transaction")
+	}
+
+	tx.RollbackTo("before")
+	if records, _ := table.GetAll(); len(records) != 1 {
+		t.Error("expected rollback to savepoint to restore truncated records")
+	}
+
+	tx.TruncateTable("users")
+	table.Insert(userRecord(2, "bob"))
+
+	if changes := tx.manager.ChangeEvents(); len(changes) != 2 || changes[0].Type() != events.TRUNCATE_TABLE_EVENT {
+		t.Errorf("expected truncate event followed by insert, got %v", changes)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	db.StartTransaction(func(tx *Transaction) {
+		table, _ := tx.Table("users")
+		if records, _ := table.GetAll(); len(records) != 1 || records[0].GetUint64("id") != 2 {
+			t.Errorf("expected only record inserted after truncate, got %v", records)
+		}
+	})
+}
+
+func TestTransaction_TruncateTable_ConcurrentWriteConflicts(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	truncate, _ := db.Begin(context.Background(), TransactionOptions{})
+	if err := truncate.TruncateTable("users"); err != nil {
+		t.Fatalf("TruncateTable: %v", err)
+	}
+
+	db.StartTransaction(func(tx *Transaction) {
+		table, _ := tx.Table("users")
+		table.Insert(userRecord(2, "bob"))
+	})
+
+	if err := truncate.Commit(); !IsConflict(err) {
+		t.Errorf("expected conflict for truncate of concurrently changed table, got %v", err)
+	}
+
+	db.StartTransaction(func(tx *Transaction) {
+		table, _ := tx.Table("users")
+		if records, _ := table.GetAll(); len(records) != 2 {
+			t.Errorf("expected concurrently inserted record to be kept, got %v", records)
+		}
+	})
+}
+
+func TestTransaction_TruncateTable_AfterOwnWritesCommits(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	tx, _ := db.Begin(context.Background(), TransactionOptions{})
+	table, _ := tx.Table("users")
+	table.Insert(userRecord(2, "bob"))
+	if err := tx.TruncateTable("users"); err != nil {
+		t.Fatalf("TruncateTable: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("expected truncate after own writes to commit, got %v", err)
+	}
+
+	db.StartTransaction(func(tx *Transaction) {
+		table, _ := tx.Table("users")
+		if records, _ := table.GetAll(); len(records) != 0 {
+			t.Errorf("expected truncated table to be empty, got %v", records)
+		}
+	})
+}
+
+func TestTransaction_RenameTable_ConcurrentRenamesConflict(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+
+	first, _ := db.Begin(context.Background(), TransactionOptions{})
+	second, _ := db.Begin(context.Background(), TransactionOptions{})
+	if err := first.RenameTable("users", "customers"); err != nil {
+		t.Fatalf("RenameTable: %v", err)
+	}
+	if table, _ := first.Table("customers"); table == nil {
+		t.Error("expected renamed table to be visible within the transaction")
+	}
+	second.MigrateTable("users", AddColumn(Column{Name: "age", Type: primitive.TYPE_UINT32, Nullable: true}))
+
+	if err := first.Commit(); err != nil {
+		t.Fatalf("first Commit failed: %v", err)
+	}
+	if err := second.Commit(); !IsConflict(err) {
+		t.Errorf("expected conflict for migration of renamed table, got %v", err)
+	}
+
+	db.StartTransaction(func(tx *Transaction) {
+		table, _ := tx.Table("customers")
+		if table == nil {
+			t.Fatal("expected table under the new name")
+		}
+		if records, _ := table.GetAll(); len(records) != 1 {
+			t.Error("expected records to be kept under the new name")
+		}
+	})
+}
+
+func TestTableManager_ApplyChangeEvents_ReclaimsDroppedTable(t *testing.T) {
+	testPager := newTestPager()
+	manager := newTableManager(TableManagerState{Version: 3}, func() TableID { return 1 }, testPager)
+	table, _ := manager.CreateTable(basicSchema())
+	for id := uint64(1); id <= 300; id++ {
+		table.Insert(userRecord(id, "user"))
+	}
+	manager.saveChanges()
+
+	if err := manager.DropTable("users"); err != nil {
+		t.Fatalf("DropTable: %v", err)
+	}
+	if dropped, _ := manager.TableByID(table.id); dropped != nil {
+		t.Error("expected dropped table to be hidden from TableByID")
+	}
+
+	if reclaimEvents, _ := manager.ReclaimEvents(2); len(reclaimEvents) != 0 {
+		t.Errorf("expected table dropped at version 3 to be visible at version 2, got %v", reclaimEvents)
+	}
+
+	reclaimEvents, _ := manager.ReclaimEvents(3)
+	if len(reclaimEvents) != 1 || reclaimEvents[0].Type() != events.RECLAIM_TABLE_EVENT {
+		t.Fatalf("expected reclaim event, got %v", reclaimEvents)
+	}
+
+	for range 2 { // Replay of reclaimed table does nothing
+		if _, err := manager.ApplyChangeEvents(reclaimEvents); err != nil {
+			t.Fatalf("ApplyChangeEvents: %v", err)
+		}
+	}
+
+	reclaimed, _ := manager.catalogTable(table.id)
+	if reclaimed.state != TABLE_DROPPED || reclaimed.Root() != pager.NULL_PAGE {
+		t.Errorf("expected dropped table with empty tree, got state %d and root %d", reclaimed.state, reclaimed.Root())
+	}
+	if testPager.RetiredPages().Empty() && testPager.ReusablePages().Empty() {
+		t.Error("expected pages of dropped table to be freed")
+	}
+
+	purgeEvents, _ := manager.ReclaimEvents(3)
+	if len(purgeEvents) != 1 || purgeEvents[0].Type() != events.PURGE_TABLE_EVENT {
+		t.Fatalf("expected purge event, got %v", purgeEvents)
+	}
+	if _, err := manager.ApplyChangeEvents(purgeEvents); err != nil {
+		t.Fatalf("ApplyChangeEvents: %v", err)
+	}
+	if purged, _ := manager.catalogTable(table.id); purged != nil {
+		t.Error("expected catalog entry to be purged")
+	}
+}
+
+func TestDatabase_ReclaimDroppedTables_ReusesPages(t *testing.T) {
+	db, err := NewDatabase(newTestDatabaseConfig(t))
+	if err != nil {
+		t.Fatalf("NewDatabase failed: %v", err)
+	}
+
+	fill := func(name string) {
+		schema := basicSchema()
+		schema.Name = name
+		db.StartTransaction(func(tx *Transaction) {
+			table, _ := tx.CreateTable(schema)
+			for id := uint64(1); id <= 2000; id++ {
+				table.Insert(userRecord(id, "user"))
+			}
+		})
+	}
+	pagesCount := func() uint64 {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+		return db.header.pagesCount
+	}
+
+	fill("sessions")
+	filledPages := pagesCount()
+
+	if err := db.StartTransaction(func(tx *Transaction) { tx.DropTable("sessions") }); err != nil {
+		t.Fatalf("DropTable: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if err := db.reclaimDroppedTables(); err != nil {
+			t.Fatalf("reclaimDroppedTables: %v", err)
+		}
+
+		tx, _ := db.Begin(context.Background(), TransactionOptions{ReadOnly: true})
+		records, _ := tx.manager.catalog.GetAll()
+		tx.Rollback()
+
+		if len(records) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected dropped table to be purged, %d catalog entries remain", len(records))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	fill("sessions")
+
+	if grown := pagesCount() - filledPages; grown > filledPages/2 {
+		t.Errorf("expected pages of dropped table to be reused, storage grew by %d pages from %d", grown, filledPages)
+	}
+}
@@ -0,0 +1,441 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"distributed-storage/internal/codec"
+	"distributed-storage/internal/events"
+	"distributed-storage/internal/kv"
+	"distributed-storage/internal/primitive"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+)
+
+const ROW_VERSION_FIELD = "@version" // Reserved payload field with schema version the row was written with
+
+const (
+	MIGRATION_ADD_COLUMN MigrationOperationKind = iota + 1
+	MIGRATION_RENAME_COLUMN
+	MIGRATION_DROP_COLUMN
+	MIGRATION_WIDEN_TYPE
+)
+
+type MigrationOperationKind uint8
+
+// MigrationOperation is a single change of table columns. Rows written with older schema versions are upgraded
+// by replaying operations of newer migrations, so operations must stay valid for rows of any older version.
+type MigrationOperation struct {
+	Kind     MigrationOperationKind
+	Column   string
+	NewName  string                  // New column name of MIGRATION_RENAME_COLUMN
+	Type     primitive.PrimitiveType // Column type of MIGRATION_ADD_COLUMN and MIGRATION_WIDEN_TYPE
+	Nullable bool                    // Nullability of column added by MIGRATION_ADD_COLUMN
+	Default  []byte                  // Value encoded by codec.EncodeValue which MIGRATION_ADD_COLUMN sets in existing rows
+}
+
+type Migration struct {
+	Version    uint32
+	Operations []MigrationOperation
+}
+
+// Widening conversions allowed by MIGRATION_WIDEN_TYPE, every value of the source type is representable in the target one
+var wideningConversions = map[primitive.PrimitiveType][]primitive.PrimitiveType{
+	primitive.TYPE_INT32:  {primitive.TYPE_INT64, primitive.TYPE_FLOAT64, primitive.TYPE_DECIMAL},
+	primitive.TYPE_UINT32: {primitive.TYPE_UINT64, primitive.TYPE_INT64, primitive.TYPE_FLOAT64, primitive.TYPE_DECIMAL},
+	primitive.TYPE_INT64:  {primitive.TYPE_DECIMAL},
+	primitive.TYPE_UINT64: {primitive.TYPE_DECIMAL},
+	primitive.TYPE_DATE:   {primitive.TYPE_TIMESTAMP},
+}
+
+func AddColumn(column Column) MigrationOperation {
+	return MigrationOperation{Kind: MIGRATION_ADD_COLUMN, Column: column.Name, Type: column.Type, Nullable: column.Nullable, Default: column.Default}
+}
+
+func RenameColumn(column string, newName string) MigrationOperation {
+	return MigrationOperation{Kind: MIGRATION_RENAME_COLUMN, Column: column, NewName: newName}
+}
+
+func DropColumn(column string) MigrationOperation {
+	return MigrationOperation{Kind: MIGRATION_DROP_COLUMN, Column: column}
+}
+
+func WidenColumn(column string, columnType primitive.PrimitiveType) MigrationOperation {
+	return MigrationOperation{Kind: MIGRATION_WIDEN_TYPE, Column: column, Type: columnType}
+}
+
+// migrate returns a copy of the schema with the next version which applies the operations
+func (schema *TableSchema) migrate(operations []MigrationOperation) (*TableSchema, error) {
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("Migration: at least one operation is required")
+	}
+
+	migrated := schema.clone()
+	migrated.Version++
+	migrated.Migrations = append(migrated.Migrations, Migration{Version: migrated.Version, Operations: operations})
+
+	for _, operation := range operations {
+		if operation.Column == "" {
+			return nil, fmt.Errorf("Migration: operation %d must have a column", operation.Kind)
+		}
+
+		if schema.isIndexed(operation.Column) || schema.isIndexed(operation.NewName) {
+			return nil, fmt.Errorf("Migration: indexed column %q can't be changed", operation.Column)
+		}
+
+		if err := migrated.applyColumnOperation(operation); err != nil {
+			return nil, err
+		}
+	}
+
+	return migrated, nil
+}
+
+func (schema *TableSchema) applyColumnOperation(operation MigrationOperation) error {
+	idx := slices.IndexFunc(schema.Columns, func(column Column) bool { return column.Name == operation.Column })
+	typed := len(schema.Columns) > 0 // Schemaless tables only record operations for row upgrades
+
+	switch operation.Kind {
+	case MIGRATION_ADD_COLUMN:
+		if idx >= 0 {
+			return fmt.Errorf("Migration: column %q already exists", operation.Column)
+		}
+		if operation.Default == nil && !operation.Nullable && typed {
+			return fmt.Errorf("Migration: column %q must be nullable or have a default value", operation.Column)
+		}
+		if operation.Default != nil {
+			var defaultValue primitive.Primitive
+			var err error
+			if defaultValue, _, err = codec.DecodeValue(operation.Default); err != nil {
+				return fmt.Errorf("Migration: couldn't decode default value of column %q: %w", operation.Column, err)
+			}
+			if defaultValue.Type() != operation.Type {
+				return fmt.Errorf("Migration: default value of column %q has type %s, expected %s",
+					operation.Column, primitive.TypeName(defaultValue.Type()), primitive.TypeName(operation.Type))
+			}
+		}
+		if typed {
+			schema.Columns = append(schema.Columns, Column{Name: operation.Column, Type: operation.Type, Nullable: operation.Nullable, Default: operation.Default})
+		}
+
+	case MIGRATION_RENAME_COLUMN:
+		if operation.NewName == "" || operation.NewName == ROW_VERSION_FIELD {
+			return fmt.Errorf("Migration: column %q can't be renamed to %q", operation.Column, operation.NewName)
+		}
+		if typed && idx < 0 {
+			return fmt.Errorf("Migration: column %q doesn't exist", operation.Column)
+		}
+		if slices.ContainsFunc(schema.Columns, func(column Column) bool { return column.Name == operation.NewName }) {
+			return fmt.Errorf("Migration: column %q already exists", operation.NewName)
+		}
+		if typed {
+			schema.Columns[idx].Name = operation.NewName
+		}
+
+	case MIGRATION_DROP_COLUMN:
+		if typed && idx < 0 {
+			return fmt.Errorf("Migration: column %q doesn't exist", operation.Column)
+		}
+		if typed {
+			schema.Columns = slices.Delete(schema.Columns, idx, idx+1)
+		}
+
+	case MIGRATION_WIDEN_TYPE:
+		if !typed || idx < 0 {
+			return fmt.Errorf("Migration: type of column %q is unknown, so it can't be widened", operation.Column)
+		}
+		if !slices.Contains(wideningConversions[schema.Columns[idx].Type], operation.Type) {
+			return fmt.Errorf("Migration: column %q of type %s can't be widened to %s",
+				operation.Column, primitive.TypeName(schema.Columns[idx].Type), primitive.TypeName(operation.Type))
+		}
+		if schema.Columns[idx].Default != nil {
+			return fmt.Errorf("Migration: column %q with default value can't be widened", operation.Column)
+		}
+		schema.Columns[idx].Type = operation.Type
+
+	default:
+		return fmt.Errorf("Migration: unknown operation %d", operation.Kind)
+	}
+
+	return nil
+}
+
+func (schema *TableSchema) isIndexed(column string) bool {
+	if slices.Contains(schema.PrimaryIndex, column) {
+		return true
+	}
+
+	for _, secondaryIndex := range schema.SecondaryIndexes {
+		if slices.Contains(secondaryIndex.Columns, column) || slices.Contains(secondaryIndex.Include, column) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (schema *TableSchema) clone() *TableSchema {
+	cloned := *schema
+	cloned.Columns = slices.Clone(schema.Columns)
+	cloned.Migrations = slices.Clone(schema.Migrations)
+	cloned.ForeignKeys = slices.Clone(schema.ForeignKeys)
+	if schema.TTL != nil {
+		ttl := *schema.TTL
+		cloned.TTL = &ttl
+	}
+	cloned.IndexedColumns = make(map[string]primitive.PrimitiveType, len(schema.IndexedColumns))
+
+	for name, columnType := range schema.IndexedColumns {
+		cloned.IndexedColumns[name] = columnType
+	}
+
+	return &cloned
+}
+
+// MigrateTable applies operations as the next schema version of the table. Existing rows are upgraded lazily
+// when they are read, Database.MigrateRows rewrites them in the background.
+func (manager *TableManager) MigrateTable(name string, operations ...MigrationOperation) (*Table, error) {
+	table, err := manager.Table(name)
+	if err != nil {
+		return nil, fmt.Errorf("MigrateTable %q: couldn't load table: %w", name, err)
+	}
+	if table == nil {
+		return nil, fmt.Errorf("MigrateTable %q: table doesn't exist", name)
+	}
+	if table.readOnly {
+		return nil, fmt.Errorf("MigrateTable %q: %w", name, ErrReadOnlyTransaction)
+	}
+
+	schema, err := table.schema.migrate(operations)
+	if err != nil {
+		return nil, fmt.Errorf("MigrateTable %q: %w", name, err)
+	}
+
+	if err := table.setSchema(schema); err != nil {
+		return nil, fmt.Errorf("MigrateTable %q: %w", name, err)
+	}
+
+	if err := manager.UpdateTable(table); err != nil {
+		return nil, fmt.Errorf("MigrateTable %q: %w", name, err)
+	}
+
+	return table, nil
+}
+
+func (manager *TableManager) applyUpdateTableEvent(event *events.UpdateTable) error {
+	table, err := manager.TableByID(TableID(event.TableID))
+	if err != nil {
+		return err
+	}
+	if table == nil {
+		return &ConflictError{TableID: TableID(event.TableID), Reason: "table was dropped by concurrent transaction"}
+	}
+
+	oldSchema, newSchema := &TableSchema{}, &TableSchema{}
+	if err := json.Unmarshal(event.OldSchema, oldSchema); err != nil {
+		return fmt.Errorf("UpdateTable Apply: couldn't parse old schema: %w", err)
+	}
+	if err := json.Unmarshal(event.NewSchema, newSchema); err != nil {
+		return fmt.Errorf("UpdateTable Apply: couldn't parse new schema: %w", err)
+	}
+
+	if table.schema.Version != oldSchema.Version {
+		return newConflictError(table, nil, "table schema was migrated by concurrent transaction")
+	}
+	if table.schema.Name != oldSchema.Name {
+		return newConflictError(table, nil, "table was renamed by concurrent transaction")
+	}
+
+	if err := table.setSchema(newSchema); err != nil {
+		return fmt.Errorf("UpdateTable Apply: %w", err)
+	}
+
+	return nil
+}
+
+// MigrateRows rewrites rows written with older schema versions of the table, batchSize rows per transaction,
+// so migration doesn't block concurrent writers. It returns number of rewritten rows.
+func (db *Database) MigrateRows(ctx context.Context, tableName string, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		return 0, fmt.Errorf("Database: batch size must be positive, got %d", batchSize)
+	}
+
+	var from []byte
+	total := 0
+
+	for {
+		var next []byte
+		var rewritten int
+
+		err := db.RunInTransaction(ctx, func(tx *Transaction) error {
+			table, err := tx.Table(tableName)
+			if err != nil {
+				return err
+			}
+			if table == nil {
+				return fmt.Errorf("Database: table %q doesn't exist", tableName)
+			}
+
+			next, rewritten, err = table.upgradeRows(from, batchSize)
+			return err
+		}, RetryPolicy{})
+		if err != nil {
+			return total, fmt.Errorf("Database: couldn't migrate rows of table %q: %w", tableName, err)
+		}
+
+		total += rewritten
+		if next == nil {
+			return total, nil
+		}
+		from = next
+	}
+}
+
+func (table *Table) setSchema(schema *TableSchema) error {
+	previous := table.schema
+	table.schema = schema
+
+	if err := table.validateTableSchema(); err != nil {
+		table.schema = previous
+		return err
+	}
+
+	return nil
+}
+
+// upgradeRows rewrites up to limit outdated rows starting from primary index key from. It returns the key
+// to continue from, which is nil once the whole primary index is scanned.
+func (table *Table) upgradeRows(from []byte, limit int) (next []byte, rewritten int, err error) {
+	if table.readOnly {
+		return nil, 0, fmt.Errorf("Table: can't migrate rows of table %q: %w", table.schema.Name, ErrReadOnlyTransaction)
+	}
+
+	prefix := table.indexPrefix(PRIMARY_INDEX_ID)
+	if from == nil {
+		from = prefix
+	}
+
+	type outdatedRow struct{ key, value []byte }
+	var rows []outdatedRow
+
+	cursor := table.kv.Scan(&kv.ScanRequest{Key: from})
+	for key, value := cursor.Current(); table.matchIndexes(key, prefix); key, value = cursor.Next() {
+		if len(rows) == limit {
+			next = bytes.Clone(key)
+			break
+		}
+
+		version, err := table.payloadVersion(value)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if version < table.schema.Version {
+			rows = append(rows, outdatedRow{key: bytes.Clone(key), value: bytes.Clone(value)})
+		}
+	}
+
+	for _, row := range rows {
+		table.recordRead(row.key, row.value)
+
+		record, err := table.decodeRecord(row.value)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		newValue := table.encodeRow(record, row.value)
+		if _, err := table.kv.Set(&kv.SetRequest{Key: row.key, Value: newValue}); err != nil {
+			return nil, 0, err
+		}
+
+		table.changeEvents = append(table.changeEvents, events.NewUpdateEntry(uint64(table.id), row.key, row.value, newValue))
+	}
+
+	return next, len(rows), nil
+}
+
+// upgradeRecord replays migrations newer than the row version on the decoded record
+func (table *Table) upgradeRecord(record *primitive.Object, version uint32) {
+	for _, migration := range table.schema.Migrations {
+		if migration.Version <= version {
+			continue
+		}
+
+		for _, operation := range migration.Operations {
+			upgradeField(record, operation)
+		}
+	}
+}
+
+// upgradeField applies operation to a single row. Defaults and conversions are validated when migration is declared,
+// so values which still can't be converted are left as is and reported by record validation on the next write.
+func upgradeField(record *primitive.Object, operation MigrationOperation) {
+	value := record.Get(operation.Column)
+
+	switch operation.Kind {
+	case MIGRATION_ADD_COLUMN:
+		if value.Empty() && operation.Default != nil {
+			var defaultValue primitive.Primitive
+			var err error
+			if defaultValue, _, err = codec.DecodeValue(operation.Default); err == nil {
+				record.Set(operation.Column, defaultValue)
+			}
+		}
+
+	case MIGRATION_RENAME_COLUMN:
+		if record.Has(operation.Column) {
+			record.Delete(operation.Column).Set(operation.NewName, value)
+		}
+
+	case MIGRATION_DROP_COLUMN:
+		record.Delete(operation.Column)
+
+	case MIGRATION_WIDEN_TYPE:
+		if !value.Empty() && value.Type() != operation.Type {
+			if widened, err := widenValue(value, operation.Type); err == nil {
+				record.Set(operation.Column, widened)
+			}
+		}
+	}
+}
+
+func widenValue(value primitive.Primitive, target primitive.PrimitiveType) (primitive.Primitive, error) {
+	if !slices.Contains(wideningConversions[value.Type()], target) {
+		return nil, fmt.Errorf("value of type %s can't be widened to %s", primitive.TypeName(value.Type()), primitive.TypeName(target))
+	}
+
+	var integer int64
+	var unsigned uint64
+
+	switch value := value.(type) {
+	case *primitive.Int32:
+		integer = int64(value.Value())
+	case *primitive.Int64:
+		integer = value.Value()
+	case *primitive.Uint32:
+		unsigned = uint64(value.Value())
+		integer = int64(value.Value())
+	case *primitive.Uint64:
+		unsigned = value.Value()
+	case *primitive.Date:
+		if target == primitive.TYPE_TIMESTAMP {
+			return primitive.NewTimestamp(value.Value()), nil
+		}
+	}
+
+	switch {
+	case target == primitive.TYPE_INT64 && value.Type() != primitive.TYPE_UINT64:
+		return primitive.NewInt64(integer), nil
+	case target == primitive.TYPE_UINT64 && value.Type() == primitive.TYPE_UINT32:
+		return primitive.NewUint64(unsigned), nil
+	case target == primitive.TYPE_FLOAT64 && value.Type() != primitive.TYPE_UINT64:
+		return primitive.NewFloat64(float64(integer)), nil
+	case target == primitive.TYPE_DECIMAL && value.Type() == primitive.TYPE_UINT64:
+		return primitive.NewDecimal(new(big.Int).SetUint64(unsigned), 0), nil
+	case target == primitive.TYPE_DECIMAL:
+		return primitive.NewDecimalFromInt(integer), nil
+	}
+
+	return nil, fmt.Errorf("value of type %s can't be widened to %s", primitive.TypeName(value.Type()), primitive.TypeName(target))
+}
@@ -0,0 +1,255 @@
+package db
+
+import (
+	"context"
+	"distributed-storage/internal/codec"
+	"distributed-storage/internal/events"
+	"distributed-storage/internal/kv"
+	"distributed-storage/internal/pager"
+	"distributed-storage/internal/primitive"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func migrateTable(t *testing.T, table *Table, operations ...MigrationOperation) {
+	t.Helper()
+	schema, err := table.schema.migrate(operations)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := table.setSchema(schema); err != nil {
+		t.Fatalf("setSchema: %v", err)
+	}
+}
+
+func TestTable_Migrate_UpgradesOldRowsOnRead(t *testing.T) {
+	table := newTypedTable(t, typedSchema())
+	table.Insert(userRecord(1, "Alice").Set("email", primitive.NewString("alice@example.com")))
+
+	migrateTable(t, table,
+		AddColumn(Column{Name: "age", Type: primitive.TYPE_UINT32}.WithDefault(primitive.NewUint32(18))),
+		RenameColumn("name", "full_name"),
+		DropColumn("email"),
+	)
+
+	record, _ := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(1)))
+	if record.GetUint32("age") != 18 {
+		t.Errorf("expected default age 18, got %v", record.Get("age"))
+	}
+	if record.GetString("full_name") != "Alice" || record.Has("name") {
+		t.Errorf("expected column 'name' to be renamed, got %s", record)
+	}
+	if record.Has("email") {
+		t.Errorf("expected column 'email' to be dropped, got %s", record)
+	}
+	if record.Has(ROW_VERSION_FIELD) {
+		t.Errorf("expected row version to be hidden, got %s", record)
+	}
+}
+
+func TestTable_Migrate_WidensColumnType(t *testing.T) {
+	schema := typedSchema()
+	schema.Columns = append(schema.Columns, Column{Name: "visits", Type: primitive.TYPE_INT32})
+	table := newTypedTable(t, schema)
+	table.Insert(userRecord(1, "Alice").Set("visits", primitive.NewInt32(-3)))
+
+	migrateTable(t, table, WidenColumn("visits", primitive.TYPE_DECIMAL))
+
+	record, _ := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(1)))
+	if !record.Get("visits").Equal(primitive.NewDecimalFromInt(-3)) {
+		t.Errorf("expected widened decimal -3, got %v", record.Get("visits"))
+	}
+
+	if _, err := table.Insert(userRecord(2, "Bob").Set("visits", primitive.NewInt32(1))); err == nil {
+		t.Error("expected insert with old column type to be rejected")
+	}
+}
+
+func TestTable_Migrate_RejectsInvalidOperations(t *testing.T) {
+	schema := typedSchema()
+
+	cases := map[string][]MigrationOperation{
+		`indexed column "id" can't be changed`:   {DropColumn("id")},
+		`column "name" already exists`:           {AddColumn(Column{Name: "name", Type: primitive.TYPE_STRING, Nullable: true})},
+		`must be nullable or have a default`:     {AddColumn(Column{Name: "age", Type: primitive.TYPE_UINT32})},
+		`column "missing" doesn't exist`:         {RenameColumn("missing", "other")},
+		`of type string can't be widened`:        {WidenColumn("name", primitive.TYPE_INT64)},
+		`at least one operation is required`:     {},
+		`column "email" already exists`:          {RenameColumn("name", "email")},
+		`default value of column "age" has type`: {AddColumn(Column{Name: "age", Type: primitive.TYPE_UINT32}.WithDefault(primitive.NewString("x")))},
+	}
+
+	for expected, operations := range cases {
+		_, err := schema.migrate(operations)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error containing %q, got %v", expected, err)
+		}
+	}
+
+	if schema.Version != 0 || len(schema.Migrations) != 0 {
+		t.Error("expected failed migrations to leave original schema untouched")
+	}
+}
+
+func TestTable_Migrate_SchemalessTableRecordsOperations(t *testing.T) {
+	table := newTestTable(t)
+	table.Insert(userRecordWithEmail(1, "Alice", "alice@example.com"))
+
+	migrateTable(t, table, RenameColumn("email", "contact"))
+
+	record, _ := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(1)))
+	if record.GetString("contact") != "alice@example.com" {
+		t.Errorf("expected renamed column in schemaless table, got %s", record)
+	}
+}
+
+func TestTable_UpgradeRows_RewritesOutdatedRowsInBatches(t *testing.T) {
+	table := newTypedTable(t, typedSchema())
+	for id := uint64(1); id <= 5; id++ {
+		table.Insert(userRecord(id, "user"))
+	}
+
+	migrateTable(t, table, DropColumn("email"))
+	table.Insert(userRecord(6, "new"))
+	table.changeEvents = nil
+
+	next, rewritten, err := table.upgradeRows(nil, 2)
+	if err != nil || rewritten != 2 || next == nil {
+		t.Fatalf("expected first batch of 2 rows, got %d, %x, %v", rewritten, next, err)
+	}
+
+	total := rewritten
+	for next != nil {
+		next, rewritten, err = table.upgradeRows(next, 2)
+		if err != nil {
+			t.Fatalf("upgradeRows: %v", err)
+		}
+		total += rewritten
+	}
+
+	if total != 5 {
+		t.Errorf("expected 5 rewritten rows, got %d", total)
+	}
+	if len(table.changeEvents) != 5 {
+		t.Errorf("expected 5 update events, got %d", len(table.changeEvents))
+	}
+
+	cursor := table.kv.Scan(&kv.ScanRequest{})
+	for key, value := cursor.Current(); value != nil; key, value = cursor.Next() {
+		if version, err := table.payloadVersion(value); table.matchPrimaryIndex(key) && (err != nil || version != 1) {
+			t.Errorf("expected row %x to be rewritten with version 1", key)
+		}
+	}
+}
+
+func TestTable_UpgradeRows_MalformedRow_ReturnsError(t *testing.T) {
+	cases := map[string][]byte{
+		"malformed version":         codec.EncodeValue(primitive.NewUint32(1)),
+		"malformed outdated fields": codec.EncodeValue(primitive.NewString("name")),
+	}
+
+	for name, payload := range cases {
+		table := newTypedTable(t, typedSchema())
+		table.Insert(userRecord(1, "user"))
+		migrateTable(t, table, DropColumn("email"))
+		table.kv.Set(&kv.SetRequest{Key: table.getPrimaryIndex(userRecord(2, "")), Value: payload})
+
+		if _, _, err := table.upgradeRows(nil, 10); err == nil {
+			t.Errorf("%s: expected decode error", name)
+		}
+	}
+}
+
+func TestTableManager_ApplyChangeEvents_ReplaysMigration(t *testing.T) {
+	testPager := newTestPager()
+	manager := newTableManager(TableManagerState{}, func() TableID { return 1 }, testPager)
+	table, _ := manager.CreateTable(typedSchema())
+
+	migrated, _ := table.schema.migrate([]MigrationOperation{RenameColumn("email", "contact")})
+	oldSchema, _ := json.Marshal(table.schema)
+	newSchema, _ := json.Marshal(migrated)
+	migration := []TableEvent{events.NewUpdateTable(uint64(table.id), oldSchema, newSchema)}
+
+	if _, err := manager.ApplyChangeEvents(migration); err != nil {
+		t.Fatalf("ApplyChangeEvents: %v", err)
+	}
+
+	replica := newTableManager(TableManagerState{Root: manager.catalog.Root()}, func() TableID { return 2 }, testPager)
+	replayed, _ := replica.Table("accounts")
+	if replayed.schema.Version != 1 || !replayed.hasColumn("contact") {
+		t.Errorf("expected replayed schema version 1 with column 'contact', got %+v", replayed.schema)
+	}
+
+	if _, err := replica.ApplyChangeEvents(migration); !IsConflict(err) {
+		t.Errorf("expected conflict for migration of outdated schema, got %v", err)
+	}
+}
+
+func TestDatabase_MigrateTable_ConcurrentMigrationsConflict(t *testing.T) {
+	db, err := NewDatabase(newTestDatabaseConfig(t))
+	if err != nil {
+		t.Fatalf("NewDatabase failed: %v", err)
+	}
+	db.StartTransaction(func(tx *Transaction) { tx.CreateTable(typedSchema()) })
+
+	first, _ := db.Begin(context.Background(), TransactionOptions{})
+	second, _ := db.Begin(context.Background(), TransactionOptions{})
+	if _, err := first.MigrateTable("accounts", DropColumn("email")); err != nil {
+		t.Fatalf("MigrateTable failed: %v", err)
+	}
+	second.MigrateTable("accounts", RenameColumn("email", "contact"))
+
+	if err := first.Commit(); err != nil {
+		t.Fatalf("first Commit failed: %v", err)
+	}
+	if err := second.Commit(); !IsConflict(err) {
+		t.Errorf("expected conflict for concurrent migration, got %v", err)
+	}
+}
+
+func TestDatabase_MigrateRows_RewritesAllRows(t *testing.T) {
+	db, err := NewDatabase(newTestDatabaseConfig(t))
+	if err != nil {
+		t.Fatalf("NewDatabase failed: %v", err)
+	}
+	db.StartTransaction(func(tx *Transaction) {
+		table, _ := tx.CreateTable(typedSchema())
+		for id := uint64(1); id <= 7; id++ {
+			table.Insert(userRecord(id, "user"))
+		}
+	})
+	db.StartTransaction(func(tx *Transaction) {
+		tx.MigrateTable("accounts", AddColumn(Column{Name: "age", Type: primitive.TYPE_UINT32}.WithDefault(primitive.NewUint32(30))))
+	})
+
+	rewritten, err := db.MigrateRows(context.Background(), "accounts", 3)
+	if err != nil {
+		t.Fatalf("MigrateRows failed: %v", err)
+	}
+	if rewritten != 7 {
+		t.Errorf("expected 7 rewritten rows, got %d", rewritten)
+	}
+
+	db.StartTransaction(func(tx *Transaction) {
+		table, _ := tx.Table("accounts")
+		records, _ := table.GetAll()
+		for _, record := range records {
+			if record.GetUint32("age") != 30 {
+				t.Errorf("expected age 30 in %s", record)
+			}
+		}
+	})
+
+	if rewritten, _ := db.MigrateRows(context.Background(), "accounts", 3); rewritten != 0 {
+		t.Errorf("expected no rows left to rewrite, got %d", rewritten)
+	}
+}
+
+func TestTable_Insert_RejectsReservedVersionField(t *testing.T) {
+	table, _ := newTable(TableID(1), pager.NULL_PAGE, newTestPager(), basicSchema())
+
+	if _, err := table.Insert(userRecord(1, "Alice").Set(ROW_VERSION_FIELD, primitive.NewUint32(1))); err == nil {
+		t.Error("expected reserved field to be rejected")
+	}
+}
@@ -0,0 +1,547 @@
+package db
+
+import (
+	"bytes"
+	"distributed-storage/internal/codec"
+	"distributed-storage/internal/kv"
+	"distributed-storage/internal/primitive"
+	"encoding/binary"
+	"fmt"
+	"slices"
+)
+
+const MAX_INDEX_RANGES = 256 // Max number of ranges IN lists are expanded to, longer lists are filtered instead
+
+// indexRange is a contiguous range of keys of one index. Keys are scanned from start while they have prefix
+// and are below end, keys prefixed by end are included only if endInclusive is set.
+type indexRange struct {
+	indexID      int
+	start        []byte
+	prefix       []byte
+	end          []byte
+	endInclusive bool
+	equalColumns int  // Number of leading columns fixed by the prefix, used for estimates
+	bounded      bool // Whether the column after equal ones is bounded by comparison or prefix match
+}
+
+// queryPlan scans union of ranges and filters scanned records by the whole predicate
+type queryPlan struct {
+	ranges []indexRange
+	filter *Predicate
+}
+
+// FindWhere returns records matching the predicate. Sargable parts of the predicate are answered by the best primary
+// or secondary index, OR and IN are answered by union of index ranges, the rest is filtered.
+func (table *Table) FindWhere(predicate *Predicate) ([]*primitive.Object, error) {
+	page, err := table.Query(predicate, QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Records, nil
+}
+
+func (table *Table) plan(predicate *Predicate) queryPlan {
+	ranges := table.planRanges(predicate, table.statistics())
+	if ranges == nil { // Nothing is sargable, so the whole primary index is scanned
+		prefix := table.indexPrefix(PRIMARY_INDEX_ID)
+		ranges = []indexRange{{indexID: PRIMARY_INDEX_ID, start: prefix, prefix: prefix}}
+	}
+
+	return queryPlan{ranges: ranges, filter: predicate}
+}
+
+// planRanges returns index ranges containing all records matching the predicate, or nil if full scan is required
+// or estimated to be cheaper
+func (table *Table) planRanges(predicate *Predicate, statistics *TableStatistics) []indexRange {
+	switch predicate.Operator {
+	case PREDICATE_OR:
+		var ranges []indexRange
+
+		for _, operand := range predicate.Operands {
+			operandRanges := table.planRanges(operand, statistics)
+			if operandRanges == nil {
+				return nil
+			}
+			ranges = append(ranges, operandRanges...)
+		}
+
+		return ranges
+
+	case PREDICATE_NOT, PREDICATE_NOT_EQUAL, PREDICATE_IS_NULL:
+		return nil
+	}
+
+	conditions := []*Predicate{predicate}
+	if predicate.Operator == PREDICATE_AND {
+		conditions = predicate.conjuncts()
+	}
+
+	if ranges := table.bestIndexRanges(conditions, statistics); ranges != nil {
+		return ranges
+	}
+
+	for _, condition := range conditions { // Records matching AND are subset of records matching any of its operands
+		if condition.Operator == PREDICATE_OR {
+			if ranges := table.planRanges(condition, statistics); ranges != nil {
+				return ranges
+			}
+		}
+	}
+
+	return nil
+}
+
+// bestIndexRanges picks index constraining most leading columns, equality constrains better than range.
+// Primary index wins ties because records found by it don't require additional lookup. Analyzed tables pick
+// the cheapest index instead, nil is returned if full scan is cheaper than all of them.
+func (table *Table) bestIndexRanges(conditions []*Predicate, statistics *TableStatistics) []indexRange {
+	if statistics != nil {
+		return table.cheapestIndexRanges(conditions, statistics)
+	}
+
+	bestRanges, bestScore := table.indexRanges(PRIMARY_INDEX_ID, table.schema.PrimaryIndex, conditions)
+
+	for secondaryIndexNumber, secondaryIndex := range table.schema.SecondaryIndexes {
+		indexID := PRIMARY_INDEX_ID + secondaryIndexNumber + 1
+
+		if ranges, score := table.indexRanges(indexID, secondaryIndex.Columns, conditions); score > bestScore {
+			bestRanges, bestScore = ranges, score
+		}
+	}
+
+	return bestRanges
+}
+
+func (table *Table) cheapestIndexRanges(conditions []*Predicate, statistics *TableStatistics) []indexRange {
+	var bestRanges []indexRange
+	bestCost := float64(statistics.Rows) * COST_ROW_SCAN
+
+	for indexID := range statistics.Indexes {
+		columns := table.schema.PrimaryIndex
+		if indexID != PRIMARY_INDEX_ID {
+			columns = table.schema.SecondaryIndexes[indexID-PRIMARY_INDEX_ID-1].Columns
+		}
+
+		ranges, _ := table.indexRanges(indexID, columns, conditions)
+		if ranges == nil {
+			continue
+		}
+
+		if cost := statistics.rangesCost(ranges); cost < bestCost || (bestRanges == nil && cost == bestCost) {
+			bestRanges, bestCost = ranges, cost
+		}
+	}
+
+	return bestRanges
+}
+
+func (table *Table) indexRanges(indexID int, columns []string, conditions []*Predicate) ([]indexRange, int) {
+	prefixes := [][]byte{table.indexPrefix(indexID)}
+	score := 0
+	equalColumns := 0
+
+	for _, column := range columns {
+		columnType := table.schema.IndexedColumns[column]
+
+		if values := equalValues(conditions, column, columnType); values != nil && len(prefixes)*len(values) <= MAX_INDEX_RANGES {
+			var expanded [][]byte
+
+			for _, prefix := range prefixes {
+				for _, value := range values {
+					expanded = append(expanded, append(slices.Clip(prefix), codec.EncodeValue(value)...))
+				}
+			}
+
+			prefixes = expanded
+			score += 2
+			equalColumns++
+			continue
+		}
+
+		if ranges := boundedRanges(indexID, prefixes, equalColumns, conditions, column, columnType); ranges != nil {
+			return ranges, score + 1
+		}
+
+		break
+	}
+
+	if score == 0 {
+		return nil, 0
+	}
+
+	ranges := make([]indexRange, len(prefixes))
+	for idx, prefix := range prefixes {
+		ranges[idx] = indexRange{indexID: indexID, start: prefix, prefix: prefix, equalColumns: equalColumns}
+	}
+
+	return ranges, score
+}
+
+// equalValues returns values the column must be equal to, equality takes precedence over IN lists
+func equalValues(conditions []*Predicate, column string, columnType primitive.PrimitiveType) []primitive.Primitive {
+	for _, condition := range conditions {
+		if condition.Column == column && condition.Operator == PREDICATE_EQUAL && condition.Values[0].Type() == columnType {
+			return condition.Values
+		}
+	}
+
+	for _, condition := range conditions {
+		if condition.Column != column || condition.Operator != PREDICATE_IN || len(condition.Values) == 0 {
+			continue
+		}
+		if slices.ContainsFunc(condition.Values, func(value primitive.Primitive) bool { return value.Type() != columnType }) {
+			continue
+		}
+
+		return condition.Values
+	}
+
+	return nil
+}
+
+// boundedRanges narrows ranges by comparisons and prefix match on the column, nil is returned if column isn't constrained
+func boundedRanges(indexID int, prefixes [][]byte, equalColumns int, conditions []*Predicate, column string, columnType primitive.PrimitiveType) []indexRange {
+	var lower, upper, match *Predicate
+
+	for _, condition := range conditions {
+		if condition.Column != column || len(condition.Values) == 0 || condition.Values[0].Type() != columnType {
+			continue
+		}
+
+		switch condition.Operator {
+		case PREDICATE_GREATER, PREDICATE_GREATER_OR_EQUAL:
+			lower = condition
+		case PREDICATE_LESS, PREDICATE_LESS_OR_EQUAL:
+			upper = condition
+		case PREDICATE_PREFIX:
+			match = condition
+		}
+	}
+
+	if lower == nil && upper == nil && match == nil {
+		return nil
+	}
+
+	ranges := make([]indexRange, len(prefixes))
+
+	for idx, prefix := range prefixes {
+		indexRange := indexRange{indexID: indexID, start: prefix, prefix: prefix, equalColumns: equalColumns, bounded: true}
+
+		if match != nil { // String encoding without terminator is a prefix of encodings of all strings starting with it
+			encoded := codec.EncodeValue(match.Values[0])
+			indexRange.prefix = append(slices.Clip(prefix), encoded[:len(encoded)-1]...)
+			indexRange.start = indexRange.prefix
+		} else {
+			if lower != nil {
+				indexRange.start = append(slices.Clip(prefix), codec.EncodeValue(lower.Values[0])...)
+			}
+			if upper != nil {
+				indexRange.end = append(slices.Clip(prefix), codec.EncodeValue(upper.Values[0])...)
+				indexRange.endInclusive = upper.Operator == PREDICATE_LESS_OR_EQUAL
+			}
+		}
+
+		ranges[idx] = indexRange
+	}
+
+	return ranges
+}
+
+// scanRange visits records within the range in index order, or in reverse order if descending is set. Scan resumes
+// after index key if it is set, only fields holding the columns are decoded if they are set. Visit returns false to
+// stop the scan.
+func (table *Table) scanRange(indexRange indexRange, descending bool, after []byte, columns []string, visit func(index []byte, record *primitive.Object) bool) error {
+	var cursor kv.ScanResponse
+
+	switch {
+	case descending && after != nil:
+		cursor = table.kv.Scan(&kv.ScanRequest{Key: after, Reverse: true})
+	case descending:
+		cursor = table.kv.Scan(&kv.ScanRequest{Key: indexRange.upperBound(), Reverse: true})
+	case after != nil:
+		cursor = table.kv.Scan(&kv.ScanRequest{Key: after})
+	default:
+		cursor = table.kv.Scan(&kv.ScanRequest{Key: indexRange.start})
+	}
+
+	move := cursor.Next
+	if descending {
+		move = cursor.Prev
+	}
+
+	index, value := cursor.Current()
+	if !descending && after != nil && bytes.Equal(index, after) {
+		index, value = cursor.Next()
+	}
+
+	// Scanned range is recorded up to the last visited entry if visit stops the scan
+	low, high := indexRange.start, indexRange.upperBound()
+	switch {
+	case descending && after != nil:
+		high = after
+	case after != nil:
+		low = append(bytes.Clone(after), 0)
+	}
+
+	var read rangeRead
+	defer func() { table.recordRange(low, high, read) }()
+
+	stopAt := func(index []byte) {
+		if descending {
+			low = index
+		} else {
+			high = append(bytes.Clone(index), 0)
+		}
+	}
+
+	if indexRange.indexID != PRIMARY_INDEX_ID {
+		return table.scanSecondaryRange(indexRange, index, value, move, columns, &read, func(index []byte, record *primitive.Object) bool {
+			if visit(index, record) {
+				return true
+			}
+
+			stopAt(index)
+			return false
+		})
+	}
+
+	for ; indexRange.contains(index); index, value = move() {
+		read.visit(index, value)
+
+		if table.expired(value) {
+			continue
+		}
+
+		record, err := table.decodeColumns(value, columns)
+		if err != nil {
+			return err
+		}
+
+		if !visit(index, record) {
+			stopAt(index)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// scanSecondaryRange visits records of secondary index entries of the range starting from the current entry of the
+// cursor. Records are looked up in batches, which grow up to PRIMARY_LOOKUP_BATCH_SIZE entries, so scans stopped
+// early don't fetch many records they don't visit. Only entries up to the one visit stops at are added to read.
+func (table *Table) scanSecondaryRange(indexRange indexRange, index []byte, value []byte, move func() ([]byte, []byte), columns []string, read *rangeRead, visit func(index []byte, record *primitive.Object) bool) error {
+	batchSize := 1
+
+	for indexRange.contains(index) {
+		var indexes, values [][]byte
+
+		for ; indexRange.contains(index) && len(indexes) < batchSize; index, value = move() {
+			indexes = append(indexes, bytes.Clone(index))
+			values = append(values, bytes.Clone(value))
+		}
+		batchSize = min(batchSize*2, PRIMARY_LOOKUP_BATCH_SIZE)
+
+		visited := 0
+		stopped := false
+
+		err := table.lookupRecords(indexes, columns, func(idx int, record *primitive.Object) bool {
+			for ; visited <= idx; visited++ { // Entries of expired records aren't visited, but were read
+				read.visit(indexes[visited], values[visited])
+			}
+
+			stopped = !visit(indexes[idx], record)
+			return !stopped
+		})
+		if err != nil || stopped {
+			return err
+		}
+
+		for ; visited < len(indexes); visited++ {
+			read.visit(indexes[visited], values[visited])
+		}
+	}
+
+	return nil
+}
+
+// scanIndexEntries visits records made of indexed and included columns decoded from entries of the range,
+// records aren't fetched
+func (table *Table) scanIndexEntries(indexRange indexRange, descending bool, visit func(record *primitive.Object) bool) error {
+	cursor := table.kv.Scan(&kv.ScanRequest{Key: indexRange.start})
+	move := cursor.Next
+
+	if descending {
+		cursor = table.kv.Scan(&kv.ScanRequest{Key: indexRange.upperBound(), Reverse: true})
+		move = cursor.Prev
+	}
+
+	var read rangeRead
+
+	for index, value := cursor.Current(); indexRange.contains(index); index, value = move() {
+		read.visit(index, value)
+
+		record, err := table.decodeIndexEntry(index, value)
+		if err != nil {
+			return err
+		}
+
+		if visit(record) {
+			continue
+		}
+
+		if descending {
+			table.recordRange(index, indexRange.upperBound(), read)
+		} else {
+			table.recordRange(indexRange.start, append(bytes.Clone(index), 0), read)
+		}
+
+		return nil
+	}
+
+	table.recordRange(indexRange.start, indexRange.upperBound(), read)
+
+	return nil
+}
+
+// decodeIndexEntry decodes indexed columns from the key and included columns from the value of secondary index entry.
+// Values of primary index are records, so only the key is decoded.
+func (table *Table) decodeIndexEntry(index []byte, value []byte) (*primitive.Object, error) {
+	record := primitive.NewObject()
+	indexID := table.indexIDOf(index)
+
+	columns := table.indexColumns(indexID)
+	encoded := index[INDEX_ID_SIZE:]
+
+	if indexID != PRIMARY_INDEX_ID {
+		columns = slices.Concat(columns, table.schema.SecondaryIndexes[indexID-PRIMARY_INDEX_ID-1].Include)
+	}
+
+	for _, column := range columns {
+		if len(encoded) == 0 && len(value) > 0 { // Included columns follow indexed ones
+			encoded, value = value, nil
+		}
+		if len(encoded) == 0 {
+			return nil, fmt.Errorf("Table %q: index entry %x is missing column %q", table.schema.Name, index, column)
+		}
+
+		var columnValue primitive.Primitive
+		var size int
+		var err error
+
+		if columnValue, size, err = codec.DecodeValue(encoded); err != nil {
+			return nil, fmt.Errorf("Table %q: couldn't decode column %q of index entry %x: %w", table.schema.Name, column, index, err)
+		}
+		encoded = encoded[size:]
+
+		if !columnValue.Empty() {
+			record.Set(column, columnValue)
+		}
+	}
+
+	return record, nil
+}
+
+func (indexRange indexRange) contains(index []byte) bool {
+	if len(index) == 0 || !bytes.HasPrefix(index, indexRange.prefix) || bytes.Compare(index, indexRange.start) < 0 {
+		return false
+	}
+
+	if indexRange.end == nil {
+		return true
+	}
+
+	if indexRange.endInclusive && bytes.HasPrefix(index, indexRange.end) {
+		return true
+	}
+
+	return bytes.Compare(index, indexRange.end) < 0
+}
+
+// upperBound returns key all keys of the range are less than, nil means that range isn't bounded
+func (indexRange indexRange) upperBound() []byte {
+	if indexRange.end != nil && !indexRange.endInclusive {
+		return indexRange.end
+	}
+
+	if indexRange.end != nil {
+		return prefixSuccessor(indexRange.end)
+	}
+
+	return prefixSuccessor(indexRange.prefix)
+}
+
+// prefixSuccessor returns the least key greater than all keys with the prefix, which is the prefix with the last
+// incrementable byte incremented. Nil is returned if there is no such key.
+func prefixSuccessor(prefix []byte) []byte {
+	for idx := len(prefix) - 1; idx >= 0; idx-- {
+		if prefix[idx] < 0xFF {
+			successor := slices.Clone(prefix[:idx+1])
+			successor[idx]++
+			return successor
+		}
+	}
+
+	return nil
+}
+
+// indexKey returns key of the record in the index, nil if the record isn't indexed by it
+func (table *Table) indexKey(record *primitive.Object, indexID int) []byte {
+	if indexID == PRIMARY_INDEX_ID {
+		return table.getPrimaryIndex(record)
+	}
+
+	return table.getSecondaryIndex(record, indexID-PRIMARY_INDEX_ID-1)
+}
+
+// indexColumns returns columns in order they are encoded in keys of the index
+func (table *Table) indexColumns(indexID int) []string {
+	if indexID == PRIMARY_INDEX_ID {
+		return table.schema.PrimaryIndex
+	}
+
+	return slices.Concat(table.schema.SecondaryIndexes[indexID-PRIMARY_INDEX_ID-1].Columns, table.schema.PrimaryIndex)
+}
+
+// coversColumns checks that entries of the index contain all the columns
+func (table *Table) coversColumns(indexID int, columns []string) bool {
+	if table.schema.TTL != nil { // Expiry time is stored in records, so index entries can't tell if rows have expired
+		return false
+	}
+
+	covered := table.indexColumns(indexID)
+	if indexID != PRIMARY_INDEX_ID {
+		covered = slices.Concat(covered, table.schema.SecondaryIndexes[indexID-PRIMARY_INDEX_ID-1].Include)
+	}
+
+	for _, column := range columns {
+		if !slices.Contains(covered, column) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (table *Table) indexIDOf(index []byte) int {
+	return int(binary.LittleEndian.Uint32(index[:INDEX_ID_SIZE]))
+}
+
+func (table *Table) indexPrefix(indexID int) []byte {
+	return binary.LittleEndian.AppendUint32(nil, uint32(indexID))
+}
+
+// conjuncts flattens nested ANDs into a list of conditions
+func (predicate *Predicate) conjuncts() []*Predicate {
+	var conditions []*Predicate
+
+	for _, operand := range predicate.Operands {
+		if operand.Operator == PREDICATE_AND {
+			conditions = append(conditions, operand.conjuncts()...)
+		} else {
+			conditions = append(conditions, operand)
+		}
+	}
+
+	return conditions
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"distributed-storage/internal/pager"
+	"distributed-storage/internal/primitive"
+	"slices"
+	"testing"
+)
+
+func ordersSchema() *TableSchema {
+	return &TableSchema{
+		Name:         "orders",
+		PrimaryIndex: []string{"id"},
+		SecondaryIndexes: []SecondaryIndex{
+			{Columns: []string{"status", "amount"}},
+			{Columns: []string{"customer"}},
+		},
+		IndexedColumns: map[string]primitive.PrimitiveType{
+			"id":       primitive.TYPE_UINT64,
+			"status":   primitive.TYPE_STRING,
+			"amount":   primitive.TYPE_UINT32,
+			"customer": primitive.TYPE_STRING,
+		},
+	}
+}
+
+func orderRecord(id uint64, status string, amount uint32, customer string) *primitive.Object {
+	return primitive.NewObject().
+		Set("id", primitive.NewUint64(id)).
+		Set("status", primitive.NewString(status)).
+		Set("amount", primitive.NewUint32(amount)).
+		Set("customer", primitive.NewString(customer))
+}
+
+func newOrdersTable(t *testing.T) *Table {
+	t.Helper()
+	table, err := newTable(TableID(3), pager.NULL_PAGE, newTestPager(), ordersSchema())
+	if err != nil {
+		t.Fatalf("newTable: %v", err)
+	}
+
+	orders := []*primitive.Object{
+		orderRecord(1, "new", 100, "alice"),
+		orderRecord(2, "new", 250, "bob"),
+		orderRecord(3, "paid", 50, "alice"),
+		orderRecord(4, "paid", 300, "carol"),
+		orderRecord(5, "shipped", 120, "bob"),
+		orderRecord(6, "new", 250, "alfred"),
+		primitive.NewObject().Set("id", primitive.NewUint64(7)).Set("amount", primitive.NewUint32(10)),
+	}
+	for _, order := range orders {
+		if _, err := table.Insert(order); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	return table
+}
+
+func foundIDs(t *testing.T, table *Table, predicate *Predicate) []uint64 {
+	t.Helper()
+	records, err := table.FindWhere(predicate)
+	if err != nil {
+		t.Fatalf("FindWhere: %v", err)
+	}
+
+	var ids []uint64
+	for _, record := range records {
+		ids = append(ids, record.GetUint64("id"))
+	}
+	slices.Sort(ids)
+
+	return ids
+}
+
+func TestTable_FindWhere(t *testing.T) {
+	table := newOrdersTable(t)
+
+	cases := map[string]struct {
+		predicate *Predicate
+		expected  []uint64
+	}{
+		"primary range":        {And(Ge("id", primitive.NewUint64(2)), Lt("id", primitive.NewUint64(4))), []uint64{2, 3}},
+		"primary in":           {In("id", primitive.NewUint64(6), primitive.NewUint64(1), primitive.NewUint64(42)), []uint64{1, 6}},
+		"composite equality":   {And(Eq("status", primitive.NewString("new")), Eq("amount", primitive.NewUint32(250))), []uint64{2, 6}},
+		"composite range":      {And(Eq("status", primitive.NewString("new")), Le("amount", primitive.NewUint32(250)), Gt("amount", primitive.NewUint32(100))), []uint64{2, 6}},
+		"in with range":        {And(In("status", primitive.NewString("new"), primitive.NewString("paid")), Gt("amount", primitive.NewUint32(200))), []uint64{2, 4, 6}},
+		"prefix":               {HasPrefix("customer", "al"), []uint64{1, 3, 6}},
+		"index union":          {Or(Eq("customer", primitive.NewString("carol")), Eq("status", primitive.NewString("shipped")), Eq("id", primitive.NewUint64(4))), []uint64{4, 5}},
+		"union with full scan": {Or(Eq("customer", primitive.NewString("carol")), IsNull("status")), []uint64{4, 7}},
+		"not":                  {Not(Eq("status", primitive.NewString("new"))), []uint64{3, 4, 5, 7}},
+		"not equal":            {Ne("customer", primitive.NewString("alice")), []uint64{2, 4, 5, 6}},
+		"filtered residual":    {And(Eq("customer", primitive.NewString("bob")), Lt("amount", primitive.NewUint32(200))), []uint64{5}},
+		"mismatched type":      {Eq("amount", primitive.NewUint64(100)), nil},
+		"nested or in and":     {And(Or(Eq("id", primitive.NewUint64(1)), Eq("id", primitive.NewUint64(3))), Eq("amount", primitive.NewUint32(50))), []uint64{3}},
+	}
+
+	for name, testCase := range cases {
+		if ids := foundIDs(t, table, testCase.predicate); !slices.Equal(ids, testCase.expected) {
+			t.Errorf("%s: expected %v, got %v", name, testCase.expected, ids)
+		}
+	}
+}
+
+func TestTable_Plan_ChoosesIndexes(t *testing.T) {
+	table := newOrdersTable(t)
+
+	cases := map[string]struct {
+		predicate *Predicate
+		indexIDs  []int
+	}{
+		"primary equality wins ties":    {And(Eq("id", primitive.NewUint64(1)), Eq("customer", primitive.NewString("alice"))), []int{0}},
+		"composite index":               {And(Eq("status", primitive.NewString("new")), Gt("amount", primitive.NewUint32(1))), []int{1}},
+		"leading column range":          {Lt("customer", primitive.NewString("b")), []int{2}},
+		"in expands to ranges":          {In("customer", primitive.NewString("alice"), primitive.NewString("bob")), []int{2, 2}},
+		"union of different indexes":    {Or(Eq("customer", primitive.NewString("bob")), Eq("id", primitive.NewUint64(3))), []int{2, 0}},
+		"non sargable is full scan":     {Not(Eq("id", primitive.NewUint64(1))), []int{0}},
+		"non leading column is ignored": {Eq("amount", primitive.NewUint32(50)), []int{0}},
+	}
+
+	for name, testCase := range cases {
+		plan := table.plan(testCase.predicate)
+
+		var indexIDs []int
+		for _, indexRange := range plan.ranges {
+			indexIDs = append(indexIDs, indexRange.indexID)
+		}
+
+		if !slices.Equal(indexIDs, testCase.indexIDs) {
+			t.Errorf("%s: expected indexes %v, got %v", name, testCase.indexIDs, indexIDs)
+		}
+	}
+}
+
+func TestTable_FindWhere_InvalidPredicate_ReturnsError(t *testing.T) {
+	table := newOrdersTable(t)
+
+	if _, err := table.FindWhere(Lt("amount", primitive.NewNull())); err == nil {
+		t.Error("expected error for comparison with null")
+	}
+}
@@ -0,0 +1,251 @@
+package db
+
+import (
+	"bytes"
+	"distributed-storage/internal/codec"
+	"distributed-storage/internal/primitive"
+	"fmt"
+	"strings"
+)
+
+const (
+	PREDICATE_EQUAL PredicateOperator = iota + 1
+	PREDICATE_NOT_EQUAL
+	PREDICATE_LESS
+	PREDICATE_LESS_OR_EQUAL
+	PREDICATE_GREATER
+	PREDICATE_GREATER_OR_EQUAL
+	PREDICATE_IN
+	PREDICATE_IS_NULL
+	PREDICATE_PREFIX
+	PREDICATE_AND
+	PREDICATE_OR
+	PREDICATE_NOT
+)
+
+type PredicateOperator uint8
+
+// Predicate is a node of query condition tree. Leaf nodes compare Column with Values, AND, OR and NOT nodes combine
+// Operands. Missing fields are null, comparisons with null are false, so NOT of such comparison is true.
+type Predicate struct {
+	Operator PredicateOperator
+	Column   string
+	Values   []primitive.Primitive
+	Operands []*Predicate
+}
+
+func Eq(column string, value primitive.Primitive) *Predicate {
+	return &Predicate{Operator: PREDICATE_EQUAL, Column: column, Values: []primitive.Primitive{value}}
+}
+
+func Ne(column string, value primitive.Primitive) *Predicate {
+	return &Predicate{Operator: PREDICATE_NOT_EQUAL, Column: column, Values: []primitive.Primitive{value}}
+}
+
+func Lt(column string, value primitive.Primitive) *Predicate {
+	return &Predicate{Operator: PREDICATE_LESS, Column: column, Values: []primitive.Primitive{value}}
+}
+
+func Le(column string, value primitive.Primitive) *Predicate {
+	return &Predicate{Operator: PREDICATE_LESS_OR_EQUAL, Column: column, Values: []primitive.Primitive{value}}
+}
+
+func Gt(column string, value primitive.Primitive) *Predicate {
+	return &Predicate{Operator: PREDICATE_GREATER, Column: column, Values: []primitive.Primitive{value}}
+}
+
+func Ge(column string, value primitive.Primitive) *Predicate {
+	return &Predicate{Operator: PREDICATE_GREATER_OR_EQUAL, Column: column, Values: []primitive.Primitive{value}}
+}
+
+func In(column string, values ...primitive.Primitive) *Predicate {
+	return &Predicate{Operator: PREDICATE_IN, Column: column, Values: values}
+}
+
+func IsNull(column string) *Predicate {
+	return &Predicate{Operator: PREDICATE_IS_NULL, Column: column}
+}
+
+func HasPrefix(column string, prefix string) *Predicate {
+	return &Predicate{Operator: PREDICATE_PREFIX, Column: column, Values: []primitive.Primitive{primitive.NewString(prefix)}}
+}
+
+func And(operands ...*Predicate) *Predicate {
+	return &Predicate{Operator: PREDICATE_AND, Operands: operands}
+}
+
+func Or(operands ...*Predicate) *Predicate {
+	return &Predicate{Operator: PREDICATE_OR, Operands: operands}
+}
+
+func Not(operand *Predicate) *Predicate {
+	return &Predicate{Operator: PREDICATE_NOT, Operands: []*Predicate{operand}}
+}
+
+// Where converts equality query used by Table.Find into AND of equalities
+func Where(query *primitive.Object) *Predicate {
+	var operands []*Predicate
+
+	for column, value := range query.Values() {
+		operands = append(operands, Eq(column, value))
+	}
+
+	return And(operands...)
+}
+
+func (predicate *Predicate) Matches(record *primitive.Object) bool {
+	switch predicate.Operator {
+	case PREDICATE_AND:
+		for _, operand := range predicate.Operands {
+			if !operand.Matches(record) {
+				return false
+			}
+		}
+		return true
+
+	case PREDICATE_OR:
+		for _, operand := range predicate.Operands {
+			if operand.Matches(record) {
+				return true
+			}
+		}
+		return false
+
+	case PREDICATE_NOT:
+		return !predicate.Operands[0].Matches(record)
+
+	case PREDICATE_IS_NULL:
+		return record.Get(predicate.Column).Empty()
+	}
+
+	value := record.Get(predicate.Column)
+	if value.Empty() {
+		return false
+	}
+
+	switch predicate.Operator {
+	case PREDICATE_IN:
+		for _, expected := range predicate.Values {
+			if cmp, comparable := compareValues(value, expected); comparable && cmp == 0 {
+				return true
+			}
+		}
+		return false
+
+	case PREDICATE_PREFIX:
+		str, ok := value.(*primitive.String)
+		return ok && strings.HasPrefix(str.Value(), predicate.Values[0].(*primitive.String).Value())
+	}
+
+	cmp, comparable := compareValues(value, predicate.Values[0])
+	if !comparable {
+		return false
+	}
+
+	switch predicate.Operator {
+	case PREDICATE_EQUAL:
+		return cmp == 0
+	case PREDICATE_NOT_EQUAL:
+		return cmp != 0
+	case PREDICATE_LESS:
+		return cmp < 0
+	case PREDICATE_LESS_OR_EQUAL:
+		return cmp <= 0
+	case PREDICATE_GREATER:
+		return cmp > 0
+	case PREDICATE_GREATER_OR_EQUAL:
+		return cmp >= 0
+	}
+
+	return false
+}
+
+func (predicate *Predicate) validate() error {
+	switch predicate.Operator {
+	case PREDICATE_AND, PREDICATE_OR:
+		if len(predicate.Operands) == 0 && predicate.Operator == PREDICATE_OR {
+			return fmt.Errorf("Predicate: OR must have at least one operand")
+		}
+		for _, operand := range predicate.Operands {
+			if err := operand.validate(); err != nil {
+				return err
+			}
+		}
+
+	case PREDICATE_NOT:
+		if len(predicate.Operands) != 1 {
+			return fmt.Errorf("Predicate: NOT must have exactly one operand")
+		}
+		return predicate.Operands[0].validate()
+
+	case PREDICATE_IS_NULL:
+		if predicate.Column == "" {
+			return fmt.Errorf("Predicate: IS NULL must have a column")
+		}
+
+	case PREDICATE_IN:
+		if predicate.Column == "" {
+			return fmt.Errorf("Predicate: IN must have a column")
+		}
+
+	case PREDICATE_PREFIX:
+		if _, ok := predicate.singleValue().(*primitive.String); !ok || predicate.Column == "" {
+			return fmt.Errorf("Predicate: prefix match on column %q must have a string value", predicate.Column)
+		}
+
+	case PREDICATE_EQUAL, PREDICATE_NOT_EQUAL, PREDICATE_LESS, PREDICATE_LESS_OR_EQUAL, PREDICATE_GREATER, PREDICATE_GREATER_OR_EQUAL:
+		if value := predicate.singleValue(); value == nil || value.Empty() || predicate.Column == "" {
+			return fmt.Errorf("Predicate: comparison on column %q must have a single non null value", predicate.Column)
+		}
+
+	default:
+		return fmt.Errorf("Predicate: unknown operator %d", predicate.Operator)
+	}
+
+	return nil
+}
+
+// columns returns columns compared by the predicate and its operands
+func (predicate *Predicate) columns() []string {
+	if predicate.Column != "" {
+		return []string{predicate.Column}
+	}
+
+	var columns []string
+	for _, operand := range predicate.Operands {
+		columns = append(columns, operand.columns()...)
+	}
+
+	return columns
+}
+
+// rejectsNull checks that the predicate is a comparison of the column, which is false for null
+func (predicate *Predicate) rejectsNull(column string) bool {
+	switch predicate.Operator {
+	case PREDICATE_AND, PREDICATE_OR, PREDICATE_NOT, PREDICATE_IS_NULL:
+		return false
+	}
+
+	return predicate.Column == column
+}
+
+func (predicate *Predicate) singleValue() primitive.Primitive {
+	if len(predicate.Values) != 1 {
+		return nil
+	}
+
+	return predicate.Values[0]
+}
+
+// compareValues orders values of the same type, values of different types aren't comparable
+func compareValues(value primitive.Primitive, other primitive.Primitive) (int, bool) {
+	if value.Type() != other.Type() {
+		return 0, false
+	}
+
+	if decimal, ok := value.(*primitive.Decimal); ok { // Numerically equal decimals of different scales have different encodings
+		return decimal.Cmp(other.(*primitive.Decimal)), true
+	}
+
+	return bytes.Compare(codec.EncodeValue(value), codec.EncodeValue(other)), true
+}
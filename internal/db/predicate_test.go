@@ -0,0 +1,102 @@
+package db
+
+import (
+	"distributed-storage/internal/pager"
+	"distributed-storage/internal/primitive"
+	"math/big"
+	"slices"
+	"testing"
+)
+
+func TestPredicate_Matches(t *testing.T) {
+	record := primitive.NewObject().
+		Set("name", primitive.NewString("Alice")).
+		Set("age", primitive.NewUint32(30)).
+		Set("balance", primitive.NewDecimal(big.NewInt(1050), 2))
+
+	cases := []struct {
+		predicate *Predicate
+		expected  bool
+	}{
+		{Eq("name", primitive.NewString("Alice")), true},
+		{Eq("age", primitive.NewUint64(30)), false},
+		{Ne("name", primitive.NewString("Bob")), true},
+		{Lt("age", primitive.NewUint32(31)), true},
+		{Le("age", primitive.NewUint32(30)), true},
+		{Gt("age", primitive.NewUint32(30)), false},
+		{Ge("age", primitive.NewUint32(30)), true},
+		{Gt("balance", primitive.NewDecimal(big.NewInt(105), 1)), false},
+		{Ge("balance", primitive.NewDecimal(big.NewInt(105), 1)), true},
+		{In("name", primitive.NewString("Bob"), primitive.NewString("Alice")), true},
+		{In("name"), false},
+		{In("balance", primitive.NewDecimal(big.NewInt(105), 1)), true},
+		{IsNull("email"), true},
+		{IsNull("name"), false},
+		{HasPrefix("name", "Al"), true},
+		{HasPrefix("name", "al"), false},
+		{Lt("email", primitive.NewString("z")), false},
+		{Not(Lt("email", primitive.NewString("z"))), true},
+		{And(Eq("name", primitive.NewString("Alice")), Gt("age", primitive.NewUint32(18))), true},
+		{And(Eq("name", primitive.NewString("Alice")), Gt("age", primitive.NewUint32(40))), false},
+		{Or(Eq("name", primitive.NewString("Bob")), Gt("age", primitive.NewUint32(18))), true},
+		{Or(), false},
+		{And(), true},
+	}
+
+	for idx, testCase := range cases {
+		if matched := testCase.predicate.Matches(record); matched != testCase.expected {
+			t.Errorf("case %d: expected %v, got %v", idx, testCase.expected, matched)
+		}
+	}
+}
+
+func TestPredicate_Validate_RejectsMalformedPredicates(t *testing.T) {
+	cases := []*Predicate{
+		Eq("", primitive.NewString("a")),
+		Eq("name", primitive.NewNull()),
+		{Operator: PREDICATE_LESS, Column: "age"},
+		{Operator: PREDICATE_PREFIX, Column: "name", Values: []primitive.Primitive{primitive.NewUint32(1)}},
+		{Operator: PREDICATE_NOT},
+		{Operator: 100},
+		Or(),
+		And(Eq("name", primitive.NewString("a")), IsNull("")),
+	}
+
+	for idx, predicate := range cases {
+		if err := predicate.validate(); err == nil {
+			t.Errorf("case %d: expected validation error", idx)
+		}
+	}
+}
+
+func TestTable_FindWhere_DecimalIndex_IgnoresScale(t *testing.T) {
+	table, err := newTable(TableID(1), pager.NULL_PAGE, newTestPager(), &TableSchema{
+		Name:             "accounts",
+		PrimaryIndex:     []string{"id"},
+		SecondaryIndexes: []SecondaryIndex{{Columns: []string{"balance"}}},
+		IndexedColumns:   map[string]primitive.PrimitiveType{"id": primitive.TYPE_UINT64, "balance": primitive.TYPE_DECIMAL},
+	})
+	if err != nil {
+		t.Fatalf("newTable failed: %v", err)
+	}
+
+	for id, balance := range []*primitive.Decimal{primitive.NewDecimal(big.NewInt(1), 0), primitive.NewDecimal(big.NewInt(100), 2), primitive.NewDecimal(big.NewInt(2), 0)} {
+		table.Insert(primitive.NewObject().Set("id", primitive.NewUint64(uint64(id))).Set("balance", balance))
+	}
+
+	one := primitive.NewDecimal(big.NewInt(10), 1)
+	cases := map[string]*Predicate{
+		"equal":            Eq("balance", one),
+		"in":               In("balance", one),
+		"inclusive ranges": And(Ge("balance", one), Le("balance", one)),
+	}
+
+	for name, predicate := range cases {
+		if ranges := table.plan(predicate).ranges; len(ranges) != 1 || ranges[0].indexID != 1 {
+			t.Errorf("%s: expected a single range of balance index, got %v", name, ranges)
+		}
+		if ids := foundIDs(t, table, predicate); !slices.Equal(ids, []uint64{0, 1}) {
+			t.Errorf("%s: expected rows of numerically equal balances [0 1], got %v", name, ids)
+		}
+	}
+}
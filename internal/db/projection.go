@@ -0,0 +1,70 @@
+package db
+
+import (
+	"distributed-storage/internal/primitive"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// GetColumns returns the columns of record matching the query, fields holding other columns aren't decoded
+func (table *Table) GetColumns(query *primitive.Object, columns []string) (*primitive.Object, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("Table %q: projection must have at least one column", table.schema.Name)
+	}
+
+	record, err := table.get(query, columns)
+	if record == nil || err != nil {
+		return nil, err
+	}
+
+	return projectRecord(record, columns), nil
+}
+
+// FindColumns returns the columns of records matching the query. Query answered by secondary index whose keys and
+// included columns contain both the query and the requested columns doesn't fetch records.
+func (table *Table) FindColumns(query *primitive.Object, columns []string) ([]*primitive.Object, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("Table %q: projection must have at least one column", table.schema.Name)
+	}
+
+	records, err := table.find(query, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx, record := range records {
+		records[idx] = projectRecord(record, columns)
+	}
+
+	return records, nil
+}
+
+// projectRecord returns record with only the columns, columns missing in the record are omitted
+func projectRecord(record *primitive.Object, columns []string) *primitive.Object {
+	projected := primitive.NewObject()
+
+	for _, column := range columns {
+		if record.Has(column) {
+			projected.SetPath(column, record.Get(column))
+		}
+	}
+
+	return projected
+}
+
+// projectedColumns returns columns which must be decoded to return the columns of records matching the query,
+// nil means all columns
+func projectedColumns(query *primitive.Object, columns []string) []string {
+	if columns == nil {
+		return nil
+	}
+
+	return slices.Concat(columns, slices.Collect(maps.Keys(query.Values())))
+}
+
+// holdsColumn checks whether the field is the column or an object containing it
+func holdsColumn(field string, column string) bool {
+	return field == column || strings.HasPrefix(column, field+".")
+}
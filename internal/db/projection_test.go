@@ -0,0 +1,194 @@
+package db
+
+import (
+	"distributed-storage/internal/codec"
+	"distributed-storage/internal/events"
+	"distributed-storage/internal/kv"
+	"distributed-storage/internal/pager"
+	"distributed-storage/internal/primitive"
+	"strings"
+	"testing"
+)
+
+func newCoveringOrdersTable(t *testing.T) *Table {
+	t.Helper()
+	schema := ordersSchema()
+	schema.SecondaryIndexes[1].Include = []string{"amount", "note"}
+
+	table, err := newTable(TableID(4), pager.NULL_PAGE, newTestPager(), schema)
+	if err != nil {
+		t.Fatalf("newTable: %v", err)
+	}
+
+	table.Insert(orderRecord(1, "new", 100, "alice").Set("note", primitive.NewString("gift")))
+	table.Insert(orderRecord(2, "paid", 250, "bob"))
+	table.Insert(orderRecord(3, "new", 50, "alice"))
+
+	return table
+}
+
+func TestTable_FindColumns_AnsweredFromCoveringIndex(t *testing.T) {
+	table := newCoveringOrdersTable(t)
+
+	// Records missing in primary index prove that matches come from index entries alone
+	table.kv.Delete(&kv.DeleteRequest{Key: table.encodePrimaryIndex([]primitive.Primitive{primitive.NewUint64(1)})})
+	table.kv.Delete(&kv.DeleteRequest{Key: table.encodePrimaryIndex([]primitive.Primitive{primitive.NewUint64(3)})})
+
+	records, err := table.FindColumns(primitive.NewObject().Set("customer", primitive.NewString("alice")), []string{"id", "amount", "note"})
+	if err != nil {
+		t.Fatalf("FindColumns: %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].GetUint64("id") != 1 || records[0].GetUint32("amount") != 100 || records[0].GetString("note") != "gift" {
+		t.Errorf("expected record 1 with amount and note, got %s", records[0])
+	}
+	if records[1].GetUint64("id") != 3 || records[1].Has("note") || records[1].Has("customer") {
+		t.Errorf("expected record 3 without note and unrequested customer, got %s", records[1])
+	}
+
+	if records, _ := table.FindColumns(primitive.NewObject().Set("customer", primitive.NewString("alice")), []string{"status"}); len(records) != 0 {
+		t.Errorf("expected uncovered column to fetch deleted records, got %v", records)
+	}
+}
+
+func TestTable_Update_MaintainsIncludedColumns(t *testing.T) {
+	table := newCoveringOrdersTable(t)
+	table.changeEvents = nil
+
+	table.Update(primitive.NewObject().Set("id", primitive.NewUint64(2)).Set("amount", primitive.NewUint32(275)))
+
+	entry := table.getSecondaryIndex(orderRecord(2, "paid", 275, "bob"), 1)
+	response, _ := table.kv.Get(&kv.GetRequest{Key: entry})
+	record, err := table.decodeIndexEntry(entry, response.Value)
+	if err != nil || record.GetUint32("amount") != 275 {
+		t.Errorf("expected included amount 275, got %v, %v", record, err)
+	}
+
+	updated := 0
+	for _, event := range table.changeEvents {
+		if update, ok := event.(*events.UpdateEntry); ok && string(update.Key) == string(entry) {
+			updated++
+		}
+	}
+	if updated != 1 {
+		t.Errorf("expected single update of covering index entry, got %d", updated)
+	}
+
+	table.changeEvents = nil
+	table.Delete(primitive.NewObject().Set("id", primitive.NewUint64(2)))
+
+	for _, event := range table.changeEvents {
+		if deleted, ok := event.(*events.DeleteEntry); ok && string(deleted.Key) == string(entry) && string(deleted.Value) != string(response.Value) {
+			t.Errorf("expected delete event to carry included columns %x, got %x", response.Value, deleted.Value)
+		}
+	}
+}
+
+func TestTable_Aggregate_UsesIncludedColumns(t *testing.T) {
+	table := newCoveringOrdersTable(t)
+	query := AggregateQuery{Where: Eq("customer", primitive.NewString("alice")), Aggregates: []Aggregate{Sum("amount")}}
+
+	if _, indexOnly := table.indexOnlyRanges(query, query.Where); !indexOnly {
+		t.Error("expected sum of included column to be answered from index entries")
+	}
+	if results := aggregate(t, table, query); results[0].GetUint64("sum(amount)") != 150 {
+		t.Errorf("expected sum 150, got %s", results[0])
+	}
+}
+
+func TestTable_IncludedColumns_Validation(t *testing.T) {
+	schema := ordersSchema()
+	schema.SecondaryIndexes[1].Include = []string{"id"}
+
+	if _, err := newTable(TableID(4), pager.NULL_PAGE, newTestPager(), schema); err == nil {
+		t.Error("expected included primary index column to be rejected")
+	}
+
+	table := newCoveringOrdersTable(t)
+	if _, err := table.schema.migrate([]MigrationOperation{DropColumn("note")}); err == nil {
+		t.Error("expected included column to be protected from migrations")
+	}
+}
+
+func TestTable_DecodeColumns_SkipsOtherFields(t *testing.T) {
+	table := newTestTable(t)
+	record := userRecord(1, "Alice").
+		Set("address", primitive.NewObject().Set("city", primitive.NewString("Oslo"))).
+		Set("bio", primitive.NewString("long text"))
+
+	decoded, err := table.decodeColumns(table.encodePayload(record), []string{"name", "address.city"})
+	if err != nil {
+		t.Fatalf("decodeColumns: %v", err)
+	}
+
+	if decoded.GetString("name") != "Alice" || decoded.GetString("address.city") != "Oslo" {
+		t.Errorf("expected name and address to be decoded, got %s", decoded)
+	}
+	if decoded.Has("bio") || decoded.Has("id") {
+		t.Errorf("expected other fields to be skipped, got %s", decoded)
+	}
+
+	if full := table.decodePayload(table.encodePayload(record)); !full.Equal(record) {
+		t.Errorf("expected %s, got %s", record, full)
+	}
+}
+
+func TestTable_DecodeColumns_ReadsFieldListPayload(t *testing.T) {
+	table := newTypedTable(t, typedSchema())
+	migrateTable(t, table, RenameColumn("name", "full_name"))
+
+	var legacy []byte
+	for _, field := range []struct {
+		name  string
+		value primitive.Primitive
+	}{
+		{ROW_VERSION_FIELD, primitive.NewUint32(0)},
+		{"id", primitive.NewUint64(1)},
+		{"name", primitive.NewString("Alice")},
+	} {
+		legacy = append(legacy, codec.EncodeValue(primitive.NewString(field.name))...)
+		legacy = append(legacy, codec.EncodeValue(field.value)...)
+	}
+	table.kv.Set(&kv.SetRequest{Key: table.encodePrimaryIndex([]primitive.Primitive{primitive.NewUint64(1)}), Value: legacy})
+
+	record, err := table.GetColumns(primitive.NewObject().Set("id", primitive.NewUint64(1)), []string{"full_name"})
+	if err != nil {
+		t.Fatalf("GetColumns: %v", err)
+	}
+	if record.GetString("full_name") != "Alice" || record.Has("id") {
+		t.Errorf("expected only upgraded column 'full_name', got %s", record)
+	}
+}
+
+func TestTable_Query_ProjectsColumns(t *testing.T) {
+	table := newOrdersTable(t)
+	options := QueryOptions{OrderBy: "customer", Limit: 2, Columns: []string{"customer"}}
+	predicate := Or(Eq("status", primitive.NewString("new")), Eq("customer", primitive.NewString("carol")))
+
+	var customers []string
+	for {
+		page, err := table.Query(predicate, options)
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+
+		for _, record := range page.Records {
+			if len(record.Values()) != 1 {
+				t.Errorf("expected only customer column, got %s", record)
+			}
+			customers = append(customers, record.GetString("customer"))
+		}
+
+		if page.Continuation == "" {
+			break
+		}
+		options.Continuation = page.Continuation
+	}
+
+	if strings.Join(customers, ",") != "alfred,alice,bob,carol" {
+		t.Errorf("expected ordered customers, got %v", customers)
+	}
+}
@@ -0,0 +1,304 @@
+package db
+
+import (
+	"bytes"
+	"distributed-storage/internal/primitive"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+)
+
+const DELETE_BATCH_SIZE = 256 // Number of records DeleteWhere finds at once
+
+const (
+	ORDER_ASCENDING SortOrder = iota
+	ORDER_DESCENDING
+)
+
+type SortOrder uint8
+
+// QueryOptions of Table.Query. Ordered queries scan an index whose leading column is OrderBy,
+// so records without the column aren't returned by them.
+type QueryOptions struct {
+	OrderBy      string // Leading column of primary or secondary index, records are returned in plan index order if empty
+	Order        SortOrder
+	Limit        int      // Max number of returned records, zero means no limit
+	Continuation string   // Token returned by previous page of the query with the same predicate and options
+	Columns      []string // Columns of returned records, other fields aren't decoded unless the predicate reads them
+}
+
+type QueryPage struct {
+	Records      []*primitive.Object
+	Continuation string // Set if the limit was reached, so the next page may still be empty
+}
+
+// queryPosition is the last returned index key together with number of the range it was scanned from
+type queryPosition struct {
+	rangeNumber int
+	index       []byte
+}
+
+// Query returns a page of records matching the predicate, nil predicate matches all records. Continuation token
+// encodes the last returned index key, so the next page seeks straight to it.
+func (table *Table) Query(predicate *Predicate, options QueryOptions) (*QueryPage, error) {
+	page := &QueryPage{}
+
+	err := table.iterate(predicate, options, func(position queryPosition, record *primitive.Object) bool {
+		page.Records = append(page.Records, record)
+
+		if options.Limit > 0 && len(page.Records) == options.Limit {
+			page.Continuation = encodeContinuation(position)
+			return false
+		}
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// Rows streams records matching the predicate straight from index cursors, so tables larger than memory can be
+// scanned. Iteration stops at the first decode or lookup error, which is yielded with nil record.
+func (table *Table) Rows(predicate *Predicate, options QueryOptions) iter.Seq2[*primitive.Object, error] {
+	return func(yield func(*primitive.Object, error) bool) {
+		returned := 0
+
+		err := table.iterate(predicate, options, func(_ queryPosition, record *primitive.Object) bool {
+			returned++
+
+			return yield(record, nil) && (options.Limit == 0 || returned < options.Limit)
+		})
+		if err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// DeleteWhere deletes records matching the predicate and streams deleted records. Records are found in batches
+// of DELETE_BATCH_SIZE, stopping iteration stops deleting.
+func (table *Table) DeleteWhere(predicate *Predicate) iter.Seq2[*primitive.Object, error] {
+	return func(yield func(*primitive.Object, error) bool) {
+		options := QueryOptions{Limit: DELETE_BATCH_SIZE}
+
+		for {
+			page, err := table.Query(predicate, options)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for _, record := range page.Records {
+				deleted, err := table.Delete(record)
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+
+				if !yield(deleted, nil) {
+					return
+				}
+			}
+
+			if page.Continuation == "" {
+				return
+			}
+			options.Continuation = page.Continuation
+		}
+	}
+}
+
+// iterate visits records matching the predicate in order of the query options together with their positions
+func (table *Table) iterate(predicate *Predicate, options QueryOptions, visit func(position queryPosition, record *primitive.Object) bool) error {
+	plan, err := table.planQuery(predicate, options)
+	if err != nil {
+		return err
+	}
+
+	columns := options.Columns
+	if columns != nil { // Predicate and deduplication of union ranges read their columns from decoded records
+		columns = slices.Concat(columns, plan.filter.columns())
+		for _, indexRange := range plan.ranges {
+			columns = append(columns, table.indexColumns(indexRange.indexID)...)
+		}
+	}
+
+	descending := options.Order == ORDER_DESCENDING
+	ranges := plan.ranges
+	if descending {
+		ranges = slices.Clone(ranges)
+		slices.Reverse(ranges)
+	}
+
+	position, err := decodeContinuation(options.Continuation)
+	if err != nil {
+		return fmt.Errorf("Table %q: %w", table.schema.Name, err)
+	}
+	if position.rangeNumber >= len(ranges) {
+		return fmt.Errorf("Table %q: continuation token doesn't belong to the query", table.schema.Name)
+	}
+
+	stopped := false
+
+	for rangeNumber := position.rangeNumber; rangeNumber < len(ranges) && !stopped; rangeNumber++ {
+		var after []byte
+		if rangeNumber == position.rangeNumber {
+			after = position.index
+		}
+
+		err := table.scanRange(ranges[rangeNumber], descending, after, columns, func(index []byte, record *primitive.Object) bool {
+			if table.coveredByRanges(ranges[:rangeNumber], record) || !plan.filter.Matches(record) {
+				return true // Records of overlapping union ranges are returned by the first range containing them
+			}
+
+			if options.Columns != nil {
+				record = projectRecord(record, options.Columns)
+			}
+
+			stopped = !visit(queryPosition{rangeNumber: rangeNumber, index: index}, record)
+
+			return !stopped
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// planQuery validates the query and plans it, nil predicate matches all records
+func (table *Table) planQuery(predicate *Predicate, options QueryOptions) (queryPlan, error) {
+	if predicate == nil {
+		predicate = And()
+	}
+
+	if err := predicate.validate(); err != nil {
+		return queryPlan{}, fmt.Errorf("Table %q: %w", table.schema.Name, err)
+	}
+
+	if options.Limit < 0 {
+		return queryPlan{}, fmt.Errorf("Table %q: limit must not be negative, got %d", table.schema.Name, options.Limit)
+	}
+
+	plan := table.plan(predicate)
+
+	if options.OrderBy != "" {
+		return table.orderedPlan(plan, options.OrderBy)
+	}
+
+	return plan, nil
+}
+
+// orderedPlan makes plan scan an index ordered by the column. Ranges of that index are sorted and overlapping ones
+// merged, so scanning them one after another returns records in order. Plans of other indexes are replaced by scan of
+// the whole index which is filtered.
+func (table *Table) orderedPlan(plan queryPlan, column string) (queryPlan, error) {
+	indexID := -1
+
+	if table.schema.PrimaryIndex[0] == column {
+		indexID = PRIMARY_INDEX_ID
+	} else {
+		for secondaryIndexNumber, secondaryIndex := range table.schema.SecondaryIndexes {
+			if secondaryIndex.Columns[0] == column {
+				indexID = PRIMARY_INDEX_ID + secondaryIndexNumber + 1
+				break
+			}
+		}
+	}
+
+	if indexID < 0 {
+		return plan, fmt.Errorf("Table %q: can't order by column %q because it doesn't lead any index", table.schema.Name, column)
+	}
+
+	if !slices.ContainsFunc(plan.ranges, func(indexRange indexRange) bool { return indexRange.indexID != indexID }) {
+		return queryPlan{ranges: mergeRanges(plan.ranges), filter: plan.filter}, nil
+	}
+
+	prefix := table.indexPrefix(indexID)
+
+	return queryPlan{ranges: []indexRange{{indexID: indexID, start: prefix, prefix: prefix}}, filter: plan.filter}, nil
+}
+
+// mergeRanges sorts ranges of the same index by their start and merges overlapping ones. Keys of a range are exactly
+// the keys between its start and upper bound, so merged range spans from the first start to the last upper bound.
+func mergeRanges(ranges []indexRange) []indexRange {
+	sorted := slices.Clone(ranges)
+	slices.SortFunc(sorted, func(left indexRange, right indexRange) int { return bytes.Compare(left.start, right.start) })
+
+	merged := []indexRange{sorted[0]}
+
+	for _, next := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		lastBound := last.upperBound()
+
+		if lastBound != nil && bytes.Compare(next.start, lastBound) >= 0 {
+			merged = append(merged, next)
+			continue
+		}
+
+		bound := next.upperBound()
+		if lastBound == nil || (bound != nil && bytes.Compare(bound, lastBound) < 0) {
+			bound = lastBound
+		}
+
+		*last = indexRange{
+			indexID:      last.indexID,
+			start:        last.start,
+			prefix:       commonPrefix(last.prefix, next.prefix),
+			end:          bound,
+			equalColumns: min(last.equalColumns, next.equalColumns),
+			bounded:      true,
+		}
+	}
+
+	return merged
+}
+
+func commonPrefix(left []byte, right []byte) []byte {
+	length := 0
+	for length < len(left) && length < len(right) && left[length] == right[length] {
+		length++
+	}
+
+	return left[:length]
+}
+
+func (table *Table) coveredByRanges(ranges []indexRange, record *primitive.Object) bool {
+	for _, indexRange := range ranges {
+		if index := table.indexKey(record, indexRange.indexID); index != nil && indexRange.contains(index) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func encodeContinuation(position queryPosition) string {
+	encoded := binary.AppendUvarint(nil, uint64(position.rangeNumber))
+
+	return base64.RawURLEncoding.EncodeToString(append(encoded, position.index...))
+}
+
+func decodeContinuation(token string) (queryPosition, error) {
+	if token == "" {
+		return queryPosition{}, nil
+	}
+
+	encoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return queryPosition{}, fmt.Errorf("couldn't decode continuation token: %w", err)
+	}
+
+	rangeNumber, size := binary.Uvarint(encoded)
+	if size <= 0 || len(encoded) == size || rangeNumber > math.MaxInt32 {
+		return queryPosition{}, fmt.Errorf("continuation token is malformed")
+	}
+
+	return queryPosition{rangeNumber: int(rangeNumber), index: encoded[size:]}, nil
+}
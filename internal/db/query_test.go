@@ -0,0 +1,299 @@
+package db
+
+import (
+	"distributed-storage/internal/codec"
+	"distributed-storage/internal/events"
+	"distributed-storage/internal/kv"
+	"distributed-storage/internal/primitive"
+	"slices"
+	"testing"
+)
+
+func queryIDs(t *testing.T, table *Table, predicate *Predicate, options QueryOptions) ([]uint64, string) {
+	t.Helper()
+	page, err := table.Query(predicate, options)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+
+	var ids []uint64
+	for _, record := range page.Records {
+		ids = append(ids, record.GetUint64("id"))
+	}
+
+	return ids, page.Continuation
+}
+
+func TestTable_Query_OrderBy(t *testing.T) {
+	table := newOrdersTable(t)
+
+	cases := map[string]struct {
+		predicate *Predicate
+		options   QueryOptions
+		expected  []uint64
+	}{
+		"primary descending":       {nil, QueryOptions{OrderBy: "id", Order: ORDER_DESCENDING}, []uint64{7, 6, 5, 4, 3, 2, 1}},
+		"secondary ascending":      {nil, QueryOptions{OrderBy: "customer"}, []uint64{6, 1, 3, 2, 5, 4}},
+		"secondary descending":     {nil, QueryOptions{OrderBy: "customer", Order: ORDER_DESCENDING}, []uint64{4, 5, 2, 3, 1, 6}},
+		"range descending":         {Le("customer", primitive.NewString("bob")), QueryOptions{OrderBy: "customer", Order: ORDER_DESCENDING}, []uint64{5, 2, 3, 1, 6}},
+		"exclusive bound":          {And(Gt("id", primitive.NewUint64(2)), Lt("id", primitive.NewUint64(5))), QueryOptions{OrderBy: "id", Order: ORDER_DESCENDING}, []uint64{4, 3}},
+		"filtered by other index":  {Eq("status", primitive.NewString("new")), QueryOptions{OrderBy: "customer"}, []uint64{6, 1, 2}},
+		"limit":                    {nil, QueryOptions{OrderBy: "id", Order: ORDER_DESCENDING, Limit: 2}, []uint64{7, 6}},
+		"prefix descending":        {HasPrefix("customer", "al"), QueryOptions{OrderBy: "customer", Order: ORDER_DESCENDING}, []uint64{3, 1, 6}},
+		"composite index ordering": {In("status", primitive.NewString("paid"), primitive.NewString("new")), QueryOptions{OrderBy: "status"}, []uint64{1, 2, 6, 3, 4}},
+		"overlapping ranges":       {Or(Le("customer", primitive.NewString("bob")), HasPrefix("customer", "al")), QueryOptions{OrderBy: "customer"}, []uint64{6, 1, 3, 2, 5}},
+		"ranges descending":        {In("status", primitive.NewString("shipped"), primitive.NewString("new")), QueryOptions{OrderBy: "status", Order: ORDER_DESCENDING}, []uint64{5, 6, 2, 1}},
+	}
+
+	for name, testCase := range cases {
+		if ids, _ := queryIDs(t, table, testCase.predicate, testCase.options); !slices.Equal(ids, testCase.expected) {
+			t.Errorf("%s: expected %v, got %v", name, testCase.expected, ids)
+		}
+	}
+}
+
+func TestTable_Query_OrderByKeepsRangesOfOrderingIndex(t *testing.T) {
+	table := newOrdersTable(t)
+
+	explanation, err := table.Explain(In("status", primitive.NewString("paid"), primitive.NewString("new")), QueryOptions{OrderBy: "status"})
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	if explanation.FullScan || explanation.Ranges != 2 {
+		t.Errorf("expected 2 ranges of the ordering index, got %s", explanation)
+	}
+
+	explanation, _ = table.Explain(Or(Le("customer", primitive.NewString("bob")), HasPrefix("customer", "al")), QueryOptions{OrderBy: "customer"})
+	if explanation.FullScan || explanation.Ranges != 1 {
+		t.Errorf("expected overlapping ranges to be merged, got %s", explanation)
+	}
+}
+
+func TestTable_Query_ContinuationPagesThroughAllRecords(t *testing.T) {
+	table := newOrdersTable(t)
+
+	cases := map[string]struct {
+		predicate *Predicate
+		options   QueryOptions
+	}{
+		"primary":           {nil, QueryOptions{}},
+		"secondary reverse": {nil, QueryOptions{OrderBy: "customer", Order: ORDER_DESCENDING}},
+		"overlapping union": {Or(Eq("customer", primitive.NewString("alice")), Eq("status", primitive.NewString("new")), Le("id", primitive.NewUint64(2))), QueryOptions{}},
+		"reverse union":     {Or(Eq("customer", primitive.NewString("bob")), In("id", primitive.NewUint64(5), primitive.NewUint64(1))), QueryOptions{Order: ORDER_DESCENDING}},
+	}
+
+	for name, testCase := range cases {
+		expected, _ := queryIDs(t, table, testCase.predicate, testCase.options)
+
+		var paged []uint64
+		options := testCase.options
+		options.Limit = 2
+		for {
+			ids, continuation := queryIDs(t, table, testCase.predicate, options)
+			if len(ids) > options.Limit {
+				t.Fatalf("%s: page exceeds limit: %v", name, ids)
+			}
+			paged = append(paged, ids...)
+			if continuation == "" {
+				break
+			}
+			options.Continuation = continuation
+		}
+
+		if !slices.Equal(paged, expected) {
+			t.Errorf("%s: expected pages to contain %v, got %v", name, expected, paged)
+		}
+	}
+}
+
+func TestTable_Query_ContinuationSurvivesDeletedKey(t *testing.T) {
+	table := newOrdersTable(t)
+
+	_, continuation := queryIDs(t, table, nil, QueryOptions{Limit: 3})
+	table.Delete(primitive.NewObject().Set("id", primitive.NewUint64(3)))
+
+	if ids, _ := queryIDs(t, table, nil, QueryOptions{Limit: 2, Continuation: continuation}); !slices.Equal(ids, []uint64{4, 5}) {
+		t.Errorf("expected next page to start after deleted key, got %v", ids)
+	}
+}
+
+func TestTable_Query_RejectsInvalidOptions(t *testing.T) {
+	table := newOrdersTable(t)
+
+	cases := map[string]QueryOptions{
+		"not indexed column":   {OrderBy: "amount"},
+		"negative limit":       {Limit: -1},
+		"malformed token":      {Continuation: "%%%"},
+		"token of other query": {Continuation: encodeContinuation(queryPosition{rangeNumber: 5, index: []byte{1}})},
+	}
+
+	for name, options := range cases {
+		if _, err := table.Query(nil, options); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestTable_Rows_StreamsMatchingRecords(t *testing.T) {
+	table := newOrdersTable(t)
+
+	var ids []uint64
+	for record, err := range table.Rows(Eq("status", primitive.NewString("new")), QueryOptions{}) {
+		if err != nil {
+			t.Fatalf("Rows: %v", err)
+		}
+		ids = append(ids, record.GetUint64("id"))
+	}
+
+	if !slices.Equal(ids, []uint64{1, 2, 6}) {
+		t.Errorf("expected [1 2 6], got %v", ids)
+	}
+}
+
+func TestTable_Rows_StopsEarly(t *testing.T) {
+	table := newOrdersTable(t)
+	table.changeEvents = nil // Records of the setup count as committed, reads of own writes are recorded with values before them
+	table.trackReads = true
+
+	count := 0
+	for range table.Rows(nil, QueryOptions{}) {
+		count++
+		if count == 2 {
+			break
+		}
+	}
+
+	if count != 2 {
+		t.Errorf("expected iteration to stop after 2 records, got %d", count)
+	}
+	if len(table.readEvents) != 1 || table.readEvents[0].(*events.ReadRange).Entries != 2 {
+		t.Errorf("expected scanned range of only 2 rows to be read, got %v", table.readEvents)
+	}
+}
+
+func TestTable_Rows_BySecondaryIndex_ReadsOnlyVisitedEntries(t *testing.T) {
+	table := newOrdersTable(t)
+	for id := uint64(100); id < 100+2*PRIMARY_LOOKUP_BATCH_SIZE; id++ {
+		table.Insert(orderRecord(id, "bulk", 10, "dave"))
+	}
+	table.changeEvents = nil // Records of the setup count as committed, reads of own writes are recorded with values before them
+	table.trackReads = true
+
+	count := 0
+	for _, err := range table.Rows(Eq("status", primitive.NewString("bulk")), QueryOptions{}) {
+		if err != nil {
+			t.Fatalf("Rows: %v", err)
+		}
+		if count++; count == 3 {
+			break
+		}
+	}
+
+	var ranges, records int
+	for _, event := range table.readEvents {
+		switch event := event.(type) {
+		case *events.ReadRange:
+			if event.Entries != 3 {
+				t.Errorf("expected scanned range of only 3 entries to be read, got %d", event.Entries)
+			}
+			ranges++
+		case *events.ReadEntry:
+			records++
+		}
+	}
+	if ranges != 1 || records != 3 {
+		t.Errorf("expected a range and 3 records to be read, got %d ranges and %d records", ranges, records)
+	}
+
+	if found, err := table.FindWhere(Eq("status", primitive.NewString("bulk"))); err != nil || len(found) != 2*PRIMARY_LOOKUP_BATCH_SIZE {
+		t.Errorf("expected %d records looked up in batches, got %d, %v", 2*PRIMARY_LOOKUP_BATCH_SIZE, len(found), err)
+	}
+}
+
+func TestTable_Rows_RespectsLimit(t *testing.T) {
+	table := newOrdersTable(t)
+
+	count := 0
+	for range table.Rows(nil, QueryOptions{OrderBy: "id", Order: ORDER_DESCENDING, Limit: 3}) {
+		count++
+	}
+
+	if count != 3 {
+		t.Errorf("expected 3 records, got %d", count)
+	}
+}
+
+func TestTable_Rows_PropagatesErrors(t *testing.T) {
+	table := newOrdersTable(t)
+
+	// Secondary index entry of a record which doesn't exist
+	table.kv.Set(&kv.SetRequest{Key: table.getSecondaryIndex(orderRecord(42, "lost", 1, "dave"), 1)})
+
+	var records, errs int
+	for record, err := range table.Rows(Eq("customer", primitive.NewString("dave")), QueryOptions{}) {
+		if err != nil {
+			errs++
+			if record != nil {
+				t.Error("expected nil record with error")
+			}
+			continue
+		}
+		records++
+	}
+
+	if errs != 1 || records != 0 {
+		t.Errorf("expected single lookup error, got %d errors and %d records", errs, records)
+	}
+
+	// Payload which can't be decoded
+	table.kv.Set(&kv.SetRequest{Key: table.getPrimaryIndex(orderRecord(43, "", 0, "")), Value: codec.EncodeValue(primitive.NewUint32(1))})
+
+	for _, err := range table.Rows(Eq("id", primitive.NewUint64(43)), QueryOptions{}) {
+		if err == nil {
+			t.Error("expected decode error for malformed payload")
+		}
+	}
+}
+
+func TestTable_DeleteWhere_DeletesInBatches(t *testing.T) {
+	table := newTableWithSecondaryIndex(t)
+	for id := uint64(1); id <= DELETE_BATCH_SIZE*2+10; id++ {
+		email := "even@example.com"
+		if id%2 == 1 {
+			email = "odd@example.com"
+		}
+		table.Insert(userRecordWithEmail(id, "user", email))
+	}
+
+	deleted := 0
+	for record, err := range table.DeleteWhere(Eq("email", primitive.NewString("odd@example.com"))) {
+		if err != nil {
+			t.Fatalf("DeleteWhere: %v", err)
+		}
+		if record.GetUint64("id")%2 != 1 {
+			t.Errorf("unexpected deleted record %d", record.GetUint64("id"))
+		}
+		deleted++
+	}
+
+	if deleted != DELETE_BATCH_SIZE+5 {
+		t.Errorf("expected %d deleted records, got %d", DELETE_BATCH_SIZE+5, deleted)
+	}
+
+	remaining, err := table.FindWhere(Eq("email", primitive.NewString("odd@example.com")))
+	if err != nil || len(remaining) != 0 {
+		t.Errorf("expected no odd records and no dangling index entries, got %d, %v", len(remaining), err)
+	}
+}
+
+func TestTable_DeleteWhere_StopsEarly(t *testing.T) {
+	table := newOrdersTable(t)
+
+	for range table.DeleteWhere(nil) {
+		break
+	}
+
+	if records, _ := table.FindWhere(nil); len(records) != 6 {
+		t.Errorf("expected only one record to be deleted, %d left", len(records))
+	}
+}
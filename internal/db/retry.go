@@ -0,0 +1,61 @@
+package db
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+const DEFAULT_RETRY_INITIAL_BACKOFF = 1 * time.Millisecond
+const DEFAULT_RETRY_MAX_BACKOFF = 100 * time.Millisecond
+const DEFAULT_RETRY_MULTIPLIER = 2.0
+const DEFAULT_RETRY_JITTER = 0.5
+const DEFAULT_RETRY_DEADLINE = 10 * time.Second
+
+type RetryPolicy struct {
+	InitialBackoff time.Duration // Delay before the first retry
+	MaxBackoff     time.Duration // Upper bound of delay between retries
+	Multiplier     float64       // Factor the delay is multiplied by after every retry
+	Jitter         float64       // Fraction of delay in [0, 1] which is randomized to spread out competing transactions
+	Deadline       time.Duration // Total time after which retrying gives up
+	MaxAttempts    int           // Max number of attempts, zero value means attempts are limited only by deadline
+}
+
+func applyRetryPolicyDefaults(policy RetryPolicy) RetryPolicy {
+	if policy.InitialBackoff == 0 {
+		policy.InitialBackoff = DEFAULT_RETRY_INITIAL_BACKOFF
+	}
+
+	if policy.MaxBackoff == 0 {
+		policy.MaxBackoff = DEFAULT_RETRY_MAX_BACKOFF
+	}
+
+	if policy.Multiplier < 1 {
+		policy.Multiplier = DEFAULT_RETRY_MULTIPLIER
+	}
+
+	if policy.Jitter <= 0 || policy.Jitter > 1 {
+		policy.Jitter = DEFAULT_RETRY_JITTER
+	}
+
+	if policy.Deadline == 0 {
+		policy.Deadline = DEFAULT_RETRY_DEADLINE
+	}
+
+	return policy
+}
+
+// backoff returns delay before the given retry attempt (starting from 1) with applied jitter
+func (policy RetryPolicy) backoff(attempt int) time.Duration {
+	delay := float64(policy.InitialBackoff)
+
+	for range attempt - 1 {
+		delay *= policy.Multiplier
+
+		if delay >= float64(policy.MaxBackoff) {
+			delay = float64(policy.MaxBackoff)
+			break
+		}
+	}
+
+	return time.Duration(delay * (1 - policy.Jitter*rand.Float64()))
+}
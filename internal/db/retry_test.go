@@ -0,0 +1,54 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyRetryPolicyDefaults_FillsEmptyValues(t *testing.T) {
+	policy := applyRetryPolicyDefaults(RetryPolicy{})
+	if policy.InitialBackoff != DEFAULT_RETRY_INITIAL_BACKOFF {
+		t.Errorf("expected InitialBackoff=%v, got %v", DEFAULT_RETRY_INITIAL_BACKOFF, policy.InitialBackoff)
+	}
+	if policy.MaxBackoff != DEFAULT_RETRY_MAX_BACKOFF {
+		t.Errorf("expected MaxBackoff=%v, got %v", DEFAULT_RETRY_MAX_BACKOFF, policy.MaxBackoff)
+	}
+	if policy.Multiplier != DEFAULT_RETRY_MULTIPLIER {
+		t.Errorf("expected Multiplier=%v, got %v", DEFAULT_RETRY_MULTIPLIER, policy.Multiplier)
+	}
+	if policy.Jitter != DEFAULT_RETRY_JITTER {
+		t.Errorf("expected Jitter=%v, got %v", DEFAULT_RETRY_JITTER, policy.Jitter)
+	}
+	if policy.Deadline != DEFAULT_RETRY_DEADLINE {
+		t.Errorf("expected Deadline=%v, got %v", DEFAULT_RETRY_DEADLINE, policy.Deadline)
+	}
+}
+
+func TestRetryPolicy_Backoff_GrowsExponentially(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Millisecond, MaxBackoff: time.Second, Multiplier: 2, Jitter: 0.0001}
+	for attempt, expected := range []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond} {
+		delay := policy.backoff(attempt + 1)
+		if delay > expected || delay < expected*99/100 {
+			t.Errorf("attempt %d: expected delay close to %v, got %v", attempt+1, expected, delay)
+		}
+	}
+}
+
+func TestRetryPolicy_Backoff_CappedByMaxBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, Multiplier: 10, Jitter: 0.5}
+	for attempt := 1; attempt < 100; attempt++ {
+		if delay := policy.backoff(attempt); delay > 5*time.Millisecond {
+			t.Fatalf("attempt %d: delay %v exceeds MaxBackoff", attempt, delay)
+		}
+	}
+}
+
+func TestRetryPolicy_Backoff_JitterWithinBounds(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2, Jitter: 0.5}
+	for range 100 {
+		delay := policy.backoff(1)
+		if delay < 5*time.Millisecond || delay > 10*time.Millisecond {
+			t.Fatalf("expected delay within [5ms, 10ms], got %v", delay)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"distributed-storage/internal/codec"
+	"distributed-storage/internal/primitive"
+	"fmt"
+)
+
+const (
+	EXTRA_COLUMNS_PERMISSIVE ExtraColumnsPolicy = iota // Records can have fields which are not described by the schema
+	EXTRA_COLUMNS_STRICT                               // Records with fields which are not described by the schema are rejected
+)
+
+type ExtraColumnsPolicy uint8
+
+type Column struct {
+	Name     string
+	Type     primitive.PrimitiveType
+	Nullable bool
+	Default  []byte // Value encoded by codec.EncodeValue which is set when the column is missing, see WithDefault
+
+	defaultValue primitive.Primitive
+}
+
+// WithDefault returns copy of the column with the given default value
+func (column Column) WithDefault(value primitive.Primitive) Column {
+	column.Default = codec.EncodeValue(value)
+	column.defaultValue = value
+
+	return column
+}
+
+// validateColumns checks column definitions and decodes their default values
+func (table *Table) validateColumns() error {
+	if table.schema.ExtraColumns != EXTRA_COLUMNS_PERMISSIVE && table.schema.ExtraColumns != EXTRA_COLUMNS_STRICT {
+		return fmt.Errorf("Table: unknown extra columns policy %d", table.schema.ExtraColumns)
+	}
+
+	if len(table.schema.Columns) == 0 {
+		return nil
+	}
+
+	columns := make(map[string]*Column, len(table.schema.Columns))
+
+	for idx := range table.schema.Columns {
+		column := &table.schema.Columns[idx]
+
+		if column.Name == "" {
+			return fmt.Errorf("Table: column %d must have a name", idx)
+		}
+		if _, ok := columns[column.Name]; ok {
+			return fmt.Errorf("Table: column %q is defined more than once", column.Name)
+		}
+		columns[column.Name] = column
+
+		if column.Default != nil && column.defaultValue == nil {
+			var err error
+			if column.defaultValue, _, err = codec.DecodeValue(column.Default); err != nil {
+				return fmt.Errorf("Table: couldn't decode default value of column %q: %w", column.Name, err)
+			}
+		}
+
+		if column.defaultValue != nil && column.defaultValue.Type() != column.Type {
+			return fmt.Errorf("Table: default value of column %q has type %s, expected %s",
+				column.Name, primitive.TypeName(column.defaultValue.Type()), primitive.TypeName(column.Type))
+		}
+	}
+
+	for name, indexedType := range table.schema.IndexedColumns {
+		if column, ok := columns[name]; ok && column.Type != indexedType {
+			return fmt.Errorf("Table: indexed column %q has type %s, but column is defined with type %s",
+				name, primitive.TypeName(indexedType), primitive.TypeName(column.Type))
+		}
+	}
+
+	for _, name := range table.schema.PrimaryIndex {
+		if column, ok := columns[name]; ok && column.Nullable {
+			return fmt.Errorf("Table: primary index column %q can't be nullable", name)
+		}
+	}
+
+	return nil
+}
+
+// validateRecord checks the record against column schema and sets default values of missing columns.
+// Indexed columns are checked even without column schema, because index decoding relies on their types.
+func (table *Table) validateRecord(record *primitive.Object) error {
+	if record.Has(ROW_VERSION_FIELD) {
+		return fmt.Errorf("Table %q: field %q is reserved", table.schema.Name, ROW_VERSION_FIELD)
+	}
+
+	for name, indexedType := range table.schema.IndexedColumns {
+		if value := record.Get(name); !value.Empty() && value.Type() != indexedType {
+			return fmt.Errorf("Table %q: indexed column %q expects %s value, got %s",
+				table.schema.Name, name, primitive.TypeName(indexedType), primitive.TypeName(value.Type()))
+		}
+	}
+
+	if len(table.schema.Columns) == 0 {
+		return nil
+	}
+
+	for _, column := range table.schema.Columns {
+		value := record.Get(column.Name)
+
+		if value.Empty() && column.defaultValue != nil {
+			record.Set(column.Name, column.defaultValue)
+			continue
+		}
+
+		if value.Empty() {
+			if !column.Nullable {
+				return fmt.Errorf("Table %q: column %q can't be null", table.schema.Name, column.Name)
+			}
+			continue
+		}
+
+		if value.Type() != column.Type {
+			return fmt.Errorf("Table %q: column %q expects %s value, got %s",
+				table.schema.Name, column.Name, primitive.TypeName(column.Type), primitive.TypeName(value.Type()))
+		}
+	}
+
+	if table.schema.ExtraColumns == EXTRA_COLUMNS_STRICT {
+		for name := range record.Values() {
+			if !table.hasColumn(name) {
+				return fmt.Errorf("Table %q: column %q isn't defined in schema", table.schema.Name, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (table *Table) hasColumn(name string) bool {
+	for _, column := range table.schema.Columns {
+		if column.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,163 @@
+package db
+
+import (
+	"distributed-storage/internal/pager"
+	"distributed-storage/internal/primitive"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func typedSchema() *TableSchema {
+	return &TableSchema{
+		Name:           "accounts",
+		PrimaryIndex:   []string{"id"},
+		IndexedColumns: map[string]primitive.PrimitiveType{"id": primitive.TYPE_UINT64},
+		Columns: []Column{
+			{Name: "id", Type: primitive.TYPE_UINT64},
+			{Name: "name", Type: primitive.TYPE_STRING},
+			{Name: "email", Type: primitive.TYPE_STRING, Nullable: true},
+			Column{Name: "active", Type: primitive.TYPE_BOOL}.WithDefault(primitive.NewBool(true)),
+		},
+		ExtraColumns: EXTRA_COLUMNS_STRICT,
+	}
+}
+
+func newTypedTable(t *testing.T, schema *TableSchema) *Table {
+	t.Helper()
+	table, err := newTable(TableID(8), pager.NULL_PAGE, newTestPager(), schema)
+	if err != nil {
+		t.Fatalf("newTable: %v", err)
+	}
+	return table
+}
+
+func TestTable_Insert_SetsDefaultValues(t *testing.T) {
+	table := newTypedTable(t, typedSchema())
+
+	if _, err := table.Insert(userRecord(1, "Alice")); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	record, _ := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(1)))
+	if !record.GetBool("active") {
+		t.Error("expected default value of column 'active' to be set")
+	}
+	if record.Has("email") {
+		t.Error("expected nullable column without default to stay missing")
+	}
+}
+
+func TestTable_Insert_RejectsInvalidRecords(t *testing.T) {
+	table := newTypedTable(t, typedSchema())
+
+	cases := map[string]*primitive.Object{
+		`column "name" can't be null`:            primitive.NewObject().Set("id", primitive.NewUint64(1)),
+		`column "name" expects string value`:     userRecord(1, "Alice").Set("name", primitive.NewUint32(7)),
+		`column "nickname" isn't defined`:        userRecord(1, "Alice").Set("nickname", primitive.NewString("Al")),
+		`indexed column "id" expects uint64 val`: primitive.NewObject().Set("id", primitive.NewString("1")).Set("name", primitive.NewString("Alice")),
+	}
+
+	for expected, record := range cases {
+		_, err := table.Insert(record)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error containing %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestTable_Insert_PermissiveAllowsExtraColumns(t *testing.T) {
+	schema := typedSchema()
+	schema.ExtraColumns = EXTRA_COLUMNS_PERMISSIVE
+	table := newTypedTable(t, schema)
+
+	if _, err := table.Insert(userRecord(1, "Alice").Set("nickname", primitive.NewString("Al"))); err != nil {
+		t.Errorf("expected extra column to be accepted, got %v", err)
+	}
+}
+
+func TestTable_Insert_ValidatesIndexedColumnsWithoutColumnSchema(t *testing.T) {
+	table := newTableWithSecondaryIndex(t)
+
+	if _, err := table.Insert(userRecord(1, "Alice").Set("email", primitive.NewUint32(1))); err == nil {
+		t.Error("expected error for indexed column of wrong type")
+	}
+}
+
+func TestTable_Update_ValidatesMergedRecord(t *testing.T) {
+	table := newTypedTable(t, typedSchema())
+	table.Insert(userRecord(1, "Alice"))
+
+	if _, err := table.Update(primitive.NewObject().Set("id", primitive.NewUint64(1)).Set("active", primitive.NewString("yes"))); err == nil {
+		t.Error("expected error for update with wrong type")
+	}
+	if _, err := table.Update(primitive.NewObject().Set("id", primitive.NewUint64(1)).Set("email", primitive.NewString("a@example.com"))); err != nil {
+		t.Errorf("expected partial update to be valid, got %v", err)
+	}
+}
+
+func TestTable_Upsert_ValidatesRecord(t *testing.T) {
+	table := newTypedTable(t, typedSchema())
+
+	if _, err := table.Upsert(primitive.NewObject().Set("id", primitive.NewUint64(1))); err == nil {
+		t.Error("expected error for upsert without required column")
+	}
+}
+
+func TestTable_UpdateMany_DoesNotApplyInvalidUpdate(t *testing.T) {
+	table := newTypedTable(t, typedSchema())
+	table.Insert(userRecord(1, "Alice"))
+	table.Insert(userRecord(2, "Bob"))
+
+	if _, err := table.UpdateMany(primitive.NewObject(), primitive.NewObject().Set("name", primitive.NewBool(false))); err == nil {
+		t.Fatal("expected error for invalid update")
+	}
+
+	records, _ := table.GetAll()
+	for _, record := range records {
+		if record.Get("name").Type() != primitive.TYPE_STRING {
+			t.Errorf("expected record %d not to be changed", record.GetUint64("id"))
+		}
+	}
+}
+
+func TestNewTable_InvalidColumns(t *testing.T) {
+	cases := map[string]func(schema *TableSchema){
+		"duplicate column": func(schema *TableSchema) {
+			schema.Columns = append(schema.Columns, Column{Name: "name", Type: primitive.TYPE_STRING})
+		},
+		"default of wrong type": func(schema *TableSchema) { schema.Columns[1] = schema.Columns[1].WithDefault(primitive.NewUint32(1)) },
+		"indexed type mismatch": func(schema *TableSchema) { schema.IndexedColumns["id"] = primitive.TYPE_UINT32 },
+		"nullable primary key":  func(schema *TableSchema) { schema.Columns[0].Nullable = true },
+		"unknown extra policy":  func(schema *TableSchema) { schema.ExtraColumns = ExtraColumnsPolicy(9) },
+	}
+
+	for name, corrupt := range cases {
+		schema := typedSchema()
+		corrupt(schema)
+		if _, err := newTable(TableID(8), pager.NULL_PAGE, newTestPager(), schema); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestColumn_Default_SurvivesSchemaSerialization(t *testing.T) {
+	encoded, err := json.Marshal(typedSchema())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	schema := &TableSchema{}
+	if err := json.Unmarshal(encoded, schema); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	table := newTypedTable(t, schema)
+	record := userRecord(1, "Alice")
+	if _, err := table.Insert(record); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if !record.GetBool("active") {
+		t.Error("expected default value to be restored from serialized schema")
+	}
+}
@@ -0,0 +1,259 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"distributed-storage/internal/codec"
+	"distributed-storage/internal/events"
+	"distributed-storage/internal/kv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const STATISTICS_INDEX_ID int = math.MaxUint32 // Index section holding the single entry with statistics written by Analyze
+
+const (
+	COST_ROW_SCAN     = 1.0  // Sequential read of primary index entry
+	COST_ENTRY_SCAN   = 0.5  // Sequential read of secondary index entry
+	COST_ROW_LOOKUP   = 4.0  // Point lookup of record found by secondary index entry
+	RANGE_SELECTIVITY = 0.25 // Fraction of entries estimated within comparison bounds or prefix match of a column
+)
+
+type IndexStatistics struct {
+	Entries  uint64
+	Distinct []uint64 // Number of distinct values of leading columns, Distinct[i] counts prefixes of i+1 columns
+}
+
+type TableStatistics struct {
+	Rows    uint64
+	Indexes []IndexStatistics // Statistics of primary index followed by secondary indexes in order of index ids
+}
+
+// QueryExplanation describes plan of Table.Query. Estimates are zero if the table wasn't analyzed.
+type QueryExplanation struct {
+	Index         string // "primary" or name of the secondary index, its columns if it has no name
+	FullScan      bool
+	Ranges        int
+	EstimatedRows float64 // Estimated number of scanned index entries
+	Cost          float64
+	Analyzed      bool // Whether estimates are based on statistics collected by Analyze
+}
+
+// Analyze counts entries and distinct leading column prefixes of every index and stores them in the table,
+// so the planner can choose indexes by cost. Statistics aren't updated by writes until the next Analyze.
+func (table *Table) Analyze() (*TableStatistics, error) {
+	if table.readOnly {
+		return nil, fmt.Errorf("Table: can't analyze table %q: %w", table.schema.Name, ErrReadOnlyTransaction)
+	}
+
+	statistics := &TableStatistics{Indexes: make([]IndexStatistics, len(table.schema.SecondaryIndexes)+1)}
+
+	for indexID := range statistics.Indexes {
+		index, err := table.analyzeIndex(indexID)
+		if err != nil {
+			return nil, err
+		}
+
+		statistics.Indexes[indexID] = index
+	}
+
+	statistics.Rows = statistics.Indexes[PRIMARY_INDEX_ID].Entries
+
+	encoded, err := json.Marshal(statistics)
+	if err != nil {
+		return nil, fmt.Errorf("Table %q: couldn't encode statistics: %w", table.schema.Name, err)
+	}
+
+	key := table.indexPrefix(STATISTICS_INDEX_ID)
+
+	response, err := table.kv.Set(&kv.SetRequest{Key: key, Value: encoded})
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Updated {
+		table.changeEvents = append(table.changeEvents, events.NewUpdateEntry(uint64(table.id), key, response.OldValue, encoded))
+	} else {
+		table.changeEvents = append(table.changeEvents, events.NewInsertEntry(uint64(table.id), key, encoded))
+	}
+
+	return statistics, nil
+}
+
+// Explain returns the plan Query would execute with the predicate and options
+func (table *Table) Explain(predicate *Predicate, options QueryOptions) (*QueryExplanation, error) {
+	plan, err := table.planQuery(predicate, options)
+	if err != nil {
+		return nil, err
+	}
+
+	indexID := plan.ranges[0].indexID
+	explanation := &QueryExplanation{
+		Index:    table.indexName(indexID),
+		FullScan: len(plan.ranges) == 1 && plan.ranges[0].equalColumns == 0 && !plan.ranges[0].bounded,
+		Ranges:   len(plan.ranges),
+	}
+
+	if statistics := table.statistics(); statistics != nil {
+		explanation.Analyzed = true
+
+		explanation.Cost = statistics.rangesCost(plan.ranges)
+
+		for _, indexRange := range plan.ranges {
+			explanation.EstimatedRows += statistics.estimateEntries(indexRange)
+		}
+	}
+
+	return explanation, nil
+}
+
+func (explanation *QueryExplanation) String() string {
+	var description strings.Builder
+
+	if explanation.FullScan {
+		fmt.Fprintf(&description, "full scan of %s index", explanation.Index)
+	} else {
+		fmt.Fprintf(&description, "%d range(s) of %s index", explanation.Ranges, explanation.Index)
+	}
+
+	if explanation.Analyzed {
+		fmt.Fprintf(&description, ", estimated %.0f rows, cost %.1f", explanation.EstimatedRows, explanation.Cost)
+	}
+
+	return description.String()
+}
+
+// Analyze collects statistics of the table in its own transaction
+func (db *Database) Analyze(ctx context.Context, tableName string) (*TableStatistics, error) {
+	var statistics *TableStatistics
+
+	err := db.RunInTransaction(ctx, func(tx *Transaction) error {
+		table, err := tx.Table(tableName)
+		if err != nil {
+			return err
+		}
+		if table == nil {
+			return fmt.Errorf("Database: table %q doesn't exist", tableName)
+		}
+
+		statistics, err = table.Analyze()
+		return err
+	}, RetryPolicy{})
+	if err != nil {
+		return nil, fmt.Errorf("Database: couldn't analyze table %q: %w", tableName, err)
+	}
+
+	return statistics, nil
+}
+
+// analyzeIndex scans the index in key order, so a prefix is distinct if it differs from the prefix of previous key
+func (table *Table) analyzeIndex(indexID int) (IndexStatistics, error) {
+	columns := len(table.schema.PrimaryIndex)
+	if indexID != PRIMARY_INDEX_ID {
+		columns = len(table.schema.SecondaryIndexes[indexID-PRIMARY_INDEX_ID-1].Columns)
+	}
+
+	index := IndexStatistics{Distinct: make([]uint64, columns)}
+	prefix := table.indexPrefix(indexID)
+
+	var previous []byte
+	previousEnds := make([]int, columns) // Offsets where leading column values of the previous key end
+
+	cursor := table.kv.Scan(&kv.ScanRequest{Key: prefix})
+
+	for key, _ := cursor.Current(); bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+		index.Entries++
+		end := INDEX_ID_SIZE
+
+		for column := range columns {
+			var size int
+			var err error
+
+			if _, size, err = codec.DecodeValue(key[end:]); err != nil {
+				return index, fmt.Errorf("Table %q: couldn't decode index key %x: %w", table.schema.Name, key, err)
+			}
+			end += size
+
+			if previous == nil || !bytes.Equal(previous[:previousEnds[column]], key[:end]) {
+				index.Distinct[column]++
+			}
+			previousEnds[column] = end
+		}
+
+		previous = bytes.Clone(key)
+	}
+
+	return index, nil
+}
+
+// statistics returns statistics collected by the last Analyze, nil if the table wasn't analyzed. Reading them isn't
+// recorded, so concurrent Analyze doesn't conflict with queries.
+func (table *Table) statistics() *TableStatistics {
+	response, err := table.kv.Get(&kv.GetRequest{Key: table.indexPrefix(STATISTICS_INDEX_ID)})
+	if err != nil || response.Value == nil {
+		return nil
+	}
+
+	statistics := &TableStatistics{}
+	if err := json.Unmarshal(response.Value, statistics); err != nil || len(statistics.Indexes) != len(table.schema.SecondaryIndexes)+1 {
+		return nil
+	}
+
+	return statistics
+}
+
+// estimateEntries estimates number of index entries within the range, assuming values are evenly distributed
+// among distinct prefixes
+func (statistics *TableStatistics) estimateEntries(indexRange indexRange) float64 {
+	index := statistics.Indexes[indexRange.indexID]
+	estimated := float64(index.Entries)
+
+	if indexRange.equalColumns > 0 && indexRange.equalColumns <= len(index.Distinct) {
+		estimated /= float64(max(index.Distinct[indexRange.equalColumns-1], 1))
+	}
+
+	if indexRange.bounded {
+		estimated *= RANGE_SELECTIVITY
+	}
+
+	return estimated
+}
+
+// estimatePrefixEntries estimates number of entries of the secondary index with the leading columns fixed
+func (statistics *TableStatistics) estimatePrefixEntries(secondaryIndexNumber int, columns int) float64 {
+	return statistics.estimateEntries(indexRange{indexID: PRIMARY_INDEX_ID + secondaryIndexNumber + 1, equalColumns: columns})
+}
+
+// entryCost is cost of reading a record through an entry of the index
+func (statistics *TableStatistics) entryCost(indexID int) float64 {
+	if indexID == PRIMARY_INDEX_ID {
+		return COST_ROW_SCAN
+	}
+
+	return COST_ENTRY_SCAN + COST_ROW_LOOKUP
+}
+
+func (statistics *TableStatistics) rangesCost(ranges []indexRange) float64 {
+	cost := 0.0
+
+	for _, indexRange := range ranges {
+		cost += statistics.estimateEntries(indexRange) * statistics.entryCost(indexRange.indexID)
+	}
+
+	return cost
+}
+
+func (table *Table) indexName(indexID int) string {
+	if indexID == PRIMARY_INDEX_ID {
+		return "primary"
+	}
+
+	secondaryIndex := table.schema.SecondaryIndexes[indexID-PRIMARY_INDEX_ID-1]
+	if secondaryIndex.Name != "" {
+		return secondaryIndex.Name
+	}
+
+	return strings.Join(secondaryIndex.Columns, ",")
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"distributed-storage/internal/primitive"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func newAnalyzedOrdersTable(t *testing.T) *Table {
+	t.Helper()
+	table := newOrdersTable(t)
+
+	for id := uint64(10); id < 30; id++ {
+		table.Insert(orderRecord(id, "new", uint32(id), fmt.Sprintf("customer%d", id)))
+	}
+
+	if _, err := table.Analyze(); err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+
+	return table
+}
+
+func TestTable_Analyze_CountsEntriesAndDistinctPrefixes(t *testing.T) {
+	table := newOrdersTable(t)
+
+	statistics, err := table.Analyze()
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+
+	expected := &TableStatistics{Rows: 7, Indexes: []IndexStatistics{
+		{Entries: 7, Distinct: []uint64{7}},
+		{Entries: 6, Distinct: []uint64{3, 5}},
+		{Entries: 6, Distinct: []uint64{4}},
+	}}
+
+	if statistics.Rows != expected.Rows || !slices.EqualFunc(statistics.Indexes, expected.Indexes, func(a, b IndexStatistics) bool {
+		return a.Entries == b.Entries && slices.Equal(a.Distinct, b.Distinct)
+	}) {
+		t.Errorf("expected %+v, got %+v", expected, statistics)
+	}
+
+	if stored := table.statistics(); stored == nil || stored.Rows != 7 {
+		t.Errorf("expected statistics to be stored in the table, got %+v", stored)
+	}
+	if records, _ := table.GetAll(); len(records) != 7 {
+		t.Errorf("expected statistics entry to be hidden from records, got %d records", len(records))
+	}
+}
+
+func TestTable_Explain_ChoosesIndexByCost(t *testing.T) {
+	table := newOrdersTable(t)
+	predicate := And(Eq("status", primitive.NewString("new")), Eq("customer", primitive.NewString("alice")))
+
+	explanation, err := table.Explain(predicate, QueryOptions{})
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	if explanation.Index != "status,amount" || explanation.Analyzed {
+		t.Errorf("expected first matching index without statistics, got %s", explanation)
+	}
+
+	table = newAnalyzedOrdersTable(t)
+
+	explanation, _ = table.Explain(predicate, QueryOptions{})
+	if explanation.Index != "customer" || !explanation.Analyzed || explanation.EstimatedRows > 2 {
+		t.Errorf("expected selective customer index, got %s", explanation)
+	}
+
+	explanation, _ = table.Explain(Eq("status", primitive.NewString("new")), QueryOptions{})
+	if !explanation.FullScan || explanation.Index != "primary" {
+		t.Errorf("expected full scan to be cheaper than unselective status index, got %s", explanation)
+	}
+
+	if ids := foundIDs(t, table, predicate); !slices.Equal(ids, []uint64{1}) {
+		t.Errorf("expected order 1, got %v", ids)
+	}
+}
+
+func TestTable_Find_UsesStatistics(t *testing.T) {
+	table := newAnalyzedOrdersTable(t)
+	query := primitive.NewObject().Set("status", primitive.NewString("new")).Set("customer", primitive.NewString("alice"))
+
+	partialIndex, isPrimary := table.getPartialIndex(query)
+	if isPrimary || table.indexIDOf(partialIndex) != 2 {
+		t.Errorf("expected customer index to be chosen, got %x", partialIndex)
+	}
+
+	if records, _ := table.Find(query); len(records) != 1 || records[0].GetUint64("id") != 1 {
+		t.Errorf("expected order 1, got %v", records)
+	}
+}
+
+func TestDatabase_Analyze_PersistsStatistics(t *testing.T) {
+	db, err := NewDatabase(newTestDatabaseConfig(t))
+	if err != nil {
+		t.Fatalf("NewDatabase failed: %v", err)
+	}
+	db.StartTransaction(func(tx *Transaction) {
+		table, _ := tx.CreateTable(ordersSchema())
+		table.Insert(orderRecord(1, "new", 100, "alice"))
+		table.Insert(orderRecord(2, "paid", 50, "bob"))
+	})
+
+	if _, err := db.Analyze(context.Background(), "orders"); err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+
+	db.StartTransaction(func(tx *Transaction) {
+		table, _ := tx.Table("orders")
+		if statistics := table.statistics(); statistics == nil || statistics.Rows != 2 {
+			t.Errorf("expected committed statistics with 2 rows, got %+v", statistics)
+		}
+	})
+}
@@ -6,43 +6,88 @@ import (
 	"distributed-storage/internal/events"
 	"distributed-storage/internal/kv"
 	"distributed-storage/internal/pager"
-	"distributed-storage/internal/vals"
+	"distributed-storage/internal/primitive"
 	"encoding/binary"
 	"fmt"
+	"hash/fnv"
+	"maps"
+	"math"
 	"slices"
 )
 
 const PRIMARY_INDEX_ID int = 0 // Primary index id, secondary indexes ids start from 1
 const INDEX_ID_SIZE int = 4    // Size of index section id in bytes
 
+const (
+	PRIMARY_LOOKUP_BATCH_SIZE    = 256 // Number of records found by secondary index entries which are fetched together
+	PRIMARY_LOOKUP_PREFETCH_SIZE = 32  // Batches of this size or larger read pages of the primary index level by level
+)
+
+const PAYLOAD_DIRECTORY_FORMAT byte = 0xFF // First byte of payloads with field directory, older payloads start with type of field name
+
 const (
 	TABLE_ACTIVE TableState = iota
 	TABLE_DROPPING
 	TABLE_DROPPED
 )
 
+const (
+	KEY_GENERATOR_NONE     KeyGenerator = iota // Primary key must be set by the caller
+	KEY_GENERATOR_SEQUENCE                     // Uint64 primary key taken from the table sequence persisted in the catalog
+	KEY_GENERATOR_UUID_V7                      // UUID primary key ordered by generation time
+)
+
 type TableID uint64
 type TableState uint32
+type KeyGenerator uint8
 
 type SecondaryIndex struct {
 	Name    string
 	Unique  bool
 	Columns []string
+	Include []string // Columns stored in entry values, so reading them through the index doesn't fetch records
 }
 type TableSchema struct {
 	Name             string
 	PrimaryIndex     []string
 	SecondaryIndexes []SecondaryIndex
-	IndexedColumns   map[string]vals.ValueType
+	IndexedColumns   map[string]primitive.PrimitiveType
+	KeyGenerator     KeyGenerator       // Generates primary key for inserted records which don't have it
+	Columns          []Column           // Column schema, records aren't validated beyond indexed column types if empty
+	ExtraColumns     ExtraColumnsPolicy // Whether records can have fields which aren't listed in Columns
+	Version          uint32             // Schema version, rows written with older versions are upgraded on read
+	Migrations       []Migration        // Migrations applied to the table, needed to upgrade rows of older versions
+	ForeignKeys      []ForeignKey       // Foreign keys referencing primary indexes of other tables
+	TTL              *RowTTL            // Rows expire after the time to live, nil if rows don't expire
+}
+
+type tableSavepoint struct {
+	root              pager.PagePointer
+	state             TableState
+	stateVersion      DatabaseVersion
+	schema            *TableSchema
+	sequence          uint64
+	changeEventsCount int
+	readEventsCount   int
 }
 
 type Table struct {
-	id    TableID
-	state TableState
+	id           TableID
+	state        TableState
+	stateVersion DatabaseVersion // Database version the state was changed at, dropped tables are reclaimed once it is unreachable
+	sequence     uint64          // Last primary key allocated by KEY_GENERATOR_SEQUENCE
 
 	kv           *kv.KeyValue
 	schema       *TableSchema
 	changeEvents []TableEvent
+	readEvents   []TableEvent
+	writes       ownWrites      // Keys written by the transaction, collected from change events when reads are recorded
+	loaded       tableSavepoint // State the table was loaded from catalog with, empty for tables created by the transaction
+
+	readOnly   bool
+	trackReads bool
+
+	manager *TableManager // Manager which loaded the table, resolves tables of foreign keys
 }
 
 func newTable(id TableID, root pager.PagePointer, pager *pager.Pager, schema *TableSchema) (*Table, error) {
@@ -62,7 +107,12 @@ func newTable(id TableID, root pager.PagePointer, pager *pager.Pager, schema *Ta
 	return table, nil
 }
 
-func (table *Table) Get(query *vals.Object) (*vals.Object, error) {
+func (table *Table) Get(query *primitive.Object) (*primitive.Object, error) {
+	return table.get(query, nil)
+}
+
+// get returns record matching the query, only fields holding the columns and the query are decoded if columns are set
+func (table *Table) get(query *primitive.Object, columns []string) (*primitive.Object, error) {
 	index := table.getPrimaryIndex(query)
 
 	if index == nil {
@@ -74,7 +124,17 @@ func (table *Table) Get(query *vals.Object) (*vals.Object, error) {
 		return nil, err
 	}
 
-	record := table.decodePayload(response.Value)
+	table.recordRead(index, response.Value)
+
+	if table.expired(response.Value) {
+		return nil, nil
+	}
+
+	record, err := table.decodeColumns(response.Value, projectedColumns(query, columns))
+	if err != nil {
+		return nil, err
+	}
+
 	if query.Matches(record) {
 		return record, nil
 	}
@@ -82,55 +142,185 @@ func (table *Table) Get(query *vals.Object) (*vals.Object, error) {
 	return nil, nil
 }
 
-func (table *Table) Find(query *vals.Object) ([]*vals.Object, error) {
+func (table *Table) Find(query *primitive.Object) ([]*primitive.Object, error) {
+	return table.find(query, nil)
+}
+
+// find returns records matching the query. If columns are set only fields holding them and the query are decoded,
+// secondary index entries are decoded instead of fetching records if they contain all of them. Records found by
+// secondary index entries are fetched in batches.
+func (table *Table) find(query *primitive.Object, columns []string) ([]*primitive.Object, error) {
 	partialIndex, isPrimary := table.getPartialIndex(query)
 	cursor := table.kv.Scan(&kv.ScanRequest{Key: partialIndex})
 
-	var records []*vals.Object
+	decodedColumns := projectedColumns(query, columns)
+	indexOnly := !isPrimary && decodedColumns != nil && partialIndex != nil && table.coversColumns(table.indexIDOf(partialIndex), decodedColumns)
+
+	var records []*primitive.Object
+	var secondaryIndexes [][]byte
+
+	appendMatching := func(_ int, record *primitive.Object) bool {
+		if query.Matches(record) {
+			records = append(records, record)
+		}
+
+		return true
+	}
+
+	var read rangeRead
 
 	for index, value := cursor.Current(); table.matchIndexes(index, partialIndex); index, value = cursor.Next() {
-		var record *vals.Object
+		read.visit(index, value)
 
-		if isPrimary {
-			record = table.decodePayload(value)
-		} else {
-			primaryIndexValues, _, _ := table.decodeSecondaryIndex(index)
-			response, err := table.kv.Get(&kv.GetRequest{Key: table.encodePrimaryIndex(primaryIndexValues)})
+		switch {
+		case isPrimary:
+			if table.expired(value) {
+				continue
+			}
+
+			record, err := table.decodeColumns(value, decodedColumns)
 			if err != nil {
 				return nil, err
 			}
-			record = table.decodePayload(response.Value)
-		}
 
-		if query.Matches(record) {
-			records = append(records, record)
+			appendMatching(0, record)
+		case indexOnly:
+			record, err := table.decodeIndexEntry(index, value)
+			if err != nil {
+				return nil, err
+			}
+
+			appendMatching(0, record)
+		default:
+			secondaryIndexes = append(secondaryIndexes, bytes.Clone(index))
+
+			if len(secondaryIndexes) < PRIMARY_LOOKUP_BATCH_SIZE {
+				continue
+			}
+
+			if err := table.lookupRecords(secondaryIndexes, decodedColumns, appendMatching); err != nil {
+				return nil, err
+			}
+			secondaryIndexes = secondaryIndexes[:0]
 		}
 	}
 
+	if err := table.lookupRecords(secondaryIndexes, decodedColumns, appendMatching); err != nil {
+		return nil, err
+	}
+
+	table.recordRange(partialIndex, prefixSuccessor(partialIndex), read)
+
 	return records, nil
 }
 
-func (table *Table) GetAll() []*vals.Object {
+// lookupRecords fetches records the secondary index entries point to with a single pass over the tree and visits
+// them with positions of their entries in order of the entries, until visit returns false. Expired records aren't
+// visited. Batches of at least PRIMARY_LOOKUP_PREFETCH_SIZE entries are fetched in prefetch mode.
+func (table *Table) lookupRecords(secondaryIndexes [][]byte, columns []string, visit func(idx int, record *primitive.Object) bool) error {
+	if len(secondaryIndexes) == 0 {
+		return nil
+	}
+
+	primaryIndexes := make([][]byte, len(secondaryIndexes))
+	for idx, secondaryIndex := range secondaryIndexes {
+		primaryIndexValues, _, _ := table.decodeSecondaryIndex(secondaryIndex)
+		primaryIndexes[idx] = table.encodePrimaryIndex(primaryIndexValues)
+	}
+
+	response, err := table.kv.GetMany(&kv.GetManyRequest{
+		Keys:     primaryIndexes,
+		Prefetch: len(primaryIndexes) >= PRIMARY_LOOKUP_PREFETCH_SIZE,
+	})
+	if err != nil {
+		return err
+	}
+
+	for idx, primaryIndex := range primaryIndexes {
+		table.recordRead(primaryIndex, response.Values[idx])
+
+		if response.Values[idx] == nil {
+			return fmt.Errorf("Table %q: secondary index entry %x points to missing record", table.schema.Name, secondaryIndexes[idx])
+		}
+		if table.expired(response.Values[idx]) {
+			continue
+		}
+
+		record, err := table.decodeColumns(response.Values[idx], columns)
+		if err != nil {
+			return err
+		}
+
+		if !visit(idx, record) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (table *Table) GetAll() ([]*primitive.Object, error) {
 	cursor := table.kv.Scan(&kv.ScanRequest{})
 
-	var records []*vals.Object
+	var records []*primitive.Object
+	var read rangeRead
 
 	for index, value := cursor.Current(); value != nil; index, value = cursor.Next() {
 		if table.matchPrimaryIndex(index) {
-			records = append(records, table.decodePayload(value))
+			read.visit(index, value)
+
+			if table.expired(value) {
+				continue
+			}
+
+			record, err := table.decodeRecord(value)
+			if err != nil {
+				return nil, err
+			}
+
+			records = append(records, record)
 		}
 	}
 
-	return records
+	prefix := table.indexPrefix(PRIMARY_INDEX_ID)
+	table.recordRange(prefix, prefixSuccessor(prefix), read)
+
+	return records, nil
 }
 
-func (table *Table) Delete(record *vals.Object) (*vals.Object, error) {
+func (table *Table) Delete(record *primitive.Object) (*primitive.Object, error) {
+	if table.readOnly {
+		return nil, fmt.Errorf("Table: can't delete record in table %q: %w", table.schema.Name, ErrReadOnlyTransaction)
+	}
+
 	index := table.getPrimaryIndex(record)
 
 	if index == nil {
 		return nil, fmt.Errorf("Table: can't delete record because one of primary index columns is missing: %q", record)
 	}
 
+	if table.hasBeforeWriteHooks() {
+		response, err := table.kv.Get(&kv.GetRequest{Key: index})
+		if err != nil {
+			return nil, err
+		}
+
+		if response.Value != nil {
+			oldRecord, err := table.decodeRecord(response.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, err := table.runBeforeWriteHooks(WRITE_DELETE, nil, oldRecord); err != nil {
+				return nil, fmt.Errorf("Table: can't delete record: %w", err)
+			}
+		}
+	}
+
+	if err := table.checkReferences(record, false); err != nil {
+		return nil, fmt.Errorf("Table: can't delete record: %w", err)
+	}
+
 	response, err := table.kv.Delete(&kv.DeleteRequest{Key: index})
 	if err != nil {
 		return nil, err
@@ -138,10 +328,29 @@ func (table *Table) Delete(record *vals.Object) (*vals.Object, error) {
 
 	table.changeEvents = append(table.changeEvents, events.NewDeleteEntry(uint64(table.id), index, response.OldValue))
 
-	return table.decodePayload(response.OldValue), nil
+	oldRecord, err := table.decodeRecord(response.OldValue)
+	if err != nil {
+		return nil, err
+	}
+
+	if oldRecord != nil {
+		if err := table.deleteSecondaryIndexes(oldRecord); err != nil {
+			return nil, err
+		}
+
+		if err := table.updateExpiryIndex(index, nil, response.OldValue); err != nil {
+			return nil, err
+		}
+
+		if err := table.deleteReferences(oldRecord); err != nil {
+			return nil, err
+		}
+	}
+
+	return oldRecord, nil
 }
 
-func (table *Table) DeleteMany(query *vals.Object) ([]*vals.Object, error) {
+func (table *Table) DeleteMany(query *primitive.Object) ([]*primitive.Object, error) {
 	records, err := table.Find(query)
 	if err != nil {
 		return nil, err
@@ -156,38 +365,92 @@ func (table *Table) DeleteMany(query *vals.Object) ([]*vals.Object, error) {
 	return records, nil
 }
 
-func (table *Table) Insert(record *vals.Object) error {
+// Insert adds a new record and returns its primary key. Missing primary key is generated and set in the record
+// when the schema has a key generator, the key is allocated only once the record passes all checks.
+func (table *Table) Insert(record *primitive.Object) (*primitive.Object, error) {
+	if table.readOnly {
+		return nil, fmt.Errorf("Table: can't insert record in table %q: %w", table.schema.Name, ErrReadOnlyTransaction)
+	}
+
+	keyColumn := table.schema.PrimaryIndex[0]
+	if table.schema.KeyGenerator == KEY_GENERATOR_NONE || !record.Get(keyColumn).Empty() {
+		return table.insert(record, false)
+	}
+
+	key, err := table.insert(record.Set(keyColumn, table.nextKey()), true)
+	if err != nil { // The key isn't allocated, so the record gets a new one on retry
+		record.Delete(keyColumn)
+	}
+
+	return key, err
+}
+
+func (table *Table) insert(record *primitive.Object, generatedKey bool) (*primitive.Object, error) {
+	record, err := table.runBeforeWriteHooks(WRITE_INSERT, record, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Table: can't insert record: %w", err)
+	}
+
+	if err := table.validateRecord(record); err != nil {
+		return nil, fmt.Errorf("Table: can't insert invalid record: %w", err)
+	}
+
 	index := table.getPrimaryIndex(record)
 
 	if index == nil {
-		return fmt.Errorf("Table: can't insert record because one of primary index columns is missing in record %s", record)
+		return nil, fmt.Errorf("Table: can't insert record because one of primary index columns is missing in record %s", record)
 	}
 
 	response, err := table.kv.Get(&kv.GetRequest{Key: index})
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if response.Value != nil && !table.expired(response.Value) {
+		if generatedKey { // Key was taken by explicitly inserted record, it's skipped so retry gets the next one
+			table.allocateKey()
+		}
+		return nil, fmt.Errorf("Table: can't insert record because it already exists: %v", record)
+	}
+
+	if response.Value != nil { // Expired row which isn't swept yet is replaced
+		if err := table.replaceExpiredRow(index, response.Value); err != nil {
+			return nil, err
+		}
 	}
 
-	if response.Value != nil {
-		return fmt.Errorf("Table: can't insert record because it already exists: %v", record)
+	if err := table.checkParents(record, nil); err != nil {
+		return nil, fmt.Errorf("Table: can't insert record: %w", err)
 	}
 
-	value := table.encodePayload(record)
+	if generatedKey {
+		table.allocateKey()
+	}
+
+	value := table.encodeRow(record, nil)
 
 	if _, err := table.kv.Set(&kv.SetRequest{Key: index, Value: value}); err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := table.createSecondaryIndexes(record); err != nil {
-		return err
+		return nil, err
+	}
+
+	if err := table.updateExpiryIndex(index, value, nil); err != nil {
+		return nil, err
 	}
 
 	table.changeEvents = append(table.changeEvents, events.NewInsertEntry(uint64(table.id), index, value))
 
-	return nil
+	return table.primaryKey(record), nil
 }
 
-func (table *Table) Update(record *vals.Object) (*vals.Object, error) {
+func (table *Table) Update(record *primitive.Object) (*primitive.Object, error) {
+	if table.readOnly {
+		return nil, fmt.Errorf("Table: can't update record in table %q: %w", table.schema.Name, ErrReadOnlyTransaction)
+	}
+
 	index := table.getPrimaryIndex(record)
 
 	if index == nil {
@@ -200,13 +463,29 @@ func (table *Table) Update(record *vals.Object) (*vals.Object, error) {
 		return nil, err
 	}
 
-	if response.Value == nil {
+	if response.Value == nil || table.expired(response.Value) {
 		return nil, fmt.Errorf("Table: can't update record because it doesn't exist: %v", record)
 	}
 
-	oldRecord := table.decodePayload(response.Value)
-	newRecord := oldRecord.Merge(record)
-	newValue := table.encodePayload(newRecord)
+	oldRecord, err := table.decodeRecord(response.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	newRecord, err := table.runBeforeWriteHooks(WRITE_UPDATE, oldRecord.Merge(record), oldRecord)
+	if err != nil {
+		return nil, fmt.Errorf("Table: can't update record: %w", err)
+	}
+
+	if err := table.validateRecord(newRecord); err != nil {
+		return nil, fmt.Errorf("Table: can't update record with invalid values: %w", err)
+	}
+
+	if err := table.checkParents(newRecord, oldRecord); err != nil {
+		return nil, fmt.Errorf("Table: can't update record: %w", err)
+	}
+
+	newValue := table.encodeRow(newRecord, response.Value)
 
 	if _, err := table.kv.Set(&kv.SetRequest{Key: index, Value: newValue}); err != nil {
 		return nil, err
@@ -216,12 +495,20 @@ func (table *Table) Update(record *vals.Object) (*vals.Object, error) {
 		return nil, err
 	}
 
+	if err := table.updateExpiryIndex(index, newValue, response.Value); err != nil {
+		return nil, err
+	}
+
 	table.changeEvents = append(table.changeEvents, events.NewUpdateEntry(uint64(table.id), index, response.Value, newValue))
 
 	return oldRecord, nil
 }
 
-func (table *Table) Upsert(record *vals.Object) (*vals.Object, error) {
+func (table *Table) Upsert(record *primitive.Object) (*primitive.Object, error) {
+	if table.readOnly {
+		return nil, fmt.Errorf("Table: can't upsert record in table %q: %w", table.schema.Name, ErrReadOnlyTransaction)
+	}
+
 	index := table.getPrimaryIndex(record)
 
 	if index == nil {
@@ -234,7 +521,34 @@ func (table *Table) Upsert(record *vals.Object) (*vals.Object, error) {
 		return nil, err
 	}
 
-	newValue := table.encodePayload(record)
+	var oldRecord *primitive.Object
+
+	if response.Value == nil || table.expired(response.Value) {
+		record, err = table.runBeforeWriteHooks(WRITE_INSERT, record, nil)
+	} else if oldRecord, err = table.decodeRecord(response.Value); err == nil {
+		record, err = table.runBeforeWriteHooks(WRITE_UPDATE, record, oldRecord)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Table: can't upsert record: %w", err)
+	}
+
+	if err := table.validateRecord(record); err != nil {
+		return nil, fmt.Errorf("Table: can't upsert invalid record: %w", err)
+	}
+
+	if response.Value != nil && table.expired(response.Value) { // Expired row which isn't swept yet is replaced
+		if err := table.replaceExpiredRow(index, response.Value); err != nil {
+			return nil, err
+		}
+
+		response.Value = nil
+	}
+
+	if err := table.checkParents(record, oldRecord); err != nil {
+		return nil, fmt.Errorf("Table: can't upsert record: %w", err)
+	}
+
+	newValue := table.encodeRow(record, response.Value)
 
 	if _, err := table.kv.Set(&kv.SetRequest{Key: index, Value: newValue}); err != nil {
 		return nil, err
@@ -247,17 +561,21 @@ func (table *Table) Upsert(record *vals.Object) (*vals.Object, error) {
 
 		table.changeEvents = append(table.changeEvents, events.NewInsertEntry(uint64(table.id), index, newValue))
 	} else {
-		if err := table.updateSecondaryIndexes(record, table.decodePayload(response.Value)); err != nil {
+		if err := table.updateSecondaryIndexes(record, oldRecord); err != nil {
 			return nil, err
 		}
 
 		table.changeEvents = append(table.changeEvents, events.NewUpdateEntry(uint64(table.id), index, response.Value, newValue))
 	}
 
-	return table.decodePayload(response.Value), nil
+	if err := table.updateExpiryIndex(index, newValue, response.Value); err != nil {
+		return nil, err
+	}
+
+	return oldRecord, nil
 }
 
-func (table *Table) UpdateMany(query *vals.Object, update *vals.Object) ([]*vals.Object, error) {
+func (table *Table) UpdateMany(query *primitive.Object, update *primitive.Object) ([]*primitive.Object, error) {
 	primaryIndexChange := len(update.GetMany(table.schema.PrimaryIndex)) > 0
 
 	records, err := table.Find(query)
@@ -265,15 +583,30 @@ func (table *Table) UpdateMany(query *vals.Object, update *vals.Object) ([]*vals
 		return nil, err
 	}
 
-	for _, record := range records {
-		newRecord := record.Merge(update)
+	newRecords := make([]*primitive.Object, len(records))
+	for idx, record := range records { // Validate all records upfront, so invalid update doesn't leave the table partially updated
+		newRecords[idx] = record.Merge(update)
+
+		if err := table.validateRecord(newRecords[idx]); err != nil {
+			return nil, fmt.Errorf("Table: can't update records with invalid values: %w", err)
+		}
+
+		if primaryIndexChange { // Primary key of referenced record can't change, whatever delete action references have
+			if err := table.checkReferences(record, true); err != nil {
+				return nil, fmt.Errorf("Table: can't update primary key: %w", err)
+			}
+		}
+	}
+
+	for idx, record := range records {
+		newRecord := newRecords[idx]
 
 		if primaryIndexChange {
 			if _, err := table.Delete(record); err != nil {
 				return nil, err
 			}
 
-			if err := table.Insert(newRecord); err != nil {
+			if _, err := table.Insert(newRecord); err != nil {
 				return nil, err
 			}
 		} else {
@@ -306,21 +639,196 @@ func (table *Table) ChangeEvents() []TableEvent {
 	return table.changeEvents
 }
 
-func (table *Table) createSecondaryIndexes(record *vals.Object) error {
+func (table *Table) savepoint() tableSavepoint {
+	return tableSavepoint{
+		root:              table.Root(),
+		state:             table.state,
+		stateVersion:      table.stateVersion,
+		schema:            table.schema,
+		sequence:          table.sequence,
+		changeEventsCount: len(table.changeEvents),
+		readEventsCount:   len(table.readEvents),
+	}
+}
+
+func (table *Table) restoreSavepoint(savepoint tableSavepoint, pager *pager.Pager) {
+	table.kv = kv.NewKeyValue(savepoint.root, pager)
+	table.state = savepoint.state
+	table.stateVersion = savepoint.stateVersion
+	table.schema = savepoint.schema
+	table.sequence = savepoint.sequence
+	table.changeEvents = table.changeEvents[:savepoint.changeEventsCount]
+	table.readEvents = table.readEvents[:savepoint.readEventsCount]
+	if table.writes.events > savepoint.changeEventsCount {
+		table.writes = ownWrites{}
+	}
+}
+
+// nextKey returns primary key the schema key generator allocates next, without allocating it
+func (table *Table) nextKey() primitive.Primitive {
+	if table.schema.KeyGenerator == KEY_GENERATOR_UUID_V7 {
+		return primitive.NewUUIDv7()
+	}
+
+	return primitive.NewUint64(table.sequence + 1)
+}
+
+// allocateKey allocates key returned by nextKey. Sequence allocations are recorded as change events, so concurrent
+// transactions allocating from the same sequence conflict on commit.
+func (table *Table) allocateKey() {
+	if table.schema.KeyGenerator == KEY_GENERATOR_SEQUENCE {
+		table.sequence++
+		table.changeEvents = append(table.changeEvents, events.NewUpdateSequence(uint64(table.id), table.sequence-1, table.sequence))
+	}
+}
+
+// ownWrites holds values keys had before the transaction first wrote them. Reads are validated before changes of the
+// transaction are applied, so reads of its own writes are recorded with these values.
+type ownWrites struct {
+	events    int               // Number of change events collected
+	values    map[string][]byte // Values before the first write, nil for keys inserted by the transaction
+	truncated bool              // Truncate conflicts with any concurrent change, so reads after it aren't recorded
+}
+
+// collectWrites adds keys of change events appended since the last call to table writes
+func (table *Table) collectWrites() *ownWrites {
+	if table.writes.values == nil {
+		table.writes.values = make(map[string][]byte)
+	}
+
+	for _, changeEvent := range table.changeEvents[table.writes.events:] {
+		var key, value []byte
+		switch event := changeEvent.(type) {
+		case *events.InsertEntry:
+			key = event.Key
+		case *events.UpdateEntry:
+			key, value = event.Key, event.OldValue
+		case *events.DeleteEntry:
+			key, value = event.Key, event.Value
+		case *events.TruncateTable:
+			table.writes.truncated = true
+		}
+
+		if _, ok := table.writes.values[string(key)]; key != nil && !ok {
+			table.writes.values[string(key)] = value
+		}
+	}
+	table.writes.events = len(table.changeEvents)
+
+	return &table.writes
+}
+
+func (table *Table) primaryKey(record *primitive.Object) *primitive.Object {
+	key := primitive.NewObject()
+
+	for _, column := range table.schema.PrimaryIndex {
+		key.Set(column, record.Get(column))
+	}
+
+	return key
+}
+
+func (table *Table) recordRead(key []byte, value []byte) {
+	if !table.trackReads {
+		return
+	}
+
+	writes := table.collectWrites()
+	if writes.truncated {
+		return
+	}
+	if committed, ok := writes.values[string(key)]; ok {
+		value = committed
+	}
+
+	table.readEvents = append(table.readEvents, events.NewReadEntry(uint64(table.id), key, value))
+}
+
+// rangeRead accumulates entries visited by a scan, so the scanned range is recorded as a single read event
+type rangeRead struct {
+	entries  uint64
+	checksum uint64
+}
+
+func (read *rangeRead) visit(key []byte, value []byte) {
+	read.entries++
+	read.checksum += entryChecksum(key, value)
+}
+
+func (read *rangeRead) remove(key []byte, value []byte) {
+	read.entries--
+	read.checksum -= entryChecksum(key, value)
+}
+
+// recordRange records that the range from low up to high, excluding high, held the entries visited by read. Entries
+// written by the transaction are replaced with values they had before, so the range matches the committed state.
+func (table *Table) recordRange(low []byte, high []byte, read rangeRead) {
+	if !table.trackReads {
+		return
+	}
+
+	writes := table.collectWrites()
+	if writes.truncated {
+		return
+	}
+
+	for key, committed := range writes.values {
+		if bytes.Compare([]byte(key), low) < 0 || (high != nil && bytes.Compare([]byte(key), high) >= 0) {
+			continue
+		}
+
+		if response, err := table.kv.Get(&kv.GetRequest{Key: []byte(key)}); err == nil && response.Value != nil {
+			read.remove([]byte(key), response.Value)
+		}
+		if committed != nil {
+			read.visit([]byte(key), committed)
+		}
+	}
+
+	table.readEvents = append(table.readEvents, events.NewReadRange(uint64(table.id), low, high, read.entries, read.checksum))
+}
+
+// entryChecksum hashes the entry, checksums of entries are summed so they don't depend on the scan direction
+func entryChecksum(key []byte, value []byte) uint64 {
+	hash := fnv.New64a()
+	hash.Write(binary.AppendUvarint(nil, uint64(len(key))))
+	hash.Write(key)
+	hash.Write(value)
+
+	return hash.Sum64()
+}
+
+func (table *Table) createSecondaryIndexes(record *primitive.Object) error {
+	for indexNumber := range table.schema.SecondaryIndexes {
+		if secondaryIndex := table.getSecondaryIndex(record, indexNumber); secondaryIndex != nil {
+			included := table.encodeIncludedColumns(record, indexNumber)
+
+			if _, err := table.kv.Set(&kv.SetRequest{Key: secondaryIndex, Value: included}); err != nil {
+				return err
+			}
+
+			table.changeEvents = append(table.changeEvents, events.NewInsertEntry(uint64(table.id), secondaryIndex, included))
+		}
+	}
+
+	return nil
+}
+
+func (table *Table) deleteSecondaryIndexes(record *primitive.Object) error {
 	for indexNumber := range table.schema.SecondaryIndexes {
 		if secondaryIndex := table.getSecondaryIndex(record, indexNumber); secondaryIndex != nil {
-			if _, err := table.kv.Set(&kv.SetRequest{Key: secondaryIndex}); err != nil {
+			if _, err := table.kv.Delete(&kv.DeleteRequest{Key: secondaryIndex}); err != nil {
 				return err
 			}
 
-			table.changeEvents = append(table.changeEvents, events.NewInsertEntry(uint64(table.id), secondaryIndex, nil))
+			table.changeEvents = append(table.changeEvents, events.NewDeleteEntry(uint64(table.id), secondaryIndex, table.encodeIncludedColumns(record, indexNumber)))
 		}
 	}
 
 	return nil
 }
 
-func (table *Table) updateSecondaryIndexes(record *vals.Object, oldRecord *vals.Object) error {
+func (table *Table) updateSecondaryIndexes(record *primitive.Object, oldRecord *primitive.Object) error {
 	primaryIndex := table.getPrimaryIndex(record)
 	oldPrimaryIndex := table.getPrimaryIndex(oldRecord)
 
@@ -329,28 +837,52 @@ func (table *Table) updateSecondaryIndexes(record *vals.Object, oldRecord *vals.
 	for indexNumber := range table.schema.SecondaryIndexes {
 		secondaryIndex := table.getSecondaryIndex(record, indexNumber)
 		oldSecondaryIndex := table.getSecondaryIndex(oldRecord, indexNumber)
+		secondaryIndexChanged := slices.Compare(secondaryIndex, oldSecondaryIndex) != 0
+
+		included := table.encodeIncludedColumns(record, indexNumber)
+		oldIncluded := table.encodeIncludedColumns(oldRecord, indexNumber)
 
-		if oldSecondaryIndex != nil && slices.Compare(secondaryIndex, oldSecondaryIndex) != 0 {
+		if oldSecondaryIndex != nil && secondaryIndexChanged {
 			if _, err := table.kv.Delete(&kv.DeleteRequest{Key: oldSecondaryIndex}); err != nil {
 				return err
 			}
 
-			table.changeEvents = append(table.changeEvents, events.NewDeleteEntry(uint64(table.id), oldSecondaryIndex, nil))
+			table.changeEvents = append(table.changeEvents, events.NewDeleteEntry(uint64(table.id), oldSecondaryIndex, oldIncluded))
 		}
 
-		if secondaryIndex != nil && primaryIndexChanged {
-			if _, err := table.kv.Set(&kv.SetRequest{Key: secondaryIndex}); err != nil {
+		switch {
+		case secondaryIndex == nil:
+		case primaryIndexChanged || secondaryIndexChanged:
+			if _, err := table.kv.Set(&kv.SetRequest{Key: secondaryIndex, Value: included}); err != nil {
+				return err
+			}
+
+			table.changeEvents = append(table.changeEvents, events.NewInsertEntry(uint64(table.id), secondaryIndex, included))
+
+		case !bytes.Equal(included, oldIncluded):
+			if _, err := table.kv.Set(&kv.SetRequest{Key: secondaryIndex, Value: included}); err != nil {
 				return err
 			}
 
-			table.changeEvents = append(table.changeEvents, events.NewInsertEntry(uint64(table.id), secondaryIndex, nil))
+			table.changeEvents = append(table.changeEvents, events.NewUpdateEntry(uint64(table.id), secondaryIndex, oldIncluded, included))
 		}
 	}
 
 	return nil
 }
 
-func (table *Table) getPrimaryIndex(query *vals.Object) []byte {
+// encodeIncludedColumns encodes values of included columns of the secondary index in order, missing values are null
+func (table *Table) encodeIncludedColumns(record *primitive.Object, secondaryIndexNumber int) []byte {
+	var included []byte
+
+	for _, column := range table.schema.SecondaryIndexes[secondaryIndexNumber].Include {
+		included = append(included, codec.EncodeValue(record.Get(column))...)
+	}
+
+	return included
+}
+
+func (table *Table) getPrimaryIndex(query *primitive.Object) []byte {
 	vals := query.GetMany(table.schema.PrimaryIndex)
 
 	if table.containsEmptyValues(vals) {
@@ -360,7 +892,7 @@ func (table *Table) getPrimaryIndex(query *vals.Object) []byte {
 	return table.encodePrimaryIndex(vals)
 }
 
-func (table *Table) getSecondaryIndex(query *vals.Object, secondaryIndexNumber int) []byte {
+func (table *Table) getSecondaryIndex(query *primitive.Object, secondaryIndexNumber int) []byte {
 	primaryIndexVals := query.GetMany(table.schema.PrimaryIndex)
 	secondaryIndexVals := query.GetMany(table.schema.SecondaryIndexes[secondaryIndexNumber].Columns)
 
@@ -371,19 +903,29 @@ func (table *Table) getSecondaryIndex(query *vals.Object, secondaryIndexNumber i
 	return table.encodeSecondaryIndex(primaryIndexVals, secondaryIndexVals, secondaryIndexNumber)
 }
 
-func (table *Table) getPartialIndex(query *vals.Object) ([]byte, bool) {
+func (table *Table) getPartialIndex(query *primitive.Object) ([]byte, bool) {
 	primaryIndexVals := query.GetMany(table.schema.PrimaryIndex)
 
 	if !table.containsEmptyValues(primaryIndexVals) || len(table.schema.SecondaryIndexes) == 0 {
 		return table.encodePrimaryIndex(table.removeEmptyValues(primaryIndexVals)), true
 	}
 
+	statistics := table.statistics()
+
 	matchedSecondaryIndexNumber := 0
 	matchedSecondaryIndexVals := table.removeEmptyValues(query.GetMany(table.schema.SecondaryIndexes[matchedSecondaryIndexNumber].Columns))
 
 	for secondaryIndexNumber := 1; secondaryIndexNumber < len(table.schema.SecondaryIndexes); secondaryIndexNumber++ {
 		secondaryIndexVals := table.removeEmptyValues(query.GetMany(table.schema.SecondaryIndexes[secondaryIndexNumber].Columns))
 
+		if statistics != nil && len(secondaryIndexVals) > 0 && len(matchedSecondaryIndexVals) > 0 { // Analyzed tables pick the most selective index
+			if statistics.estimatePrefixEntries(secondaryIndexNumber, len(secondaryIndexVals)) < statistics.estimatePrefixEntries(matchedSecondaryIndexNumber, len(matchedSecondaryIndexVals)) {
+				matchedSecondaryIndexNumber = secondaryIndexNumber
+				matchedSecondaryIndexVals = secondaryIndexVals
+			}
+			continue
+		}
+
 		if len(secondaryIndexVals) > len(matchedSecondaryIndexVals) {
 			matchedSecondaryIndexNumber = secondaryIndexNumber
 			matchedSecondaryIndexVals = secondaryIndexVals
@@ -393,42 +935,221 @@ func (table *Table) getPartialIndex(query *vals.Object) ([]byte, bool) {
 	return table.encodeSecondaryIndex(table.removeEmptyValues(primaryIndexVals), matchedSecondaryIndexVals, matchedSecondaryIndexNumber), false
 }
 
-func (table *Table) encodePayload(record *vals.Object) []byte {
+// encodePayload encodes record with field directory of names and value sizes going before the values, so fields
+// can be decoded without decoding the others. Fields are sorted by name to keep the encoding stable.
+func (table *Table) encodePayload(record *primitive.Object) []byte {
 	if record == nil {
 		return nil
 	}
 
-	var encodedPayload []byte
+	fields := record.Values()
+	names := slices.Sorted(maps.Keys(fields))
+
+	encodedPayload := []byte{PAYLOAD_DIRECTORY_FORMAT}
+	encodedPayload = binary.AppendUvarint(encodedPayload, uint64(table.schema.Version))
+	encodedPayload = binary.AppendUvarint(encodedPayload, uint64(len(names)))
+
+	var encodedValues []byte
 
-	for fieldName, fieldValue := range record.Values() {
-		encodedPayload = append(encodedPayload, codec.EncodeValue(vals.NewString(fieldName))...)
-		encodedPayload = append(encodedPayload, codec.EncodeValue(fieldValue)...)
+	for _, name := range names {
+		encodedValue := codec.EncodeValue(fields[name])
+
+		encodedPayload = binary.AppendUvarint(encodedPayload, uint64(len(name)))
+		encodedPayload = append(encodedPayload, name...)
+		encodedPayload = binary.AppendUvarint(encodedPayload, uint64(len(encodedValue)))
+
+		encodedValues = append(encodedValues, encodedValue...)
 	}
 
-	return encodedPayload
+	return append(encodedPayload, encodedValues...)
+}
+
+func (table *Table) decodePayload(encodedPayload []byte) *primitive.Object {
+	record, _ := table.decodeRecord(encodedPayload)
+
+	return record
+}
+
+// decodeRecord decodes payload and upgrades it to the current schema version, nil is returned for empty payload
+func (table *Table) decodeRecord(encodedPayload []byte) (*primitive.Object, error) {
+	return table.decodeColumns(encodedPayload, nil)
 }
 
-func (table *Table) decodePayload(encodedPayload []byte) *vals.Object {
+// decodeColumns decodes fields holding the columns, all fields are decoded if columns is nil. Payloads without
+// field directory and rows of older schema versions, whose fields may have been renamed, are decoded whole.
+func (table *Table) decodeColumns(encodedPayload []byte, columns []string) (*primitive.Object, error) {
 	if len(encodedPayload) == 0 {
-		return nil
+		return nil, nil
 	}
 
-	record := vals.NewObject()
+	var record *primitive.Object
+	var version uint32
+	var err error
+
+	if encodedPayload[0] == PAYLOAD_DIRECTORY_FORMAT || encodedPayload[0] == PAYLOAD_EXPIRY_FORMAT {
+		record, version, err = table.decodeDirectoryPayload(encodedPayload, columns)
+	} else {
+		record, version, err = table.decodeFieldListPayload(encodedPayload)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if version < table.schema.Version {
+		table.upgradeRecord(record, version)
+	}
+
+	return record, nil
+}
+
+func (table *Table) decodeDirectoryPayload(encodedPayload []byte, columns []string) (*primitive.Object, uint32, error) {
+	offset := table.payloadHeaderSize(encodedPayload)
+	malformed := fmt.Errorf("Table %q: payload is malformed", table.schema.Name)
+
+	readUvarint := func() (uint64, bool) {
+		value, size := binary.Uvarint(encodedPayload[offset:])
+		offset += max(size, 0)
+
+		return value, size > 0
+	}
+
+	version, ok := readUvarint()
+	if !ok || version > math.MaxUint32 {
+		return nil, 0, malformed
+	}
+	if version < uint64(table.schema.Version) {
+		columns = nil
+	}
+
+	count, ok := readUvarint()
+	if !ok || count > uint64(len(encodedPayload)) {
+		return nil, 0, malformed
+	}
+
+	names := make([]string, count)
+	sizes := make([]uint64, count)
+
+	for idx := range names {
+		nameSize, ok := readUvarint()
+		if !ok || nameSize > uint64(len(encodedPayload)-offset) {
+			return nil, 0, malformed
+		}
+
+		names[idx] = string(encodedPayload[offset : offset+int(nameSize)])
+		offset += int(nameSize)
+
+		if sizes[idx], ok = readUvarint(); !ok {
+			return nil, 0, malformed
+		}
+	}
+
+	record := primitive.NewObject()
+
+	for idx, name := range names {
+		if sizes[idx] == 0 || sizes[idx] > uint64(len(encodedPayload)-offset) {
+			return nil, 0, malformed
+		}
+
+		encodedValue := encodedPayload[offset : offset+int(sizes[idx])]
+		offset += int(sizes[idx])
+
+		if columns != nil && !slices.ContainsFunc(columns, func(column string) bool { return holdsColumn(name, column) }) {
+			continue
+		}
+
+		var value primitive.Primitive
+		var err error
+
+		if value, _, err = codec.DecodeValue(encodedValue); err != nil {
+			return nil, 0, fmt.Errorf("Table %q: couldn't decode field %q: %w", table.schema.Name, name, err)
+		}
+
+		record.Set(name, value)
+	}
+
+	return record, uint32(version), nil
+}
+
+// decodeFieldListPayload decodes payload of field name and value pairs written before field directory was added
+func (table *Table) decodeFieldListPayload(encodedPayload []byte) (*primitive.Object, uint32, error) {
+	record := primitive.NewObject()
+	version := uint32(0)
 
 	for len(encodedPayload) > 0 {
-		fieldName, size := codec.DecodeValue(encodedPayload)
+		var fieldName, fieldValue primitive.Primitive
+		var size int
+		var err error
+
+		if fieldName, size, err = codec.DecodeValue(encodedPayload); err != nil {
+			return nil, 0, fmt.Errorf("Table %q: couldn't decode field name: %w", table.schema.Name, err)
+		}
 		encodedPayload = encodedPayload[size:]
 
-		fieldValue, size := codec.DecodeValue(encodedPayload)
+		name, ok := fieldName.(*primitive.String)
+		if !ok || len(encodedPayload) == 0 {
+			return nil, 0, fmt.Errorf("Table %q: payload is malformed", table.schema.Name)
+		}
+
+		if fieldValue, size, err = codec.DecodeValue(encodedPayload); err != nil {
+			return nil, 0, fmt.Errorf("Table %q: couldn't decode field %q: %w", table.schema.Name, name.Value(), err)
+		}
 		encodedPayload = encodedPayload[size:]
 
-		record.Set((fieldName.(*vals.StringValue).Value()), fieldValue)
+		if name.Value() == ROW_VERSION_FIELD {
+			if rowVersion, ok := fieldValue.(*primitive.Uint32); ok {
+				version = rowVersion.Value()
+			}
+			continue
+		}
+
+		record.Set(name.Value(), fieldValue)
 	}
 
-	return record
+	return record, version, nil
 }
 
-func (table *Table) encodePrimaryIndex(values []vals.Value) []byte {
+// payloadVersion returns schema version the row was written with, rows written before versioning have version 0
+func (table *Table) payloadVersion(encodedPayload []byte) (uint32, error) {
+	if len(encodedPayload) == 0 {
+		return 0, nil
+	}
+
+	if encodedPayload[0] == PAYLOAD_DIRECTORY_FORMAT || encodedPayload[0] == PAYLOAD_EXPIRY_FORMAT {
+		version, size := binary.Uvarint(encodedPayload[table.payloadHeaderSize(encodedPayload):])
+		if size <= 0 || version > math.MaxUint32 {
+			return 0, fmt.Errorf("Table %q: payload is malformed", table.schema.Name)
+		}
+
+		return uint32(version), nil
+	}
+
+	fieldName, size, err := codec.DecodeValue(encodedPayload)
+	if err != nil {
+		return 0, fmt.Errorf("Table %q: couldn't decode field name: %w", table.schema.Name, err)
+	}
+
+	name, ok := fieldName.(*primitive.String)
+	if !ok {
+		return 0, fmt.Errorf("Table %q: payload is malformed", table.schema.Name)
+	}
+	if name.Value() != ROW_VERSION_FIELD {
+		return 0, nil
+	}
+
+	fieldValue, _, err := codec.DecodeValue(encodedPayload[size:])
+	if err != nil {
+		return 0, fmt.Errorf("Table %q: couldn't decode field %q: %w", table.schema.Name, ROW_VERSION_FIELD, err)
+	}
+
+	version, ok := fieldValue.(*primitive.Uint32)
+	if !ok {
+		return 0, fmt.Errorf("Table %q: payload is malformed", table.schema.Name)
+	}
+
+	return version.Value(), nil
+}
+
+func (table *Table) encodePrimaryIndex(values []primitive.Primitive) []byte {
 	if len(values) == 0 {
 		return nil
 	}
@@ -444,7 +1165,7 @@ func (table *Table) encodePrimaryIndex(values []vals.Value) []byte {
 	return primaryIndex
 }
 
-func (table *Table) encodeSecondaryIndex(primaryIndexVals []vals.Value, secondaryIndexVals []vals.Value, secondaryIndexNumber int) []byte {
+func (table *Table) encodeSecondaryIndex(primaryIndexVals []primitive.Primitive, secondaryIndexVals []primitive.Primitive, secondaryIndexNumber int) []byte {
 	if len(secondaryIndexVals) == 0 {
 		return nil
 	}
@@ -464,7 +1185,7 @@ func (table *Table) encodeSecondaryIndex(primaryIndexVals []vals.Value, secondar
 	return secondaryIndex
 }
 
-func (table *Table) decodeSecondaryIndex(encodedIndex []byte) (primaryIndexVals []vals.Value, secondaryIndexVals []vals.Value, secondaryIndexNumber int) {
+func (table *Table) decodeSecondaryIndex(encodedIndex []byte) (primaryIndexVals []primitive.Primitive, secondaryIndexVals []primitive.Primitive, secondaryIndexNumber int) {
 	if table.matchPrimaryIndex(encodedIndex) {
 		return
 	}
@@ -474,22 +1195,26 @@ func (table *Table) decodeSecondaryIndex(encodedIndex []byte) (primaryIndexVals
 
 	encodedIndex = encodedIndex[INDEX_ID_SIZE:]
 
-	for _, columnName := range table.schema.SecondaryIndexes[secondaryIndexNumber].Columns {
-		columnValue := vals.New(table.schema.IndexedColumns[columnName])
-		size := columnValue.Parse(encodedIndex[1:]) // skip type byte
+	for range table.schema.SecondaryIndexes[secondaryIndexNumber].Columns {
+		columnValue, size, err := codec.DecodeValue(encodedIndex)
+		if err != nil {
+			return nil, nil, secondaryIndexNumber
+		}
 
 		secondaryIndexVals = append(secondaryIndexVals, columnValue)
 
-		encodedIndex = encodedIndex[1+size:]
+		encodedIndex = encodedIndex[size:]
 	}
 
-	for _, columnName := range table.schema.PrimaryIndex {
-		columnValue := vals.New(table.schema.IndexedColumns[columnName])
-		size := columnValue.Parse(encodedIndex[1:]) // skip type byte
+	for range table.schema.PrimaryIndex {
+		columnValue, size, err := codec.DecodeValue(encodedIndex)
+		if err != nil {
+			return nil, nil, secondaryIndexNumber
+		}
 
 		primaryIndexVals = append(primaryIndexVals, columnValue)
 
-		encodedIndex = encodedIndex[1+size:]
+		encodedIndex = encodedIndex[size:]
 	}
 
 	return
@@ -513,12 +1238,12 @@ func (table *Table) matchIndexes(encodedIndex []byte, partialEncodedIndex []byte
 	return bytes.Equal(encodedIndex[0:len(partialEncodedIndex)], partialEncodedIndex)
 }
 
-func (table *Table) containsEmptyValues(values []vals.Value) bool {
-	return slices.IndexFunc(values, func(value vals.Value) bool { return value.Empty() }) >= 0
+func (table *Table) containsEmptyValues(values []primitive.Primitive) bool {
+	return slices.IndexFunc(values, func(value primitive.Primitive) bool { return value.Empty() }) >= 0
 }
 
-func (table *Table) removeEmptyValues(values []vals.Value) []vals.Value {
-	var nonEmptyValues []vals.Value
+func (table *Table) removeEmptyValues(values []primitive.Primitive) []primitive.Primitive {
+	var nonEmptyValues []primitive.Primitive
 
 	for _, value := range values {
 		if value.Empty() {
@@ -540,5 +1265,54 @@ func (table *Table) validateTableSchema() error {
 		return fmt.Errorf("Table: schema must have a primary index")
 	}
 
+	if err := table.validateKeyGenerator(); err != nil {
+		return err
+	}
+
+	if err := table.validateColumns(); err != nil {
+		return err
+	}
+
+	if err := table.validateForeignKeys(); err != nil {
+		return err
+	}
+
+	if err := table.validateTTL(); err != nil {
+		return err
+	}
+
+	for _, secondaryIndex := range table.schema.SecondaryIndexes {
+		for _, column := range secondaryIndex.Include {
+			if column == "" || slices.Contains(secondaryIndex.Columns, column) || slices.Contains(table.schema.PrimaryIndex, column) {
+				return fmt.Errorf("Table: included column %q of secondary index %v must be a named column outside of the index key", column, secondaryIndex.Columns)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (table *Table) validateKeyGenerator() error {
+	var keyType primitive.PrimitiveType
+
+	switch table.schema.KeyGenerator {
+	case KEY_GENERATOR_NONE:
+		return nil
+	case KEY_GENERATOR_SEQUENCE:
+		keyType = primitive.TYPE_UINT64
+	case KEY_GENERATOR_UUID_V7:
+		keyType = primitive.TYPE_UUID
+	default:
+		return fmt.Errorf("Table: unknown key generator %d", table.schema.KeyGenerator)
+	}
+
+	if len(table.schema.PrimaryIndex) != 1 {
+		return fmt.Errorf("Table: key generator requires primary index with a single column")
+	}
+
+	if columnType, ok := table.schema.IndexedColumns[table.schema.PrimaryIndex[0]]; !ok || columnType != keyType {
+		return fmt.Errorf("Table: key generator requires primary index column %q of type %d", table.schema.PrimaryIndex[0], keyType)
+	}
+
 	return nil
 }
@@ -1,9 +1,11 @@
 package db
 
 import (
+	"distributed-storage/internal/codec"
+	"distributed-storage/internal/kv"
 	"distributed-storage/internal/pager"
+	"distributed-storage/internal/primitive"
 	"distributed-storage/internal/store"
-	"distributed-storage/internal/vals"
 	"testing"
 )
 
@@ -18,8 +20,8 @@ func basicSchema() *TableSchema {
 	return &TableSchema{
 		Name:         "users",
 		PrimaryIndex: []string{"id"},
-		IndexedColumns: map[string]vals.ValueType{
-			"id": vals.TYPE_UINT64,
+		IndexedColumns: map[string]primitive.PrimitiveType{
+			"id": primitive.TYPE_UINT64,
 		},
 	}
 }
@@ -29,9 +31,9 @@ func schemaWithSecondaryIndex() *TableSchema {
 		Name:             "users",
 		PrimaryIndex:     []string{"id"},
 		SecondaryIndexes: []SecondaryIndex{{Columns: []string{"email"}}},
-		IndexedColumns: map[string]vals.ValueType{
-			"id":    vals.TYPE_UINT64,
-			"email": vals.TYPE_STRING,
+		IndexedColumns: map[string]primitive.PrimitiveType{
+			"id":    primitive.TYPE_UINT64,
+			"email": primitive.TYPE_STRING,
 		},
 	}
 }
@@ -54,17 +56,17 @@ func newTableWithSecondaryIndex(t *testing.T) *Table {
 	return table
 }
 
-func userRecord(id uint64, name string) *vals.Object {
-	return vals.NewObject().
-		Set("id", vals.NewUint64(id)).
-		Set("name", vals.NewString(name))
+func userRecord(id uint64, name string) *primitive.Object {
+	return primitive.NewObject().
+		Set("id", primitive.NewUint64(id)).
+		Set("name", primitive.NewString(name))
 }
 
-func userRecordWithEmail(id uint64, name, email string) *vals.Object {
-	return vals.NewObject().
-		Set("id", vals.NewUint64(id)).
-		Set("name", vals.NewString(name)).
-		Set("email", vals.NewString(email))
+func userRecordWithEmail(id uint64, name, email string) *primitive.Object {
+	return primitive.NewObject().
+		Set("id", primitive.NewUint64(id)).
+		Set("name", primitive.NewString(name)).
+		Set("email", primitive.NewString(email))
 }
 
 // --- newTable ---
@@ -79,7 +81,7 @@ func TestNewTable_ValidSchema(t *testing.T) {
 func TestNewTable_MissingName(t *testing.T) {
 	schema := &TableSchema{
 		PrimaryIndex:   []string{"id"},
-		IndexedColumns: map[string]vals.ValueType{"id": vals.TYPE_UINT64},
+		IndexedColumns: map[string]primitive.PrimitiveType{"id": primitive.TYPE_UINT64},
 	}
 	_, err := newTable(TableID(1), pager.NULL_PAGE, newTestPager(), schema)
 	if err == nil {
@@ -90,7 +92,7 @@ func TestNewTable_MissingName(t *testing.T) {
 func TestNewTable_MissingPrimaryIndex(t *testing.T) {
 	schema := &TableSchema{
 		Name:           "users",
-		IndexedColumns: map[string]vals.ValueType{},
+		IndexedColumns: map[string]primitive.PrimitiveType{},
 	}
 	_, err := newTable(TableID(1), pager.NULL_PAGE, newTestPager(), schema)
 	if err == nil {
@@ -133,7 +135,7 @@ func TestTable_Root_NullBeforeInsert(t *testing.T) {
 
 func TestTable_Root_NonNullAfterInsert(t *testing.T) {
 	table := newTestTable(t)
-	if err := table.Insert(userRecord(1, "Alice")); err != nil {
+	if _, err := table.Insert(userRecord(1, "Alice")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
 	if table.Root() == pager.NULL_PAGE {
@@ -145,38 +147,149 @@ func TestTable_Root_NonNullAfterInsert(t *testing.T) {
 
 func TestTable_Insert(t *testing.T) {
 	table := newTestTable(t)
-	if err := table.Insert(userRecord(1, "Alice")); err != nil {
+	if _, err := table.Insert(userRecord(1, "Alice")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
 }
 
 func TestTable_Insert_Duplicate(t *testing.T) {
 	table := newTestTable(t)
-	if err := table.Insert(userRecord(1, "Alice")); err != nil {
+	if _, err := table.Insert(userRecord(1, "Alice")); err != nil {
 		t.Fatalf("first Insert: %v", err)
 	}
-	if err := table.Insert(userRecord(1, "Bob")); err == nil {
+	if _, err := table.Insert(userRecord(1, "Bob")); err == nil {
 		t.Fatal("expected error on duplicate insert")
 	}
 }
 
 func TestTable_Insert_MissingPrimaryKey(t *testing.T) {
 	table := newTestTable(t)
-	noID := vals.NewObject().Set("name", vals.NewString("Alice"))
-	if err := table.Insert(noID); err == nil {
+	noID := primitive.NewObject().Set("name", primitive.NewString("Alice"))
+	if _, err := table.Insert(noID); err == nil {
 		t.Fatal("expected error on insert without primary key")
 	}
 }
 
+func sequenceSchema() *TableSchema {
+	return &TableSchema{
+		Name:           "invoices",
+		PrimaryIndex:   []string{"id"},
+		IndexedColumns: map[string]primitive.PrimitiveType{"id": primitive.TYPE_UINT64},
+		KeyGenerator:   KEY_GENERATOR_SEQUENCE,
+	}
+}
+
+func TestTable_Insert_GeneratesSequenceKey(t *testing.T) {
+	table, err := newTable(TableID(5), pager.NULL_PAGE, newTestPager(), sequenceSchema())
+	if err != nil {
+		t.Fatalf("newTable: %v", err)
+	}
+
+	for expected := uint64(1); expected <= 3; expected++ {
+		record := primitive.NewObject().Set("amount", primitive.NewUint32(10))
+		key, err := table.Insert(record)
+		if err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+		if key.GetUint64("id") != expected {
+			t.Errorf("expected generated key %d, got %d", expected, key.GetUint64("id"))
+		}
+		if record.GetUint64("id") != expected {
+			t.Errorf("expected record to be filled with key %d, got %v", expected, record.Get("id"))
+		}
+	}
+}
+
+func TestTable_Insert_KeepsExplicitKey(t *testing.T) {
+	table, _ := newTable(TableID(5), pager.NULL_PAGE, newTestPager(), sequenceSchema())
+
+	key, err := table.Insert(primitive.NewObject().Set("id", primitive.NewUint64(100)))
+	if err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if key.GetUint64("id") != 100 || table.sequence != 0 {
+		t.Errorf("expected explicit key to be kept without advancing sequence, got key %d and sequence %d", key.GetUint64("id"), table.sequence)
+	}
+}
+
+func TestTable_Insert_AllocatesSequenceKeyOfInsertedRecordsOnly(t *testing.T) {
+	schema := sequenceSchema()
+	schema.Columns = []Column{{Name: "id", Type: primitive.TYPE_UINT64}, {Name: "amount", Type: primitive.TYPE_UINT32}}
+	table, err := newTable(TableID(5), pager.NULL_PAGE, newTestPager(), schema)
+	if err != nil {
+		t.Fatalf("newTable: %v", err)
+	}
+
+	invalid := primitive.NewObject().Set("amount", primitive.NewString("ten"))
+	if _, err := table.Insert(invalid); err == nil {
+		t.Fatal("expected invalid record to be rejected")
+	}
+	if table.sequence != 0 || len(table.changeEvents) != 0 || invalid.Has("id") {
+		t.Errorf("expected rejected record not to allocate key, got sequence %d and record %s", table.sequence, invalid)
+	}
+
+	table.Insert(primitive.NewObject().Set("id", primitive.NewUint64(1)).Set("amount", primitive.NewUint32(5)))
+	record := primitive.NewObject().Set("amount", primitive.NewUint32(10))
+	if _, err := table.Insert(record); err == nil {
+		t.Fatal("expected generated key taken by explicit key to be rejected")
+	}
+
+	key, err := table.Insert(record)
+	if err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if key.GetUint64("id") != 2 {
+		t.Errorf("expected taken key to be skipped, got key %d", key.GetUint64("id"))
+	}
+}
+
+func TestTable_Insert_GeneratesUUIDv7Key(t *testing.T) {
+	schema := &TableSchema{
+		Name:           "events",
+		PrimaryIndex:   []string{"id"},
+		IndexedColumns: map[string]primitive.PrimitiveType{"id": primitive.TYPE_UUID},
+		KeyGenerator:   KEY_GENERATOR_UUID_V7,
+	}
+	table, err := newTable(TableID(6), pager.NULL_PAGE, newTestPager(), schema)
+	if err != nil {
+		t.Fatalf("newTable: %v", err)
+	}
+
+	first, _ := table.Insert(primitive.NewObject().Set("kind", primitive.NewString("created")))
+	second, _ := table.Insert(primitive.NewObject().Set("kind", primitive.NewString("updated")))
+
+	records, _ := table.GetAll()
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if !records[0].Get("id").Equal(first.Get("id")) || !records[1].Get("id").Equal(second.Get("id")) {
+		t.Error("expected records to be ordered by UUIDv7 generation time")
+	}
+}
+
+func TestNewTable_InvalidKeyGenerator(t *testing.T) {
+	schemas := []*TableSchema{
+		{Name: "a", PrimaryIndex: []string{"id"}, IndexedColumns: map[string]primitive.PrimitiveType{"id": primitive.TYPE_STRING}, KeyGenerator: KEY_GENERATOR_SEQUENCE},
+		{Name: "b", PrimaryIndex: []string{"id", "n"}, IndexedColumns: map[string]primitive.PrimitiveType{"id": primitive.TYPE_UUID, "n": primitive.TYPE_UINT32}, KeyGenerator: KEY_GENERATOR_UUID_V7},
+		{Name: "c", PrimaryIndex: []string{"id"}, IndexedColumns: map[string]primitive.PrimitiveType{"id": primitive.TYPE_UINT64}, KeyGenerator: KeyGenerator(42)},
+	}
+
+	for _, schema := range schemas {
+		if _, err := newTable(TableID(7), pager.NULL_PAGE, newTestPager(), schema); err == nil {
+			t.Errorf("expected error for schema %q", schema.Name)
+		}
+	}
+}
+
 // --- Get ---
 
 func TestTable_Get_Existing(t *testing.T) {
 	table := newTestTable(t)
-	if err := table.Insert(userRecord(1, "Alice")); err != nil {
+	if _, err := table.Insert(userRecord(1, "Alice")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
 
-	got, err := table.Get(vals.NewObject().Set("id", vals.NewUint64(1)))
+	got, err := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(1)))
 	if err != nil {
 		t.Fatalf("Get: %v", err)
 	}
@@ -194,7 +307,7 @@ func TestTable_Get_Existing(t *testing.T) {
 func TestTable_Get_NonExisting(t *testing.T) {
 	table := newTestTable(t)
 
-	got, err := table.Get(vals.NewObject().Set("id", vals.NewUint64(99)))
+	got, err := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(99)))
 	if err != nil {
 		t.Fatalf("Get: %v", err)
 	}
@@ -206,7 +319,7 @@ func TestTable_Get_NonExisting(t *testing.T) {
 func TestTable_Get_MissingPrimaryKey(t *testing.T) {
 	table := newTestTable(t)
 
-	_, err := table.Get(vals.NewObject().Set("name", vals.NewString("Alice")))
+	_, err := table.Get(primitive.NewObject().Set("name", primitive.NewString("Alice")))
 	if err == nil {
 		t.Fatal("expected error when primary key is missing from query")
 	}
@@ -216,7 +329,7 @@ func TestTable_Get_MissingPrimaryKey(t *testing.T) {
 
 func TestTable_GetAll_EmptyTable(t *testing.T) {
 	table := newTestTable(t)
-	records := table.GetAll()
+	records, _ := table.GetAll()
 	if len(records) != 0 {
 		t.Errorf("expected 0 records, got %d", len(records))
 	}
@@ -225,29 +338,48 @@ func TestTable_GetAll_EmptyTable(t *testing.T) {
 func TestTable_GetAll(t *testing.T) {
 	table := newTestTable(t)
 	for i := uint64(1); i <= 3; i++ {
-		if err := table.Insert(userRecord(i, "user")); err != nil {
+		if _, err := table.Insert(userRecord(i, "user")); err != nil {
 			t.Fatalf("Insert id=%d: %v", i, err)
 		}
 	}
 
-	records := table.GetAll()
+	records, _ := table.GetAll()
 	if len(records) != 3 {
 		t.Errorf("expected 3 records, got %d", len(records))
 	}
 }
 
+func TestTable_MalformedPayload_ReturnsDecodeErrors(t *testing.T) {
+	table := newTestTable(t)
+	table.Insert(userRecord(1, "user"))
+	table.kv.Set(&kv.SetRequest{Key: table.getPrimaryIndex(userRecord(2, "")), Value: codec.EncodeValue(primitive.NewUint32(1))})
+
+	if _, err := table.GetAll(); err == nil {
+		t.Error("GetAll: expected decode error")
+	}
+	if _, err := table.Update(userRecord(2, "updated")); err == nil {
+		t.Error("Update: expected decode error")
+	}
+	if _, err := table.Upsert(userRecord(2, "upserted")); err == nil {
+		t.Error("Upsert: expected decode error")
+	}
+	if _, err := table.Delete(userRecord(2, "")); err == nil {
+		t.Error("Delete: expected decode error")
+	}
+}
+
 // --- Find ---
 
 func TestTable_Find_ByPrimaryKey(t *testing.T) {
 	table := newTestTable(t)
-	if err := table.Insert(userRecord(1, "Alice")); err != nil {
+	if _, err := table.Insert(userRecord(1, "Alice")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
-	if err := table.Insert(userRecord(2, "Bob")); err != nil {
+	if _, err := table.Insert(userRecord(2, "Bob")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
 
-	results, err := table.Find(vals.NewObject().Set("id", vals.NewUint64(1)))
+	results, err := table.Find(primitive.NewObject().Set("id", primitive.NewUint64(1)))
 	if err != nil {
 		t.Fatalf("Find: %v", err)
 	}
@@ -261,11 +393,11 @@ func TestTable_Find_ByPrimaryKey(t *testing.T) {
 
 func TestTable_Find_NoMatch(t *testing.T) {
 	table := newTestTable(t)
-	if err := table.Insert(userRecord(1, "Alice")); err != nil {
+	if _, err := table.Insert(userRecord(1, "Alice")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
 
-	results, err := table.Find(vals.NewObject().Set("id", vals.NewUint64(99)))
+	results, err := table.Find(primitive.NewObject().Set("id", primitive.NewUint64(99)))
 	if err != nil {
 		t.Fatalf("Find: %v", err)
 	}
@@ -276,14 +408,14 @@ func TestTable_Find_NoMatch(t *testing.T) {
 
 func TestTable_Find_BySecondaryIndex(t *testing.T) {
 	table := newTableWithSecondaryIndex(t)
-	if err := table.Insert(userRecordWithEmail(1, "Alice", "alice@example.com")); err != nil {
+	if _, err := table.Insert(userRecordWithEmail(1, "Alice", "alice@example.com")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
-	if err := table.Insert(userRecordWithEmail(2, "Bob", "bob@example.com")); err != nil {
+	if _, err := table.Insert(userRecordWithEmail(2, "Bob", "bob@example.com")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
 
-	results, err := table.Find(vals.NewObject().Set("email", vals.NewString("alice@example.com")))
+	results, err := table.Find(primitive.NewObject().Set("email", primitive.NewString("alice@example.com")))
 	if err != nil {
 		t.Fatalf("Find: %v", err)
 	}
@@ -295,18 +427,142 @@ func TestTable_Find_BySecondaryIndex(t *testing.T) {
 	}
 }
 
+func TestTable_Find_BySecondaryIndex_DanglingEntry(t *testing.T) {
+	table := newTableWithSecondaryIndex(t)
+	table.Insert(userRecordWithEmail(1, "Alice", "alice@example.com"))
+
+	// Secondary index entry of a record which doesn't exist
+	table.kv.Set(&kv.SetRequest{Key: table.getSecondaryIndex(userRecordWithEmail(2, "Lost", "alice@example.com"), 0)})
+
+	if _, err := table.Find(primitive.NewObject().Set("email", primitive.NewString("alice@example.com"))); err == nil {
+		t.Error("expected error for secondary index entry of missing record")
+	}
+}
+
+func TestTable_Find_BySecondaryIndex_FetchesRecordsInBatches(t *testing.T) {
+	table, err := newTable(TableID(3), pager.NULL_PAGE, newTestPager(), ordersSchema())
+	if err != nil {
+		t.Fatalf("newTable: %v", err)
+	}
+
+	total := PRIMARY_LOOKUP_BATCH_SIZE + PRIMARY_LOOKUP_PREFETCH_SIZE/2 // Last batch is fetched without prefetch
+	for id := 1; id <= total; id++ {
+		status := "new"
+		if id%3 == 0 {
+			status = "paid"
+		}
+		if _, err := table.Insert(orderRecord(uint64(id), status, uint32(total-id), "alice")); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	results, err := table.Find(primitive.NewObject().Set("status", primitive.NewString("new")))
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	if expected := total - total/3; len(results) != expected {
+		t.Fatalf("expected %d results, got %d", expected, len(results))
+	}
+	for idx, record := range results {
+		if record.GetString("status") != "new" || idx > 0 && results[idx-1].GetUint32("amount") >= record.GetUint32("amount") {
+			t.Fatalf("expected records in order of secondary index, got %s after %s", record, results[max(idx-1, 0)])
+		}
+	}
+}
+
+func TestTable_Find_ByBoolFloatAndBytesIndexes(t *testing.T) {
+	schema := &TableSchema{
+		Name:             "measurements",
+		PrimaryIndex:     []string{"sensor", "value"},
+		SecondaryIndexes: []SecondaryIndex{{Columns: []string{"valid"}}},
+		IndexedColumns: map[string]primitive.PrimitiveType{
+			"sensor": primitive.TYPE_BYTES,
+			"value":  primitive.TYPE_FLOAT64,
+			"valid":  primitive.TYPE_BOOL,
+		},
+	}
+	table, err := newTable(TableID(3), pager.NULL_PAGE, newTestPager(), schema)
+	if err != nil {
+		t.Fatalf("newTable: %v", err)
+	}
+
+	for idx, value := range []float64{-1.5, 0, 2.25} {
+		record := primitive.NewObject().
+			Set("sensor", primitive.NewBytes([]byte{0x00, byte(idx)})).
+			Set("value", primitive.NewFloat64(value)).
+			Set("valid", primitive.NewBool(value >= 0))
+		if _, err := table.Insert(record); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	results, err := table.Find(primitive.NewObject().Set("valid", primitive.NewBool(true)))
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+
+	record, err := table.Get(primitive.NewObject().Set("sensor", primitive.NewBytes([]byte{0x00, 0x00})).Set("value", primitive.NewFloat64(-1.5)))
+	if err != nil || record == nil {
+		t.Fatalf("expected record by bytes and float primary key, got %v, %v", record, err)
+	}
+	if record.GetBool("valid") {
+		t.Error("expected valid=false")
+	}
+}
+
+func TestTable_Find_ByNestedIndexColumn(t *testing.T) {
+	schema := &TableSchema{
+		Name:             "orders",
+		PrimaryIndex:     []string{"id"},
+		SecondaryIndexes: []SecondaryIndex{{Columns: []string{"address.city"}}},
+		IndexedColumns: map[string]primitive.PrimitiveType{
+			"id":           primitive.TYPE_UINT64,
+			"address.city": primitive.TYPE_STRING,
+		},
+	}
+	table, err := newTable(TableID(4), pager.NULL_PAGE, newTestPager(), schema)
+	if err != nil {
+		t.Fatalf("newTable: %v", err)
+	}
+
+	for id, city := range []string{"Berlin", "Paris", "Berlin"} {
+		record := primitive.NewObject().
+			Set("id", primitive.NewUint64(uint64(id))).
+			Set("address", primitive.NewObject().Set("city", primitive.NewString(city))).
+			Set("items", primitive.NewArray(primitive.NewObject().Set("price", primitive.NewUint32(10))))
+		if _, err := table.Insert(record); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	results, err := table.Find(primitive.NewObject().Set("address.city", primitive.NewString("Berlin")))
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if !results[0].Get("items.0.price").Equal(primitive.NewUint32(10)) {
+		t.Errorf("expected nested array to be stored, got %v", results[0].Get("items.0.price"))
+	}
+}
+
 // --- Delete ---
 
 func TestTable_Delete_Existing(t *testing.T) {
 	table := newTestTable(t)
-	if err := table.Insert(userRecord(1, "Alice")); err != nil {
+	if _, err := table.Insert(userRecord(1, "Alice")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
-	if err := table.Insert(userRecord(2, "Bob")); err != nil {
+	if _, err := table.Insert(userRecord(2, "Bob")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
 
-	old, err := table.Delete(vals.NewObject().Set("id", vals.NewUint64(1)))
+	old, err := table.Delete(primitive.NewObject().Set("id", primitive.NewUint64(1)))
 	if err != nil {
 		t.Fatalf("Delete: %v", err)
 	}
@@ -318,7 +574,7 @@ func TestTable_Delete_Existing(t *testing.T) {
 	}
 
 	// Verify the remaining record is still accessible.
-	got, err := table.Get(vals.NewObject().Set("id", vals.NewUint64(2)))
+	got, err := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(2)))
 	if err != nil {
 		t.Fatalf("Get after Delete: %v", err)
 	}
@@ -327,16 +583,29 @@ func TestTable_Delete_Existing(t *testing.T) {
 	}
 }
 
+func TestTable_Delete_RemovesSecondaryIndexEntries(t *testing.T) {
+	table := newTableWithSecondaryIndex(t)
+	table.Insert(userRecordWithEmail(1, "Alice", "alice@example.com"))
+
+	if _, err := table.Delete(primitive.NewObject().Set("id", primitive.NewUint64(1))); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if results, err := table.FindWhere(Eq("email", primitive.NewString("alice@example.com"))); err != nil || len(results) != 0 {
+		t.Errorf("expected no dangling secondary index entry, got %d results, %v", len(results), err)
+	}
+}
+
 func TestTable_Delete_LastRecord(t *testing.T) {
 	table := newTestTable(t)
-	if err := table.Insert(userRecord(1, "Alice")); err != nil {
+	if _, err := table.Insert(userRecord(1, "Alice")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
-	if _, err := table.Delete(vals.NewObject().Set("id", vals.NewUint64(1))); err != nil {
+	if _, err := table.Delete(primitive.NewObject().Set("id", primitive.NewUint64(1))); err != nil {
 		t.Fatalf("Delete: %v", err)
 	}
 
-	got, err := table.Get(vals.NewObject().Set("id", vals.NewUint64(1)))
+	got, err := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(1)))
 	if err != nil {
 		t.Fatalf("Get after last delete: %v", err)
 	}
@@ -348,7 +617,7 @@ func TestTable_Delete_LastRecord(t *testing.T) {
 func TestTable_Delete_NonExisting(t *testing.T) {
 	table := newTestTable(t)
 
-	old, err := table.Delete(vals.NewObject().Set("id", vals.NewUint64(99)))
+	old, err := table.Delete(primitive.NewObject().Set("id", primitive.NewUint64(99)))
 	if err != nil {
 		t.Fatalf("Delete: %v", err)
 	}
@@ -360,7 +629,7 @@ func TestTable_Delete_NonExisting(t *testing.T) {
 func TestTable_Delete_MissingPrimaryKey(t *testing.T) {
 	table := newTestTable(t)
 
-	_, err := table.Delete(vals.NewObject().Set("name", vals.NewString("Alice")))
+	_, err := table.Delete(primitive.NewObject().Set("name", primitive.NewString("Alice")))
 	if err == nil {
 		t.Fatal("expected error when primary key is missing from record")
 	}
@@ -370,17 +639,17 @@ func TestTable_Delete_MissingPrimaryKey(t *testing.T) {
 
 func TestTable_DeleteMany(t *testing.T) {
 	table := newTableWithSecondaryIndex(t)
-	if err := table.Insert(userRecordWithEmail(1, "Alice", "shared@example.com")); err != nil {
+	if _, err := table.Insert(userRecordWithEmail(1, "Alice", "shared@example.com")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
-	if err := table.Insert(userRecordWithEmail(2, "Bob", "other@example.com")); err != nil {
+	if _, err := table.Insert(userRecordWithEmail(2, "Bob", "other@example.com")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
-	if err := table.Insert(userRecordWithEmail(3, "Eve", "shared@example.com")); err != nil {
+	if _, err := table.Insert(userRecordWithEmail(3, "Eve", "shared@example.com")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
 
-	deleted, err := table.DeleteMany(vals.NewObject().Set("email", vals.NewString("shared@example.com")))
+	deleted, err := table.DeleteMany(primitive.NewObject().Set("email", primitive.NewString("shared@example.com")))
 	if err != nil {
 		t.Fatalf("DeleteMany: %v", err)
 	}
@@ -388,7 +657,7 @@ func TestTable_DeleteMany(t *testing.T) {
 		t.Errorf("expected 2 deleted records, got %d", len(deleted))
 	}
 
-	remaining := table.GetAll()
+	remaining, _ := table.GetAll()
 	if len(remaining) != 1 {
 		t.Errorf("expected 1 remaining record, got %d", len(remaining))
 	}
@@ -401,11 +670,11 @@ func TestTable_DeleteMany(t *testing.T) {
 
 func TestTable_Update_Existing(t *testing.T) {
 	table := newTestTable(t)
-	if err := table.Insert(userRecord(1, "Alice")); err != nil {
+	if _, err := table.Insert(userRecord(1, "Alice")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
 
-	update := vals.NewObject().Set("id", vals.NewUint64(1)).Set("name", vals.NewString("Alicia"))
+	update := primitive.NewObject().Set("id", primitive.NewUint64(1)).Set("name", primitive.NewString("Alicia"))
 	old, err := table.Update(update)
 	if err != nil {
 		t.Fatalf("Update: %v", err)
@@ -417,7 +686,7 @@ func TestTable_Update_Existing(t *testing.T) {
 		t.Errorf("expected old name='Alice', got %q", old.GetString("name"))
 	}
 
-	got, err := table.Get(vals.NewObject().Set("id", vals.NewUint64(1)))
+	got, err := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(1)))
 	if err != nil {
 		t.Fatalf("Get: %v", err)
 	}
@@ -438,12 +707,28 @@ func TestTable_Update_NonExisting(t *testing.T) {
 func TestTable_Update_MissingPrimaryKey(t *testing.T) {
 	table := newTestTable(t)
 
-	_, err := table.Update(vals.NewObject().Set("name", vals.NewString("Alice")))
+	_, err := table.Update(primitive.NewObject().Set("name", primitive.NewString("Alice")))
 	if err == nil {
 		t.Fatal("expected error when primary key is missing")
 	}
 }
 
+func TestTable_Update_MovesSecondaryIndexEntry(t *testing.T) {
+	table := newTableWithSecondaryIndex(t)
+	table.Insert(userRecordWithEmail(1, "Alice", "alice@example.com"))
+
+	if _, err := table.Update(userRecordWithEmail(1, "Alice", "alice@example.org")); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	if results, _ := table.Find(primitive.NewObject().Set("email", primitive.NewString("alice@example.org"))); len(results) != 1 {
+		t.Errorf("expected record to be found by new email, got %d results", len(results))
+	}
+	if results, err := table.FindWhere(Eq("email", primitive.NewString("alice@example.com"))); err != nil || len(results) != 0 {
+		t.Errorf("expected old email entry to be removed, got %d results, %v", len(results), err)
+	}
+}
+
 // --- Upsert ---
 
 func TestTable_Upsert_NewRecord(t *testing.T) {
@@ -457,7 +742,7 @@ func TestTable_Upsert_NewRecord(t *testing.T) {
 		t.Errorf("expected nil old value on insert-upsert, got %v", old)
 	}
 
-	got, err := table.Get(vals.NewObject().Set("id", vals.NewUint64(1)))
+	got, err := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(1)))
 	if err != nil {
 		t.Fatalf("Get: %v", err)
 	}
@@ -468,7 +753,7 @@ func TestTable_Upsert_NewRecord(t *testing.T) {
 
 func TestTable_Upsert_ExistingRecord(t *testing.T) {
 	table := newTestTable(t)
-	if err := table.Insert(userRecord(1, "Alice")); err != nil {
+	if _, err := table.Insert(userRecord(1, "Alice")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
 
@@ -483,7 +768,7 @@ func TestTable_Upsert_ExistingRecord(t *testing.T) {
 		t.Errorf("expected old name='Alice', got %q", old.GetString("name"))
 	}
 
-	got, err := table.Get(vals.NewObject().Set("id", vals.NewUint64(1)))
+	got, err := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(1)))
 	if err != nil {
 		t.Fatalf("Get: %v", err)
 	}
@@ -495,7 +780,7 @@ func TestTable_Upsert_ExistingRecord(t *testing.T) {
 func TestTable_Upsert_MissingPrimaryKey(t *testing.T) {
 	table := newTestTable(t)
 
-	_, err := table.Upsert(vals.NewObject().Set("name", vals.NewString("Alice")))
+	_, err := table.Upsert(primitive.NewObject().Set("name", primitive.NewString("Alice")))
 	if err == nil {
 		t.Fatal("expected error when primary key is missing")
 	}
@@ -505,18 +790,18 @@ func TestTable_Upsert_MissingPrimaryKey(t *testing.T) {
 
 func TestTable_UpdateMany(t *testing.T) {
 	table := newTableWithSecondaryIndex(t)
-	if err := table.Insert(userRecordWithEmail(1, "Alice", "group@example.com")); err != nil {
+	if _, err := table.Insert(userRecordWithEmail(1, "Alice", "group@example.com")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
-	if err := table.Insert(userRecordWithEmail(2, "Bob", "group@example.com")); err != nil {
+	if _, err := table.Insert(userRecordWithEmail(2, "Bob", "group@example.com")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
-	if err := table.Insert(userRecordWithEmail(3, "Eve", "other@example.com")); err != nil {
+	if _, err := table.Insert(userRecordWithEmail(3, "Eve", "other@example.com")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
 
-	query := vals.NewObject().Set("email", vals.NewString("group@example.com"))
-	update := vals.NewObject().Set("name", vals.NewString("Updated"))
+	query := primitive.NewObject().Set("email", primitive.NewString("group@example.com"))
+	update := primitive.NewObject().Set("name", primitive.NewString("Updated"))
 	old, err := table.UpdateMany(query, update)
 	if err != nil {
 		t.Fatalf("UpdateMany: %v", err)
@@ -543,7 +828,7 @@ func TestTable_UpdateMany(t *testing.T) {
 
 func TestTable_ChangeEvents_AfterInsert(t *testing.T) {
 	table := newTestTable(t)
-	if err := table.Insert(userRecord(1, "Alice")); err != nil {
+	if _, err := table.Insert(userRecord(1, "Alice")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
 
@@ -554,12 +839,12 @@ func TestTable_ChangeEvents_AfterInsert(t *testing.T) {
 
 func TestTable_ChangeEvents_AfterDelete(t *testing.T) {
 	table := newTestTable(t)
-	if err := table.Insert(userRecord(1, "Alice")); err != nil {
+	if _, err := table.Insert(userRecord(1, "Alice")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
 	beforeDelete := len(table.ChangeEvents())
 
-	if _, err := table.Delete(vals.NewObject().Set("id", vals.NewUint64(1))); err != nil {
+	if _, err := table.Delete(primitive.NewObject().Set("id", primitive.NewUint64(1))); err != nil {
 		t.Fatalf("Delete: %v", err)
 	}
 	if len(table.ChangeEvents()) <= beforeDelete {
@@ -569,12 +854,12 @@ func TestTable_ChangeEvents_AfterDelete(t *testing.T) {
 
 func TestTable_ChangeEvents_AfterUpdate(t *testing.T) {
 	table := newTestTable(t)
-	if err := table.Insert(userRecord(1, "Alice")); err != nil {
+	if _, err := table.Insert(userRecord(1, "Alice")); err != nil {
 		t.Fatalf("Insert: %v", err)
 	}
 	beforeUpdate := len(table.ChangeEvents())
 
-	update := vals.NewObject().Set("id", vals.NewUint64(1)).Set("name", vals.NewString("Alicia"))
+	update := primitive.NewObject().Set("id", primitive.NewUint64(1)).Set("name", primitive.NewString("Alicia"))
 	if _, err := table.Update(update); err != nil {
 		t.Fatalf("Update: %v", err)
 	}
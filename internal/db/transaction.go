@@ -4,13 +4,16 @@ import (
 	"context"
 	"fmt"
 	"sync/atomic"
+	"time"
 )
 
 type TransactionState int32
+type IsolationLevel int32
 
 type TransactionCommit struct {
 	ReadEvents   []TableEvent
 	ChangeEvents []TableEvent
+	Durability   DurabilityMode
 	Response     chan<- TransactionCommitResponse
 }
 
@@ -19,12 +22,28 @@ type TransactionCommitResponse struct {
 	Error   error
 }
 
+type TransactionOptions struct {
+	ReadOnly   bool
+	Timeout    time.Duration // Zero value means TRANSACTION_TIMEOUT
+	Isolation  IsolationLevel
+	Durability DurabilityMode
+}
+
+type transactionSavepoint struct {
+	name  string
+	state TableManagerSavepoint
+}
+
 type Transaction struct {
 	state atomic.Int32 // It's reference to TransactionState but with atomic operations support
 
+	options     TransactionOptions
 	manager     *TableManager
 	commitQueue chan<- TransactionCommit
 	ctx         context.Context
+	cancel      context.CancelFunc
+	release     func() // Releases transaction snapshot, so pages reachable only from it can be reclaimed
+	savepoints  []transactionSavepoint
 }
 
 const (
@@ -34,8 +53,13 @@ const (
 	TRANSACTION_ABORTED
 )
 
+const (
+	ISOLATION_SNAPSHOT     IsolationLevel = iota // Transaction is aborted only if entries it writes were changed concurrently
+	ISOLATION_SERIALIZABLE                       // Transaction is also aborted if entries or ranges it reads were changed concurrently
+)
+
 func NewTransaction(db *Database, ctx context.Context) (*Transaction, error) {
-	return db.createTransaction(ctx)
+	return db.Begin(ctx, TransactionOptions{})
 }
 
 func (tx *Transaction) Commit() (err error) {
@@ -48,7 +72,14 @@ func (tx *Transaction) Commit() (err error) {
 	}()
 
 	if !tx.setCommitting() {
-		return fmt.Errorf("Transaction: couldn't commit transaction because it is not active")
+		return fmt.Errorf("Transaction: couldn't commit transaction: %w", ErrTransactionNotActive)
+	}
+
+	defer tx.finish()
+
+	if err := tx.ctx.Err(); err != nil {
+		tx.setAborted()
+		return fmt.Errorf("Transaction: couldn't commit transaction because its context is done: %w", err)
 	}
 
 	// If there is nothing to write, then just return
@@ -60,11 +91,20 @@ func (tx *Transaction) Commit() (err error) {
 	// Create channel to get response from db writer
 	responseChannel := make(chan TransactionCommitResponse, 1)
 
-	tx.commitQueue <- TransactionCommit{
+	commit := TransactionCommit{
+		ReadEvents:   tx.manager.ReadEvents(),
 		ChangeEvents: tx.manager.ChangeEvents(),
+		Durability:   tx.options.Durability,
 		Response:     responseChannel,
 	}
 
+	select {
+	case tx.commitQueue <- commit:
+	case <-tx.ctx.Done():
+		tx.setAborted()
+		return fmt.Errorf("Transaction: commit transaction cancelled by context")
+	}
+
 	select {
 	case response := <-responseChannel:
 		if response.Success {
@@ -80,8 +120,68 @@ func (tx *Transaction) Commit() (err error) {
 	}
 }
 
-func (tx *Transaction) Rollback() {
-	tx.setAborted()
+func (tx *Transaction) Rollback() error {
+	if !tx.setAborted() {
+		return fmt.Errorf("Transaction: couldn't rollback transaction: %w", ErrTransactionNotActive)
+	}
+
+	tx.manager.Discard()
+	tx.finish()
+
+	return nil
+}
+
+// Savepoint marks current state of transaction, so changes made after it can be undone by RollbackTo.
+// If savepoint with the same name already exists, the new one shadows it until released.
+func (tx *Transaction) Savepoint(name string) error {
+	if TransactionState(tx.state.Load()) != TRANSACTION_PROCESSING {
+		return fmt.Errorf("Transaction: couldn't create savepoint %q: %w", name, ErrTransactionNotActive)
+	}
+
+	tx.savepoints = append(tx.savepoints, transactionSavepoint{name: name, state: tx.manager.Savepoint()})
+
+	return nil
+}
+
+// RollbackTo undoes all changes made after the savepoint. Savepoint itself stays, while savepoints created after it are removed.
+func (tx *Transaction) RollbackTo(name string) error {
+	if TransactionState(tx.state.Load()) != TRANSACTION_PROCESSING {
+		return fmt.Errorf("Transaction: couldn't rollback to savepoint %q: %w", name, ErrTransactionNotActive)
+	}
+
+	savepointIndex := tx.findSavepoint(name)
+	if savepointIndex < 0 {
+		return fmt.Errorf("Transaction: couldn't rollback to savepoint %q because it doesn't exist", name)
+	}
+
+	tx.manager.RestoreSavepoint(tx.savepoints[savepointIndex].state)
+	tx.savepoints = tx.savepoints[:savepointIndex+1]
+
+	return nil
+}
+
+// Release removes the savepoint and all savepoints created after it, keeping the changes made since them.
+func (tx *Transaction) Release(name string) error {
+	if TransactionState(tx.state.Load()) != TRANSACTION_PROCESSING {
+		return fmt.Errorf("Transaction: couldn't release savepoint %q: %w", name, ErrTransactionNotActive)
+	}
+
+	savepointIndex := tx.findSavepoint(name)
+	if savepointIndex < 0 {
+		return fmt.Errorf("Transaction: couldn't release savepoint %q because it doesn't exist", name)
+	}
+
+	tx.savepoints = tx.savepoints[:savepointIndex]
+
+	return nil
+}
+
+func (tx *Transaction) Context() context.Context {
+	return tx.ctx
+}
+
+func (tx *Transaction) Options() TransactionOptions {
+	return tx.options
 }
 
 func (tx *Transaction) Table(tableName string) (*Table, error) {
@@ -94,6 +194,10 @@ func (tx *Transaction) Table(tableName string) (*Table, error) {
 }
 
 func (tx *Transaction) CreateTable(schema *TableSchema) (*Table, error) {
+	if tx.options.ReadOnly {
+		return nil, fmt.Errorf("Transaction: couldn't create table %s: %w", schema.Name, ErrReadOnlyTransaction)
+	}
+
 	table, err := tx.manager.CreateTable(schema)
 	if err != nil {
 		return nil, fmt.Errorf("Transaction: couldn't create table %s because of error during creating table: %w", schema.Name, err)
@@ -103,6 +207,10 @@ func (tx *Transaction) CreateTable(schema *TableSchema) (*Table, error) {
 }
 
 func (tx *Transaction) DropTable(tableName string) error {
+	if tx.options.ReadOnly {
+		return fmt.Errorf("Transaction: couldn't drop table %s: %w", tableName, ErrReadOnlyTransaction)
+	}
+
 	if err := tx.manager.DropTable(tableName); err != nil {
 		return fmt.Errorf("Transaction: couldn't drop table %s because of error during dropping table: %w", tableName, err)
 	}
@@ -110,6 +218,43 @@ func (tx *Transaction) DropTable(tableName string) error {
 	return nil
 }
 
+func (tx *Transaction) TruncateTable(tableName string) error {
+	if tx.options.ReadOnly {
+		return fmt.Errorf("Transaction: couldn't truncate table %s: %w", tableName, ErrReadOnlyTransaction)
+	}
+
+	if err := tx.manager.TruncateTable(tableName); err != nil {
+		return fmt.Errorf("Transaction: couldn't truncate table %s: %w", tableName, err)
+	}
+
+	return nil
+}
+
+func (tx *Transaction) RenameTable(tableName string, newName string) error {
+	if tx.options.ReadOnly {
+		return fmt.Errorf("Transaction: couldn't rename table %s: %w", tableName, ErrReadOnlyTransaction)
+	}
+
+	if err := tx.manager.RenameTable(tableName, newName); err != nil {
+		return fmt.Errorf("Transaction: couldn't rename table %s: %w", tableName, err)
+	}
+
+	return nil
+}
+
+func (tx *Transaction) MigrateTable(tableName string, operations ...MigrationOperation) (*Table, error) {
+	if tx.options.ReadOnly {
+		return nil, fmt.Errorf("Transaction: couldn't migrate table %s: %w", tableName, ErrReadOnlyTransaction)
+	}
+
+	table, err := tx.manager.MigrateTable(tableName, operations...)
+	if err != nil {
+		return nil, fmt.Errorf("Transaction: couldn't migrate table %s: %w", tableName, err)
+	}
+
+	return table, nil
+}
+
 func (tx *Transaction) IsActive() bool {
 	state := TransactionState(tx.state.Load())
 
@@ -134,9 +279,33 @@ func (tx *Transaction) setCommitted() bool {
 }
 
 func (tx *Transaction) setAborted() bool {
-	if state := TransactionState(tx.state.Load()); state != TRANSACTION_COMMITTING {
+	state := TransactionState(tx.state.Load())
+
+	if state != TRANSACTION_PROCESSING && state != TRANSACTION_COMMITTING {
 		return false
 	}
 
-	return tx.state.CompareAndSwap(int32(TRANSACTION_COMMITTING), int32(TRANSACTION_ABORTED))
+	return tx.state.CompareAndSwap(int32(state), int32(TRANSACTION_ABORTED))
+}
+
+func (tx *Transaction) findSavepoint(name string) int {
+	for savepointIndex := len(tx.savepoints) - 1; savepointIndex >= 0; savepointIndex-- {
+		if tx.savepoints[savepointIndex].name == name {
+			return savepointIndex
+		}
+	}
+
+	return -1
+}
+
+func (tx *Transaction) finish() {
+	tx.savepoints = nil
+
+	if tx.cancel != nil {
+		tx.cancel()
+	}
+
+	if tx.release != nil {
+		tx.release()
+	}
 }
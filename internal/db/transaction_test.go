@@ -3,7 +3,8 @@ package db
 import (
 	"context"
 	"distributed-storage/internal/pager"
-	"distributed-storage/internal/vals"
+	"distributed-storage/internal/primitive"
+	"errors"
 	"testing"
 )
 
@@ -71,6 +72,32 @@ func TestTransaction_Rollback_SetsAborted(t *testing.T) {
 	}
 }
 
+func TestTransaction_Rollback_WhenProcessing_DiscardsChanges(t *testing.T) {
+	tx := newTestTransaction(t)
+	if _, err := tx.CreateTable(basicSchema()); err != nil {
+		t.Fatalf("CreateTable failed: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if TransactionState(tx.state.Load()) != TRANSACTION_ABORTED {
+		t.Error("expected ABORTED state after Rollback")
+	}
+	if len(tx.manager.ChangeEvents()) != 0 {
+		t.Error("expected no change events after Rollback")
+	}
+}
+
+func TestTransaction_Rollback_AfterCommit_ReturnsError(t *testing.T) {
+	tx := newTestTransaction(t)
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if err := tx.Rollback(); !errors.Is(err, ErrTransactionNotActive) {
+		t.Errorf("expected ErrTransactionNotActive on Rollback of committed transaction, got %v", err)
+	}
+}
+
 func TestTransaction_Commit_NoChanges_SetsCommitted(t *testing.T) {
 	tx := newTestTransaction(t)
 	// No change events → Commit should succeed immediately without the commit loop
@@ -110,8 +137,8 @@ func TestTransaction_CreateTable_Success(t *testing.T) {
 	schema := &TableSchema{
 		Name:         "orders",
 		PrimaryIndex: []string{"id"},
-		IndexedColumns: map[string]vals.ValueType{
-			"id": vals.TYPE_UINT64,
+		IndexedColumns: map[string]primitive.PrimitiveType{
+			"id": primitive.TYPE_UINT64,
 		},
 	}
 	table, err := tx.CreateTable(schema)
@@ -128,8 +155,8 @@ func TestTransaction_CreateTable_ThenTable_FindsIt(t *testing.T) {
 	schema := &TableSchema{
 		Name:         "items",
 		PrimaryIndex: []string{"id"},
-		IndexedColumns: map[string]vals.ValueType{
-			"id": vals.TYPE_UINT64,
+		IndexedColumns: map[string]primitive.PrimitiveType{
+			"id": primitive.TYPE_UINT64,
 		},
 	}
 	if _, err := tx.CreateTable(schema); err != nil {
@@ -149,8 +176,8 @@ func TestTransaction_DropTable(t *testing.T) {
 	schema := &TableSchema{
 		Name:         "temp",
 		PrimaryIndex: []string{"id"},
-		IndexedColumns: map[string]vals.ValueType{
-			"id": vals.TYPE_UINT64,
+		IndexedColumns: map[string]primitive.PrimitiveType{
+			"id": primitive.TYPE_UINT64,
 		},
 	}
 	if _, err := tx.CreateTable(schema); err != nil {
@@ -185,8 +212,8 @@ func TestTransaction_CancelledContext_CommitReturnsError(t *testing.T) {
 	schema := &TableSchema{
 		Name:         "ctx_test",
 		PrimaryIndex: []string{"id"},
-		IndexedColumns: map[string]vals.ValueType{
-			"id": vals.TYPE_UINT64,
+		IndexedColumns: map[string]primitive.PrimitiveType{
+			"id": primitive.TYPE_UINT64,
 		},
 	}
 	if _, err := tx.CreateTable(schema); err != nil {
@@ -199,3 +226,163 @@ func TestTransaction_CancelledContext_CommitReturnsError(t *testing.T) {
 		t.Error("expected error from Commit on cancelled context")
 	}
 }
+
+func newTestTransactionWithTable(t *testing.T) (*Transaction, *Table) {
+	t.Helper()
+	tx := newTestTransaction(t)
+	table, err := tx.CreateTable(basicSchema())
+	if err != nil {
+		t.Fatalf("CreateTable failed: %v", err)
+	}
+	if _, err := table.Insert(userRecord(1, "alice")); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	return tx, table
+}
+
+func TestTransaction_RollbackTo_RevertsTreeAndEvents(t *testing.T) {
+	tx, table := newTestTransactionWithTable(t)
+	eventsCount := len(tx.manager.ChangeEvents())
+
+	if err := tx.Savepoint("batch"); err != nil {
+		t.Fatalf("Savepoint failed: %v", err)
+	}
+	table.Insert(userRecord(2, "bob"))
+	table.Update(userRecord(1, "alicia"))
+
+	if err := tx.RollbackTo("batch"); err != nil {
+		t.Fatalf("RollbackTo failed: %v", err)
+	}
+
+	table, _ = tx.Table("users")
+	if record, _ := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(2))); record != nil {
+		t.Error("expected record inserted after savepoint to be gone")
+	}
+	record, _ := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(1)))
+	if record == nil || record.GetString("name") != "alice" {
+		t.Errorf("expected record updated after savepoint to be reverted, got %v", record)
+	}
+	if len(tx.manager.ChangeEvents()) != eventsCount {
+		t.Errorf("expected %d change events after RollbackTo, got %d", eventsCount, len(tx.manager.ChangeEvents()))
+	}
+}
+
+func TestTransaction_RollbackTo_RevertsCreatedTable(t *testing.T) {
+	tx := newTestTransaction(t)
+	tx.Savepoint("schema")
+	if _, err := tx.CreateTable(basicSchema()); err != nil {
+		t.Fatalf("CreateTable failed: %v", err)
+	}
+	if err := tx.RollbackTo("schema"); err != nil {
+		t.Fatalf("RollbackTo failed: %v", err)
+	}
+	if table, _ := tx.Table("users"); table != nil {
+		t.Error("expected table created after savepoint to be gone")
+	}
+	if len(tx.manager.ChangeEvents()) != 0 {
+		t.Error("expected no change events after RollbackTo")
+	}
+}
+
+func TestTransaction_RollbackTo_KeepsTablesLoadedAfterSavepoint(t *testing.T) {
+	db := newTestDatabaseWithRecords(t)
+	tx, _ := db.Begin(context.Background(), TransactionOptions{})
+
+	tx.Savepoint("batch")
+	table, _ := tx.Table("users")
+	table.Insert(userRecord(2, "bob"))
+	if err := tx.RollbackTo("batch"); err != nil {
+		t.Fatalf("RollbackTo failed: %v", err)
+	}
+
+	if record, _ := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(2))); record != nil {
+		t.Error("expected record inserted after savepoint to be gone")
+	}
+	if _, err := table.Insert(userRecord(3, "carol")); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	reader, _ := db.Begin(context.Background(), TransactionOptions{})
+	users, _ := reader.Table("users")
+	if record, _ := users.Get(primitive.NewObject().Set("id", primitive.NewUint64(3))); record == nil {
+		t.Error("expected record inserted through table loaded after savepoint to be committed")
+	}
+	if record, _ := users.Get(primitive.NewObject().Set("id", primitive.NewUint64(1))); record == nil {
+		t.Error("expected committed record to stay")
+	}
+}
+
+func TestTransaction_RollbackTo_CanBeRepeated(t *testing.T) {
+	tx, table := newTestTransactionWithTable(t)
+	tx.Savepoint("batch")
+
+	for id := uint64(2); id < 4; id++ {
+		table, _ = tx.Table("users")
+		if _, err := table.Insert(userRecord(id, "bob")); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+		if err := tx.RollbackTo("batch"); err != nil {
+			t.Fatalf("RollbackTo failed: %v", err)
+		}
+	}
+
+	table, _ = tx.Table("users")
+	if records, _ := table.GetAll(); len(records) != 1 {
+		t.Errorf("expected 1 record after repeated RollbackTo, got %d", len(records))
+	}
+}
+
+func TestTransaction_RollbackTo_RemovesLaterSavepoints(t *testing.T) {
+	tx, table := newTestTransactionWithTable(t)
+	tx.Savepoint("outer")
+	table.Insert(userRecord(2, "bob"))
+	tx.Savepoint("inner")
+	table.Insert(userRecord(3, "carol"))
+
+	if err := tx.RollbackTo("outer"); err != nil {
+		t.Fatalf("RollbackTo failed: %v", err)
+	}
+	if err := tx.RollbackTo("inner"); err == nil {
+		t.Error("expected savepoint created after 'outer' to be removed")
+	}
+	if err := tx.RollbackTo("outer"); err != nil {
+		t.Errorf("expected 'outer' savepoint to stay, got %v", err)
+	}
+}
+
+func TestTransaction_Release_KeepsChanges(t *testing.T) {
+	tx, table := newTestTransactionWithTable(t)
+	tx.Savepoint("batch")
+	table.Insert(userRecord(2, "bob"))
+
+	if err := tx.Release("batch"); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if err := tx.RollbackTo("batch"); err == nil {
+		t.Error("expected RollbackTo released savepoint to fail")
+	}
+	if record, _ := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(2))); record == nil {
+		t.Error("expected changes to be kept after Release")
+	}
+}
+
+func TestTransaction_Savepoint_UnknownName_ReturnsError(t *testing.T) {
+	tx := newTestTransaction(t)
+	if err := tx.RollbackTo("missing"); err == nil {
+		t.Error("expected error for unknown savepoint in RollbackTo")
+	}
+	if err := tx.Release("missing"); err == nil {
+		t.Error("expected error for unknown savepoint in Release")
+	}
+}
+
+func TestTransaction_Savepoint_AfterCommit_ReturnsError(t *testing.T) {
+	tx := newTestTransaction(t)
+	tx.Commit()
+	if err := tx.Savepoint("late"); !errors.Is(err, ErrTransactionNotActive) {
+		t.Errorf("expected ErrTransactionNotActive, got %v", err)
+	}
+}
@@ -0,0 +1,342 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"distributed-storage/internal/events"
+	"distributed-storage/internal/kv"
+	"distributed-storage/internal/primitive"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+)
+
+const EXPIRY_INDEX_ID int = math.MaxUint32 - 1 // Internal index of row expiry times, below STATISTICS_INDEX_ID
+
+const PAYLOAD_EXPIRY_FORMAT byte = 0xFE // First byte of payloads with field directory preceded by expiry time of the row
+
+// RowTTL makes rows expire at the timestamp in Column plus Duration, or Duration after insert when Column is empty.
+// Rows without the timestamp never expire.
+type RowTTL struct {
+	Column   string
+	Duration time.Duration
+}
+
+func (table *Table) validateTTL() error {
+	ttl := table.schema.TTL
+	if ttl == nil {
+		return nil
+	}
+
+	if ttl.Duration < 0 || (ttl.Column == "" && ttl.Duration == 0) {
+		return fmt.Errorf("Table: TTL must have a timestamp column or a positive duration, got %s", ttl.Duration)
+	}
+
+	if indexedType, ok := table.schema.IndexedColumns[ttl.Column]; ok && indexedType != primitive.TYPE_TIMESTAMP {
+		return fmt.Errorf("Table: TTL column %q must be a timestamp, got %s", ttl.Column, primitive.TypeName(indexedType))
+	}
+
+	for _, column := range table.schema.Columns {
+		if column.Name == ttl.Column && column.Type != primitive.TYPE_TIMESTAMP {
+			return fmt.Errorf("Table: TTL column %q must be a timestamp, got %s", ttl.Column, primitive.TypeName(column.Type))
+		}
+	}
+
+	return nil
+}
+
+// encodeRow encodes the record with its expiry time. Rows expiring after insert keep expiry time of the old payload.
+func (table *Table) encodeRow(record *primitive.Object, oldPayload []byte) []byte {
+	payload := table.encodePayload(record)
+
+	expiresAt, ok := table.rowExpiry(record, oldPayload)
+	if !ok {
+		return payload
+	}
+
+	return append(binary.AppendVarint([]byte{PAYLOAD_EXPIRY_FORMAT}, expiresAt), payload[1:]...)
+}
+
+// rowExpiry returns expiry time of the record in unix nanoseconds
+func (table *Table) rowExpiry(record *primitive.Object, oldPayload []byte) (int64, bool) {
+	ttl := table.schema.TTL
+
+	switch {
+	case ttl == nil:
+		return 0, false
+	case ttl.Column != "":
+		if record.Get(ttl.Column).Type() != primitive.TYPE_TIMESTAMP {
+			return 0, false
+		}
+
+		return record.GetTimestamp(ttl.Column).Add(ttl.Duration).UnixNano(), true
+	default:
+		if expiresAt, ok := table.payloadExpiry(oldPayload); ok {
+			return expiresAt, true
+		}
+
+		return time.Now().Add(ttl.Duration).UnixNano(), true
+	}
+}
+
+// payloadExpiry returns expiry time stored in the payload
+func (table *Table) payloadExpiry(encodedPayload []byte) (int64, bool) {
+	if len(encodedPayload) == 0 || encodedPayload[0] != PAYLOAD_EXPIRY_FORMAT {
+		return 0, false
+	}
+
+	expiresAt, size := binary.Varint(encodedPayload[1:])
+
+	return expiresAt, size > 0
+}
+
+// payloadHeaderSize returns size of the format byte and the expiry time preceding field directory
+func (table *Table) payloadHeaderSize(encodedPayload []byte) int {
+	if encodedPayload[0] != PAYLOAD_EXPIRY_FORMAT {
+		return 1
+	}
+
+	_, size := binary.Varint(encodedPayload[1:])
+
+	return 1 + max(size, 0)
+}
+
+// expired reports whether the row has expired, reads hide such rows until the sweeper deletes them
+func (table *Table) expired(encodedPayload []byte) bool {
+	expiresAt, ok := table.payloadExpiry(encodedPayload)
+
+	return ok && expiresAt <= time.Now().UnixNano()
+}
+
+func (table *Table) expiryIndex(expiresAt int64, primaryIndex []byte) []byte {
+	expiryIndex := binary.BigEndian.AppendUint64(table.indexPrefix(EXPIRY_INDEX_ID), uint64(expiresAt)^(1<<63))
+
+	return append(expiryIndex, primaryIndex...)
+}
+
+// updateExpiryIndex moves expiry index entry of the row from the old payload expiry time to the new one, the entry
+// value is the primary index of the row
+func (table *Table) updateExpiryIndex(primaryIndex []byte, payload []byte, oldPayload []byte) error {
+	expiresAt, ok := table.payloadExpiry(payload)
+	oldExpiresAt, oldOk := table.payloadExpiry(oldPayload)
+
+	if ok == oldOk && expiresAt == oldExpiresAt {
+		return nil
+	}
+
+	if oldOk {
+		oldIndex := table.expiryIndex(oldExpiresAt, primaryIndex)
+		if _, err := table.kv.Delete(&kv.DeleteRequest{Key: oldIndex}); err != nil {
+			return err
+		}
+
+		table.changeEvents = append(table.changeEvents, events.NewDeleteEntry(uint64(table.id), oldIndex, primaryIndex))
+	}
+
+	if ok {
+		index := table.expiryIndex(expiresAt, primaryIndex)
+		if _, err := table.kv.Set(&kv.SetRequest{Key: index, Value: primaryIndex}); err != nil {
+			return err
+		}
+
+		table.changeEvents = append(table.changeEvents, events.NewInsertEntry(uint64(table.id), index, primaryIndex))
+	}
+
+	return nil
+}
+
+// sweepExpired deletes up to limit rows which expired by the time, in order of their expiry times, starting at expiry
+// index entry from. Deletes apply foreign key actions and run hooks like any other delete, rows which can't be deleted
+// are skipped with their changes undone. Returns the number of deleted rows and the entry to continue from, which is
+// nil once every expired row was visited.
+func (table *Table) sweepExpired(now time.Time, from []byte, limit int, logger *slog.Logger) (int, []byte, error) {
+	prefix := table.indexPrefix(EXPIRY_INDEX_ID)
+	upperBound := table.expiryIndex(now.UnixNano(), nil)
+
+	if from == nil {
+		from = prefix
+	}
+
+	swept := 0
+
+	for swept < limit {
+		var primaryIndexes [][]byte
+
+		cursor := table.kv.Scan(&kv.ScanRequest{Key: from})
+		for index, value := cursor.Current(); table.matchIndexes(index, prefix) && len(primaryIndexes) < limit-swept; index, value = cursor.Next() {
+			if len(index) < len(upperBound) {
+				return 0, nil, fmt.Errorf("Table %q: malformed expiry index entry %x", table.schema.Name, index)
+			}
+
+			if bytes.Compare(index[:len(upperBound)], upperBound) > 0 {
+				break
+			}
+
+			primaryIndexes = append(primaryIndexes, value)
+			from = append(bytes.Clone(index), 0) // Following entry, skipped rows aren't visited again
+		}
+
+		if len(primaryIndexes) == 0 {
+			return swept, nil, nil
+		}
+
+		for _, primaryIndex := range primaryIndexes {
+			deleted, err := table.deleteExpiredRow(primaryIndex, logger)
+			if err != nil {
+				return 0, nil, err
+			}
+
+			if deleted {
+				swept++
+			}
+		}
+	}
+
+	return swept, from, nil
+}
+
+// deleteExpiredRow deletes the row with Delete and reports whether it was deleted. Row which can't be deleted is
+// logged and its partial changes are undone.
+func (table *Table) deleteExpiredRow(primaryIndex []byte, logger *slog.Logger) (bool, error) {
+	response, err := table.kv.Get(&kv.GetRequest{Key: primaryIndex})
+	if err != nil {
+		return false, err
+	}
+	if response.Value == nil {
+		return false, fmt.Errorf("Table %q: expiry index entry points to missing record %x", table.schema.Name, primaryIndex)
+	}
+
+	record, err := table.decodeRecord(response.Value)
+	if err != nil {
+		return false, err
+	}
+
+	if table.manager == nil { // Without manager there are no foreign keys or hooks, so only storage errors can occur
+		_, err := table.Delete(table.primaryKey(record))
+		return err == nil, err
+	}
+
+	savepoint := table.manager.Savepoint()
+
+	if _, err := table.Delete(table.primaryKey(record)); err != nil {
+		table.manager.RestoreSavepoint(savepoint)
+		logger.Warn("Table: skipping expired row which can't be deleted",
+			"table", table.schema.Name, "key", fmt.Sprintf("%x", primaryIndex), "error", err)
+
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// replaceExpiredRow removes the expired row with its secondary and expiry index entries before a new row with the same
+// primary key is written. The row is already invisible, so foreign key actions and hooks aren't applied.
+func (table *Table) replaceExpiredRow(primaryIndex []byte, payload []byte) error {
+	record, err := table.decodeRecord(payload)
+	if err != nil {
+		return err
+	}
+
+	if _, err := table.kv.Delete(&kv.DeleteRequest{Key: primaryIndex}); err != nil {
+		return err
+	}
+
+	table.changeEvents = append(table.changeEvents, events.NewDeleteEntry(uint64(table.id), primaryIndex, payload))
+
+	if err := table.deleteSecondaryIndexes(record); err != nil {
+		return err
+	}
+
+	return table.updateExpiryIndex(primaryIndex, nil, payload)
+}
+
+// expiringTables returns active tables with TTL
+func (manager *TableManager) expiringTables() ([]*Table, error) {
+	var tables []*Table
+
+	records, err := manager.catalog.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("expiringTables: %w", err)
+	}
+
+	for _, record := range records {
+		table, err := manager.decodeTable(record)
+		if err != nil {
+			return nil, fmt.Errorf("expiringTables: %w", err)
+		}
+
+		if table.state != TABLE_ACTIVE || table.schema.TTL == nil {
+			continue
+		}
+
+		if table, err = manager.TableByID(table.id); err != nil {
+			return nil, fmt.Errorf("expiringTables: %w", err)
+		}
+		if table != nil {
+			tables = append(tables, table)
+		}
+	}
+
+	return tables, nil
+}
+
+func (db *Database) runExpiryLoop() {
+	ticker := time.NewTicker(db.config.ExpiryInterval)
+
+	for range ticker.C {
+		if err := db.sweepExpiredRows(); err != nil {
+			db.config.Logger.Error("Database: failed to sweep expired rows", "error", err)
+		}
+	}
+}
+
+// sweepExpiredRows deletes expired rows of every table with TTL, ExpiryBatchSize rows per transaction, so sweeping
+// doesn't hold back concurrent writers. Deletions are written as regular DeleteEntry events. Every table is swept
+// from the entry where its previous batch stopped, so rows which can't be deleted don't hold back later ones.
+func (db *Database) sweepExpiredRows() error {
+	resume := make(map[TableID][]byte)
+	done := make(map[TableID]bool)
+
+	for {
+		swept := 0
+
+		tx, err := db.Begin(context.Background(), TransactionOptions{})
+		if err != nil {
+			return err
+		}
+
+		tables, err := tx.manager.expiringTables()
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		for _, table := range tables {
+			if done[table.id] {
+				continue
+			}
+
+			var next []byte
+			if swept, next, err = table.sweepExpired(time.Now(), resume[table.id], db.config.ExpiryBatchSize, db.config.Logger); err != nil {
+				break
+			}
+
+			resume[table.id], done[table.id] = next, next == nil
+
+			if swept > 0 {
+				break
+			}
+		}
+
+		if err != nil || swept == 0 {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+}
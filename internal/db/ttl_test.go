@@ -0,0 +1,253 @@
+package db
+
+import (
+	"distributed-storage/internal/events"
+	"distributed-storage/internal/kv"
+	"distributed-storage/internal/pager"
+	"distributed-storage/internal/primitive"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func sessionsSchema(ttl *RowTTL) *TableSchema {
+	return &TableSchema{
+		Name:             "sessions",
+		PrimaryIndex:     []string{"id"},
+		SecondaryIndexes: []SecondaryIndex{{Columns: []string{"user"}}},
+		IndexedColumns:   map[string]primitive.PrimitiveType{"id": primitive.TYPE_UINT64, "user": primitive.TYPE_STRING},
+		TTL:              ttl,
+	}
+}
+
+func sessionRecord(id uint64, expiresAt time.Time) *primitive.Object {
+	return primitive.NewObject().
+		Set("id", primitive.NewUint64(id)).
+		Set("user", primitive.NewString("alice")).
+		Set("expires_at", primitive.NewTimestamp(expiresAt))
+}
+
+func newSessionsTable(t *testing.T, ttl *RowTTL) *Table {
+	t.Helper()
+	table, err := newTable(TableID(1), pager.NULL_PAGE, newTestPager(), sessionsSchema(ttl))
+	if err != nil {
+		t.Fatalf("newTable failed: %v", err)
+	}
+	return table
+}
+
+func expiryIndexEntries(table *Table) int {
+	prefix := table.indexPrefix(EXPIRY_INDEX_ID)
+	cursor := table.kv.Scan(&kv.ScanRequest{Key: prefix})
+
+	count := 0
+	for index, _ := cursor.Current(); table.matchIndexes(index, prefix); index, _ = cursor.Next() {
+		count++
+	}
+	return count
+}
+
+func TestTable_TTL_HidesExpiredRows(t *testing.T) {
+	table := newSessionsTable(t, &RowTTL{Column: "expires_at"})
+	table.Insert(sessionRecord(1, time.Now().Add(-time.Minute)))
+	table.Insert(sessionRecord(2, time.Now().Add(time.Hour)))
+
+	if record, _ := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(1))); record != nil {
+		t.Errorf("expected expired row to be hidden, got %v", record)
+	}
+	if records, _ := table.GetAll(); len(records) != 1 {
+		t.Errorf("expected 1 live row, got %d", len(records))
+	}
+	if records, _ := table.Find(primitive.NewObject().Set("user", primitive.NewString("alice"))); len(records) != 1 {
+		t.Errorf("expected secondary index lookup to skip expired row, got %d rows", len(records))
+	}
+	if _, err := table.Update(sessionRecord(1, time.Now().Add(time.Hour))); err == nil {
+		t.Error("expected update of expired row to fail")
+	}
+
+	if _, err := table.Insert(sessionRecord(1, time.Now().Add(time.Hour))); err != nil {
+		t.Fatalf("expected expired row to be replaced, got %v", err)
+	}
+	if records, _ := table.GetAll(); len(records) != 2 {
+		t.Errorf("expected 2 live rows, got %d", len(records))
+	}
+	if entries := expiryIndexEntries(table); entries != 2 {
+		t.Errorf("expected 2 expiry index entries, got %d", entries)
+	}
+}
+
+func TestTable_TTL_DurationSinceInsert(t *testing.T) {
+	table := newSessionsTable(t, &RowTTL{Duration: 20 * time.Millisecond})
+	table.Insert(userRecord(1, "alice"))
+
+	table.Update(userRecord(1, "alicia")) // Update doesn't extend lifetime of the row
+	if records, _ := table.GetAll(); len(records) != 1 {
+		t.Fatalf("expected row to be live before expiry, got %d rows", len(records))
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if records, _ := table.GetAll(); len(records) != 0 {
+		t.Errorf("expected row to expire 20ms after insert, got %d rows", len(records))
+	}
+}
+
+func TestTable_SweepExpired_DeletesRowsInOrder(t *testing.T) {
+	table := newSessionsTable(t, &RowTTL{Column: "expires_at"})
+	for id := uint64(1); id <= 5; id++ {
+		table.Insert(sessionRecord(id, time.Now().Add(-time.Duration(id)*time.Minute)))
+	}
+	table.Insert(sessionRecord(6, time.Now().Add(time.Hour)))
+	table.changeEvents = nil
+
+	swept, next, err := table.sweepExpired(time.Now(), nil, 3, slog.Default())
+	if err != nil || swept != 3 || next == nil {
+		t.Fatalf("expected 3 swept rows, got %d (%v)", swept, err)
+	}
+
+	var deleted []uint64
+	for _, event := range table.ChangeEvents() {
+		if deleteEntry, ok := event.(*events.DeleteEntry); ok && table.matchPrimaryIndex(deleteEntry.Key) {
+			deleted = append(deleted, table.decodePayload(deleteEntry.Value).GetUint64("id"))
+		}
+	}
+	if len(deleted) != 3 || deleted[0] != 5 || deleted[2] != 3 {
+		t.Errorf("expected the longest expired rows 5, 4, 3 to be deleted, got %v", deleted)
+	}
+
+	if swept, next, _ := table.sweepExpired(time.Now(), next, 3, slog.Default()); swept != 2 || next != nil {
+		t.Errorf("expected remaining 2 expired rows to be swept, got %d", swept)
+	}
+	if entries := expiryIndexEntries(table); entries != 1 {
+		t.Errorf("expected only expiry entry of live row, got %d", entries)
+	}
+}
+
+func TestDatabase_SweepExpiredRows_SmallTransactions(t *testing.T) {
+	config := newTestDatabaseConfig(t)
+	config.ExpiryBatchSize = 2
+	config.ExpiryInterval = time.Hour // Sweeps are run by the test
+	db, err := NewDatabase(config)
+	if err != nil {
+		t.Fatalf("NewDatabase failed: %v", err)
+	}
+
+	db.StartTransaction(func(tx *Transaction) {
+		table, _ := tx.CreateTable(sessionsSchema(&RowTTL{Column: "expires_at"}))
+		for id := uint64(1); id <= 5; id++ {
+			table.Insert(sessionRecord(id, time.Now().Add(-time.Minute)))
+		}
+	})
+
+	db.mu.RLock()
+	version := db.header.version
+	db.mu.RUnlock()
+
+	if err := db.sweepExpiredRows(); err != nil {
+		t.Fatalf("sweepExpiredRows failed: %v", err)
+	}
+
+	db.mu.RLock()
+	commits := db.header.version - version
+	db.mu.RUnlock()
+	if commits != 3 {
+		t.Errorf("expected 5 rows to be swept in 3 transactions, got %d", commits)
+	}
+
+	db.StartTransaction(func(tx *Transaction) {
+		table, _ := tx.Table("sessions")
+		if table.Root() != pager.NULL_PAGE {
+			t.Error("expected swept rows and their index entries to be deleted")
+		}
+	})
+}
+
+func TestTable_SweepExpired_AfterAnalyze(t *testing.T) {
+	table := newSessionsTable(t, &RowTTL{Column: "expires_at"})
+	table.Insert(sessionRecord(1, time.Now().Add(-time.Minute)))
+	table.Insert(sessionRecord(2, time.Now().Add(time.Hour)))
+
+	if _, err := table.Analyze(); err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	if swept, _, err := table.sweepExpired(time.Now(), nil, 10, slog.Default()); err != nil || swept != 1 {
+		t.Fatalf("expected 1 swept row, got %d (%v)", swept, err)
+	}
+	if table.statistics() == nil {
+		t.Error("expected statistics to survive the sweep")
+	}
+	if entries := expiryIndexEntries(table); entries != 1 {
+		t.Errorf("expected only expiry entry of live row, got %d", entries)
+	}
+}
+
+func TestTable_SweepExpired_SkipsRowsWhichCantBeDeleted(t *testing.T) {
+	m := newTestManager(t)
+	sessions, _ := m.CreateTable(sessionsSchema(&RowTTL{Column: "expires_at"}))
+	devices, err := m.CreateTable(&TableSchema{
+		Name:             "devices",
+		PrimaryIndex:     []string{"id"},
+		SecondaryIndexes: []SecondaryIndex{{Columns: []string{"session_id"}}},
+		IndexedColumns:   map[string]primitive.PrimitiveType{"id": primitive.TYPE_UINT64, "session_id": primitive.TYPE_UINT64},
+		ForeignKeys:      []ForeignKey{{Columns: []string{"session_id"}, Table: "sessions", OnDelete: FOREIGN_KEY_RESTRICT}},
+	})
+	if err != nil {
+		t.Fatalf("CreateTable failed: %v", err)
+	}
+
+	for id := uint64(1); id <= 4; id++ {
+		sessions.Insert(sessionRecord(id, time.Now().Add(-time.Duration(5-id)*time.Minute)))
+	}
+	sessions.Insert(sessionRecord(1, time.Now().Add(time.Hour))) // Parent must be live while the device is inserted
+	devices.Insert(primitive.NewObject().Set("id", primitive.NewUint64(1)).Set("session_id", primitive.NewUint64(1)))
+	sessions.Update(sessionRecord(1, time.Now().Add(-5*time.Minute)))
+
+	swept, next, err := sessions.sweepExpired(time.Now(), nil, 2, slog.Default())
+	if err != nil || swept != 2 {
+		t.Fatalf("expected 2 rows swept past the referenced one, got %d (%v)", swept, err)
+	}
+	if swept, next, _ = sessions.sweepExpired(time.Now(), next, 2, slog.Default()); swept != 1 || next != nil {
+		t.Errorf("expected last expired row to be swept, got %d", swept)
+	}
+
+	if entries := expiryIndexEntries(sessions); entries != 1 {
+		t.Errorf("expected only expiry entry of the referenced row, got %d", entries)
+	}
+	if _, err := sessions.Delete(primitive.NewObject().Set("id", primitive.NewUint64(1))); !errors.Is(err, ErrForeignKeyViolation) {
+		t.Errorf("expected referenced row to stay, got %v", err)
+	}
+}
+
+func TestTable_Insert_ReplacesExpiredRowWithoutDeleteActions(t *testing.T) {
+	m := newTestManager(t)
+	sessions, _ := m.CreateTable(sessionsSchema(&RowTTL{Column: "expires_at"}))
+	devices, _ := m.CreateTable(&TableSchema{
+		Name:             "devices",
+		PrimaryIndex:     []string{"id"},
+		SecondaryIndexes: []SecondaryIndex{{Columns: []string{"session_id"}}},
+		IndexedColumns:   map[string]primitive.PrimitiveType{"id": primitive.TYPE_UINT64, "session_id": primitive.TYPE_UINT64},
+		ForeignKeys:      []ForeignKey{{Columns: []string{"session_id"}, Table: "sessions", OnDelete: FOREIGN_KEY_CASCADE}},
+	})
+
+	sessions.Insert(sessionRecord(1, time.Now().Add(time.Hour)))
+	devices.Insert(primitive.NewObject().Set("id", primitive.NewUint64(1)).Set("session_id", primitive.NewUint64(1)))
+	sessions.Update(sessionRecord(1, time.Now().Add(-time.Minute)))
+
+	if _, err := sessions.Insert(sessionRecord(1, time.Now().Add(time.Hour))); err != nil {
+		t.Fatalf("expected expired row to be replaced, got %v", err)
+	}
+	if _, err := sessions.Upsert(sessionRecord(1, time.Now().Add(time.Hour))); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+
+	if records, _ := devices.GetAll(); len(records) != 1 {
+		t.Errorf("expected replacing expired row not to cascade, got %d devices", len(records))
+	}
+	if records, _ := sessions.Find(primitive.NewObject().Set("user", primitive.NewString("alice"))); len(records) != 1 {
+		t.Errorf("expected single secondary entry of the new row, got %d rows", len(records))
+	}
+	if entries := expiryIndexEntries(sessions); entries != 1 {
+		t.Errorf("expected single expiry entry, got %d", entries)
+	}
+}
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"distributed-storage/internal/codec"
+	"distributed-storage/internal/events"
+	"distributed-storage/internal/pager"
+	"distributed-storage/internal/wal"
+	"fmt"
+	"sync"
+)
+
+// WAL writes committed changes of the database to the write-ahead log, one event per entry. Every commit batch ends
+// with UpdateDBVersion event followed by FreePages events of the batch, so changes not yet flushed to storage are
+// restored by replaying events written after the version of the storage header. It is used by the commit loop and
+// the sync loop, so all access is serialized.
+type WAL struct {
+	log        *wal.WAL
+	pendingLog [][]byte // Encoded events waiting for the next sync
+	err        error    // First failed sync, state of the log is unknown after it, so every later sync fails too
+
+	mu sync.Mutex
+}
+
+func newWAL(config DatabaseConfig) (*WAL, error) {
+	log, err := wal.NewWAL(wal.WALConfig{
+		Directory:        config.WALDirectory,
+		ArchiveDirectory: config.WALArchiveDirectory,
+		SegmentSize:      config.WALSegmentSize,
+
+		Logger:  config.Logger,
+		Metrics: config.Metrics,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &WAL{log: log}, nil
+}
+
+func (wal *WAL) appendTransactions(transactions []TransactionCommit) {
+	wal.mu.Lock()
+	defer wal.mu.Unlock()
+
+	for _, transaction := range transactions {
+		wal.appendEvent(events.NewStartTransaction())
+
+		for _, event := range transaction.ChangeEvents {
+			wal.appendEvent(event)
+		}
+
+		wal.appendEvent(events.NewCommitTransaction())
+	}
+}
+
+func (wal *WAL) appendVersionUpdate(version DatabaseVersion) {
+	wal.mu.Lock()
+	defer wal.mu.Unlock()
+
+	wal.appendEvent(events.NewUpdateDBVersion(uint64(version)))
+}
+
+func (wal *WAL) appendFreePages(version DatabaseVersion, list pager.PageList) {
+	if list.Empty() {
+		return
+	}
+
+	wal.mu.Lock()
+	defer wal.mu.Unlock()
+
+	wal.appendEvent(events.NewFreePages(uint64(version), list))
+}
+
+func (wal *WAL) appendEvent(event TableEvent) {
+	wal.pendingLog = append(wal.pendingLog, codec.EncodeEvent(event))
+}
+
+// sync writes pending events to the log and flushes it to disk
+func (wal *WAL) sync() error {
+	wal.mu.Lock()
+	defer wal.mu.Unlock()
+
+	if wal.err != nil {
+		return fmt.Errorf("WAL: log failed earlier: %w", wal.err)
+	}
+
+	if len(wal.pendingLog) > 0 {
+		if _, err := wal.log.Append(wal.pendingLog...); err != nil {
+			wal.err = err
+			return err
+		}
+
+		wal.pendingLog = nil
+	}
+
+	if err := wal.log.Sync(); err != nil {
+		wal.err = err
+		return err
+	}
+
+	return nil
+}
+
+// failure returns error of the failed sync, commits acknowledged before it may be lost
+func (wal *WAL) failure() error {
+	wal.mu.Lock()
+	defer wal.mu.Unlock()
+
+	return wal.err
+}
+
+func (wal *WAL) empty() bool {
+	wal.mu.Lock()
+	defer wal.mu.Unlock()
+
+	return len(wal.pendingLog) == 0 && wal.log.Empty()
+}
+
+// eventsSince returns events of commit batches written after UpdateDBVersion event of the version. Events of a batch
+// which wasn't written completely before crash are dropped, as its transactions weren't acknowledged.
+func (wal *WAL) eventsSince(version DatabaseVersion) ([]TableEvent, error) {
+	var restoredEvents []TableEvent
+	versionFound := false
+	committed := 0 // Number of restored events belonging to completely written batches
+
+	for entry, err := range wal.log.Scan(0) {
+		if err != nil {
+			return nil, err
+		}
+
+		event, err := codec.DecodeEvent(entry.Data)
+		if err != nil {
+			return nil, fmt.Errorf("WAL: failed to decode event of entry %d: %w", entry.Index, err)
+		}
+
+		if versionEvent, ok := event.(*events.UpdateDBVersion); ok && DatabaseVersion(versionEvent.Version) == version {
+			versionFound = true
+			restoredEvents, committed = nil, 0 // Events of earlier versions are already in storage
+			continue
+		}
+		if !versionFound {
+			continue
+		}
+
+		restoredEvents = append(restoredEvents, event)
+
+		switch event.(type) {
+		case *events.UpdateDBVersion:
+			committed = len(restoredEvents)
+		case *events.FreePages:
+			if committed == len(restoredEvents)-1 {
+				committed = len(restoredEvents)
+			}
+		}
+	}
+
+	if !versionFound {
+		return nil, fmt.Errorf("WAL: no changes found for version %d", version)
+	}
+
+	return restoredEvents[:committed], nil
+}
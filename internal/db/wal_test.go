@@ -0,0 +1,127 @@
+package db
+
+import (
+	"distributed-storage/internal/events"
+	"distributed-storage/internal/pager"
+	"distributed-storage/internal/primitive"
+	"testing"
+)
+
+func newTestWAL(t *testing.T) *WAL {
+	t.Helper()
+	config := applyDefaults(newTestDatabaseConfig(t))
+	if err := setupFS(config); err != nil {
+		t.Fatalf("setupFS failed: %v", err)
+	}
+	wal, err := newWAL(config)
+	if err != nil {
+		t.Fatalf("newWAL failed: %v", err)
+	}
+	return wal
+}
+
+func TestWAL_AppendTransactions_Empty_NoOp(t *testing.T) {
+	wal := newTestWAL(t)
+	wal.appendTransactions([]TransactionCommit{})
+	wal.appendFreePages(DatabaseVersion(1), pager.NewPageList())
+	if len(wal.pendingLog) != 0 {
+		t.Errorf("expected no pending events, got %d", len(wal.pendingLog))
+	}
+}
+
+func TestWAL_Empty_FalseAfterSync(t *testing.T) {
+	wal := newTestWAL(t)
+	if !wal.empty() {
+		t.Error("expected new WAL to be empty")
+	}
+	wal.appendVersionUpdate(DatabaseVersion(1))
+	if err := wal.sync(); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if wal.empty() || len(wal.pendingLog) != 0 {
+		t.Error("expected synced events to leave pending log and make WAL non-empty")
+	}
+}
+
+func TestWAL_EventsSince_DropsIncompleteBatch(t *testing.T) {
+	wal := newTestWAL(t)
+	insert := events.NewInsertEntry(1, []byte("key"), []byte("value"))
+
+	wal.appendVersionUpdate(DatabaseVersion(1))
+	wal.appendTransactions([]TransactionCommit{{ChangeEvents: []TableEvent{insert}}})
+	wal.appendVersionUpdate(DatabaseVersion(2))
+	wal.appendFreePages(DatabaseVersion(1), pager.NewPageList(pager.PageInterval{Start: 5, End: 6}))
+	wal.appendTransactions([]TransactionCommit{{ChangeEvents: []TableEvent{insert}}}) // Batch cut by crash before version update
+	if err := wal.sync(); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+
+	restored, err := wal.eventsSince(DatabaseVersion(1))
+	if err != nil {
+		t.Fatalf("eventsSince failed: %v", err)
+	}
+	if len(restored) != 5 {
+		t.Fatalf("expected start, insert, commit, version update and free pages, got %d events", len(restored))
+	}
+	if _, ok := restored[4].(*events.FreePages); !ok {
+		t.Errorf("expected batch to end with free pages, got %T", restored[4])
+	}
+
+	if restored, _ := wal.eventsSince(DatabaseVersion(2)); len(restored) != 1 {
+		t.Errorf("expected only free pages after version 2, got %d events", len(restored))
+	}
+	if _, err := wal.eventsSince(DatabaseVersion(3)); err == nil {
+		t.Error("expected error for version missing in WAL")
+	}
+}
+
+func TestDatabase_Reopen_RestoresCommitsFromWAL(t *testing.T) {
+	config := newTestDatabaseConfig(t)
+	db, err := NewDatabase(config)
+	if err != nil {
+		t.Fatalf("NewDatabase failed: %v", err)
+	}
+	db.StartTransaction(func(tx *Transaction) {
+		table, _ := tx.CreateTable(basicSchema())
+		table.Insert(userRecord(1, "alice"))
+	})
+
+	reopened, err := NewDatabase(config) // In-memory storage is empty, so every commit is restored from WAL
+	if err != nil {
+		t.Fatalf("NewDatabase failed on reopen: %v", err)
+	}
+	reopened.StartTransaction(func(tx *Transaction) {
+		table, _ := tx.Table("users")
+		if table == nil {
+			t.Fatal("expected table to be restored")
+		}
+		if record, _ := table.Get(primitive.NewObject().Set("id", primitive.NewUint64(1))); record == nil {
+			t.Error("expected committed record to be restored")
+		}
+	})
+}
+
+func TestDatabase_WALSyncFailure_RejectsLaterCommits(t *testing.T) {
+	config := newTestDatabaseConfig(t)
+	config.Durability = DURABILITY_ASYNC
+	db, err := NewDatabase(config)
+	if err != nil {
+		t.Fatalf("NewDatabase failed: %v", err)
+	}
+	db.StartTransaction(func(tx *Transaction) {
+		tx.CreateTable(basicSchema())
+	})
+
+	db.wal.log.Close() // Every following write to the log fails
+	if err := db.syncWAL(); err == nil {
+		t.Fatal("expected sync of closed log to fail")
+	}
+
+	err = db.StartTransaction(func(tx *Transaction) {
+		table, _ := tx.Table("users")
+		table.Insert(userRecord(1, "alice"))
+	})
+	if err == nil {
+		t.Error("expected commit after failed WAL sync to be rejected")
+	}
+}
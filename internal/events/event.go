@@ -16,4 +16,11 @@ const (
 	DELETE_ENTRY_EVENT
 	UPDATE_DB_VERSION_EVENT
 	FREE_PAGES_EVENT
+	READ_ENTRY_EVENT
+	READ_RANGE_EVENT
+	UPDATE_SEQUENCE_EVENT
+	TRUNCATE_TABLE_EVENT
+	RENAME_TABLE_EVENT
+	RECLAIM_TABLE_EVENT
+	PURGE_TABLE_EVENT
 )
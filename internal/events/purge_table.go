@@ -0,0 +1,14 @@
+package events
+
+// PurgeTable describes removal of the catalog entry of a dropped table whose pages were reclaimed
+type PurgeTable struct {
+	TableID uint64
+}
+
+func NewPurgeTable(tableID uint64) *PurgeTable {
+	return &PurgeTable{TableID: tableID}
+}
+
+func (event *PurgeTable) Type() EventType {
+	return PURGE_TABLE_EVENT
+}
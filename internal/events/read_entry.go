@@ -0,0 +1,17 @@
+package events
+
+// ReadEntry describes an entry observed by a serializable transaction. It is never written to WAL,
+// it is only used to validate that the entry wasn't changed by a concurrent transaction before commit.
+type ReadEntry struct {
+	TableID uint64
+	Key     []byte
+	Value   []byte
+}
+
+func NewReadEntry(tableID uint64, key []byte, value []byte) *ReadEntry {
+	return &ReadEntry{TableID: tableID, Key: key, Value: value}
+}
+
+func (event *ReadEntry) Type() EventType {
+	return READ_ENTRY_EVENT
+}
@@ -0,0 +1,20 @@
+package events
+
+// ReadRange describes a key range scanned by a serializable transaction. Like ReadEntry it is never written to WAL,
+// the range is scanned again before commit and the transaction is aborted if its entries differ from the ones seen,
+// including entries inserted into the range concurrently.
+type ReadRange struct {
+	TableID  uint64
+	Low      []byte // First key of the range
+	High     []byte // Key following the range, nil if range isn't bounded
+	Entries  uint64 // Number of entries in the range
+	Checksum uint64 // Sum of checksums of entries in the range
+}
+
+func NewReadRange(tableID uint64, low []byte, high []byte, entries uint64, checksum uint64) *ReadRange {
+	return &ReadRange{TableID: tableID, Low: low, High: high, Entries: entries, Checksum: checksum}
+}
+
+func (event *ReadRange) Type() EventType {
+	return READ_RANGE_EVENT
+}
@@ -0,0 +1,14 @@
+package events
+
+// ReclaimTable describes freeing pages of a dropped table which is no longer visible to any transaction
+type ReclaimTable struct {
+	TableID uint64
+}
+
+func NewReclaimTable(tableID uint64) *ReclaimTable {
+	return &ReclaimTable{TableID: tableID}
+}
+
+func (event *ReclaimTable) Type() EventType {
+	return RECLAIM_TABLE_EVENT
+}
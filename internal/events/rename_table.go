@@ -0,0 +1,16 @@
+package events
+
+// RenameTable describes change of a table name. Old name is used to detect concurrent renames.
+type RenameTable struct {
+	TableID uint64
+	OldName string
+	NewName string
+}
+
+func NewRenameTable(tableID uint64, oldName string, newName string) *RenameTable {
+	return &RenameTable{TableID: tableID, OldName: oldName, NewName: newName}
+}
+
+func (event *RenameTable) Type() EventType {
+	return RENAME_TABLE_EVENT
+}
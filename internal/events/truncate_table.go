@@ -0,0 +1,17 @@
+package events
+
+// TruncateTable describes removal of all entries of a table, pages of the table tree are freed at once. Root of the
+// table in the state the transaction started from is kept, so truncate of a table changed by concurrent transaction
+// is detected on commit.
+type TruncateTable struct {
+	TableID uint64
+	Root    uint64 // Root page of the table tree before the transaction, null page for tables it created
+}
+
+func NewTruncateTable(tableID uint64, root uint64) *TruncateTable {
+	return &TruncateTable{TableID: tableID, Root: root}
+}
+
+func (event *TruncateTable) Type() EventType {
+	return TRUNCATE_TABLE_EVENT
+}
@@ -0,0 +1,16 @@
+package events
+
+// UpdateSequence describes allocation of values from a table key sequence. Old value is used to detect concurrent allocations.
+type UpdateSequence struct {
+	TableID  uint64
+	OldValue uint64
+	NewValue uint64
+}
+
+func NewUpdateSequence(tableID uint64, oldValue uint64, newValue uint64) *UpdateSequence {
+	return &UpdateSequence{TableID: tableID, OldValue: oldValue, NewValue: newValue}
+}
+
+func (event *UpdateSequence) Type() EventType {
+	return UPDATE_SEQUENCE_EVENT
+}
@@ -2,6 +2,7 @@ package helpers
 
 import (
 	"container/heap"
+	"slices"
 )
 
 type MinMap[Key comparable, Value any] struct {
@@ -87,6 +88,26 @@ func (m *MinMap[Key, Value]) RemoveKey(key Key) bool {
 	return true
 }
 
+func (m *MinMap[Key, Value]) RemoveFunc(key Key, match func(Value) bool) bool {
+	values, ok := m.items[key]
+	if !ok {
+		return false
+	}
+
+	valueIndex := slices.IndexFunc(values, match)
+	if valueIndex < 0 {
+		return false
+	}
+
+	if len(values) == 1 {
+		return m.RemoveKey(key)
+	}
+
+	m.items[key] = slices.Delete(values, valueIndex, valueIndex+1)
+
+	return true
+}
+
 func (m *MinMap[Key, Value]) Len() int {
 	return len(m.items)
 }
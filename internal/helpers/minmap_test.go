@@ -171,6 +171,46 @@ func TestMinMap_RemoveKey_UpdatesMinKey(t *testing.T) {
 	}
 }
 
+func TestMinMap_RemoveFunc_KeepsOtherValues(t *testing.T) {
+	m := NewMinMap[int, string](intLess)
+	m.Add(1, "a")
+	m.Add(1, "b")
+	if !m.RemoveFunc(1, func(value string) bool { return value == "a" }) {
+		t.Error("expected RemoveFunc to return true")
+	}
+	values, ok := m.Get(1)
+	if !ok || len(values) != 1 || values[0] != "b" {
+		t.Errorf("expected only 'b' to remain, got %v ok=%v", values, ok)
+	}
+}
+
+func TestMinMap_RemoveFunc_LastValueRemovesKey(t *testing.T) {
+	m := NewMinMap[int, string](intLess)
+	m.Add(1, "a")
+	m.Add(2, "b")
+	if !m.RemoveFunc(1, func(value string) bool { return value == "a" }) {
+		t.Error("expected RemoveFunc to return true")
+	}
+	if _, ok := m.Get(1); ok {
+		t.Error("key 1 should be gone after its last value was removed")
+	}
+	key, _, ok := m.PeekMin()
+	if !ok || key != 2 {
+		t.Errorf("expected new min key=2, got key=%d ok=%v", key, ok)
+	}
+}
+
+func TestMinMap_RemoveFunc_NoMatch(t *testing.T) {
+	m := NewMinMap[int, string](intLess)
+	m.Add(1, "a")
+	if m.RemoveFunc(1, func(value string) bool { return value == "z" }) {
+		t.Error("expected RemoveFunc to return false when nothing matches")
+	}
+	if m.RemoveFunc(2, func(value string) bool { return true }) {
+		t.Error("expected RemoveFunc to return false for missing key")
+	}
+}
+
 func TestMinMap_Len_Sequence(t *testing.T) {
 	m := NewMinMap[int, string](intLess)
 	if m.Len() != 0 {
@@ -8,6 +8,15 @@ type GetResponse struct {
 	Value []byte
 }
 
+type GetManyRequest struct {
+	Keys     [][]byte
+	Prefetch bool // Pages are read level by level in page order instead of following keys along a single path
+}
+
+type GetManyResponse struct {
+	Values [][]byte // Values in order of requested keys, nil for missing keys
+}
+
 type SetRequest struct {
 	Key   []byte
 	Value []byte
@@ -28,7 +37,8 @@ type DeleteResponse struct {
 }
 
 type ScanRequest struct {
-	Key []byte
+	Key     []byte
+	Reverse bool // Cursor starts at the last key less than Key, or at the last key if Key is nil
 }
 
 type ScanResponse interface {
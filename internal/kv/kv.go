@@ -1,8 +1,10 @@
 package kv
 
 import (
+	"bytes"
 	"distributed-storage/internal/pager"
 	"distributed-storage/internal/tree"
+	"slices"
 )
 
 var config = tree.TreeConfig{
@@ -27,15 +29,57 @@ func (kv *KeyValue) Root() pager.PagePointer {
 	return kv.tree.Root()
 }
 
+// Clear removes all keys, pages of the tree are freed without visiting the keys one by one
+func (kv *KeyValue) Clear() {
+	kv.tree.Clear()
+}
+
 func (kv *KeyValue) Get(request *GetRequest) (*GetResponse, error) {
 	value, err := kv.tree.Get(request.Key)
 
 	return &GetResponse{value}, err
 }
 
+// GetMany looks up the keys in one pass over the tree, keys don't have to be sorted
+func (kv *KeyValue) GetMany(request *GetManyRequest) (*GetManyResponse, error) {
+	order := make([]int, len(request.Keys))
+	for idx := range order {
+		order[idx] = idx
+	}
+
+	slices.SortFunc(order, func(first int, second int) int {
+		return bytes.Compare(request.Keys[first], request.Keys[second])
+	})
+
+	sortedKeys := make([][]byte, len(order))
+	for idx, keyIdx := range order {
+		sortedKeys[idx] = request.Keys[keyIdx]
+	}
+
+	sortedValues, err := kv.tree.GetMany(sortedKeys, request.Prefetch)
+	if err != nil {
+		return &GetManyResponse{}, err
+	}
+
+	values := make([][]byte, len(order))
+	for idx, keyIdx := range order {
+		values[keyIdx] = sortedValues[idx]
+	}
+
+	return &GetManyResponse{values}, nil
+}
+
 func (kv *KeyValue) Scan(request *ScanRequest) ScanResponse {
 	treeScanner := tree.NewScanner(kv.tree)
 
+	if request.Reverse {
+		if request.Key == nil { // Any stored key is less than key longer than max key size
+			return treeScanner.Seek(bytes.Repeat([]byte{0xFF}, config.MaxKeySize+1), tree.LESS_COMPARISON)
+		}
+
+		return treeScanner.Seek(request.Key, tree.LESS_COMPARISON)
+	}
+
 	return treeScanner.Seek(request.Key, tree.GREATER_OR_EQUAL_COMPARISON)
 }
 
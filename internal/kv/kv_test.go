@@ -86,6 +86,29 @@ func TestKeyValue_Get_AfterMultipleSets(t *testing.T) {
 
 // --- Set ---
 
+// --- GetMany ---
+
+func TestKeyValue_GetMany_UnsortedKeys_ReturnsValuesInRequestOrder(t *testing.T) {
+	kv := newTestKV()
+	for _, key := range []string{"a", "b", "c"} {
+		if _, err := kv.Set(&SetRequest{Key: []byte(key), Value: []byte("value-" + key)}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	resp, err := kv.GetMany(&GetManyRequest{Keys: [][]byte{[]byte("c"), []byte("missing"), []byte("a"), []byte("c")}, Prefetch: true})
+	if err != nil {
+		t.Fatalf("GetMany failed: %v", err)
+	}
+
+	expected := []string{"value-c", "", "value-a", "value-c"}
+	for idx, want := range expected {
+		if want == "" && resp.Values[idx] != nil || want != "" && !bytes.Equal(resp.Values[idx], []byte(want)) {
+			t.Errorf("value %d: expected %q, got %q", idx, want, resp.Values[idx])
+		}
+	}
+}
+
 func TestKeyValue_Set_NewKey_AddedTrue(t *testing.T) {
 	kv := newTestKV()
 	resp, err := kv.Set(&SetRequest{Key: []byte("new"), Value: []byte("val")})
@@ -255,6 +278,44 @@ func TestKeyValue_Scan_IterateAll(t *testing.T) {
 	}
 }
 
+func TestKeyValue_Scan_Reverse_SeeksToLastLess(t *testing.T) {
+	kv := newTestKV()
+	for _, k := range []string{"a", "c", "e"} {
+		kv.Set(&SetRequest{Key: []byte(k), Value: []byte(k)})
+	}
+
+	cases := map[string]string{"c": "a", "d": "c", "z": "e"}
+	for seek, expected := range cases {
+		k, _ := kv.Scan(&ScanRequest{Key: []byte(seek), Reverse: true}).Current()
+		if string(k) != expected {
+			t.Errorf("expected last key < %q to be %q, got %q", seek, expected, k)
+		}
+	}
+
+	if cursor := kv.Scan(&ScanRequest{Key: []byte("a"), Reverse: true}); !cursor.Empty() {
+		t.Error("expected empty cursor when seeking before all keys")
+	}
+}
+
+func TestKeyValue_Scan_Reverse_IterateAll(t *testing.T) {
+	kv := newTestKV()
+	for i := 0; i < 200; i++ {
+		kv.Set(&SetRequest{Key: []byte{byte(i)}, Value: []byte{1}})
+	}
+
+	cursor := kv.Scan(&ScanRequest{Reverse: true})
+	expected := 199
+	for k, _ := cursor.Current(); k != nil; k, _ = cursor.Prev() {
+		if int(k[0]) != expected {
+			t.Fatalf("expected key %d, got %d", expected, k[0])
+		}
+		expected--
+	}
+	if expected != -1 {
+		t.Errorf("expected all keys traversed, stopped before %d", expected)
+	}
+}
+
 func TestKeyValue_Scan_HasNext_HasPrev(t *testing.T) {
 	kv := newTestKV()
 	kv.Set(&SetRequest{Key: []byte("a"), Value: []byte("1")})
@@ -0,0 +1,28 @@
+package metrics
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Metrics receives measurements from database internals. Implementations must be safe for concurrent use.
+type Metrics interface {
+	AddCounter(name string, value float64, labels ...Label)
+	SetGauge(name string, value float64, labels ...Label)
+	ObserveHistogram(name string, value float64, labels ...Label)
+}
+
+// Describer is implemented by receivers which keep help texts and histogram bounds of metrics, such as Registry
+type Describer interface {
+	Describe(name string, help string, bounds ...float64)
+}
+
+type Noop struct{}
+
+func (noop *Noop) AddCounter(name string, value float64, labels ...Label)       {}
+func (noop *Noop) SetGauge(name string, value float64, labels ...Label)         {}
+func (noop *Noop) ObserveHistogram(name string, value float64, labels ...Label) {}
+
+func NewNoop() *Noop {
+	return &Noop{}
+}
@@ -0,0 +1,204 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	COUNTER_METRIC   = "counter"
+	GAUGE_METRIC     = "gauge"
+	HISTOGRAM_METRIC = "histogram"
+)
+
+var DEFAULT_BUCKETS = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10}
+var COUNT_BUCKETS = []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000} // Bounds of histograms of counts, e.g. batch sizes
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+type series struct {
+	labels  []Label
+	value   float64  // Used by counters and gauges
+	buckets []uint64 // Used by histograms, number of observations less or equal to corresponding bound
+	sum     float64  // Used by histograms
+	count   uint64   // Used by histograms
+}
+
+type family struct {
+	metricType string
+	help       string
+	bounds     []float64
+	series     map[string]*series
+}
+
+// Registry is in-memory Metrics implementation which can be exposed in Prometheus text format
+type Registry struct {
+	families map[string]*family
+	mu       sync.Mutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Describe sets help text of the metric and buckets upper bounds if metric is histogram. Bounds of histogram which
+// already has observations are kept, as its buckets were counted with them.
+func (registry *Registry) Describe(name string, help string, bounds ...float64) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	metricFamily := registry.family(name, "")
+	metricFamily.help = help
+
+	if len(bounds) > 0 && len(metricFamily.series) == 0 {
+		metricFamily.bounds = slices.Sorted(slices.Values(bounds))
+	}
+}
+
+func (registry *Registry) AddCounter(name string, value float64, labels ...Label) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.family(name, COUNTER_METRIC).get(labels).value += value
+}
+
+func (registry *Registry) SetGauge(name string, value float64, labels ...Label) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.family(name, GAUGE_METRIC).get(labels).value = value
+}
+
+func (registry *Registry) ObserveHistogram(name string, value float64, labels ...Label) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	metricFamily := registry.family(name, HISTOGRAM_METRIC)
+	metricSeries := metricFamily.get(labels)
+
+	if metricSeries.buckets == nil {
+		metricSeries.buckets = make([]uint64, len(metricFamily.bounds))
+	}
+
+	for bucket, bound := range metricFamily.bounds {
+		if value <= bound {
+			metricSeries.buckets[bucket]++
+		}
+	}
+
+	metricSeries.sum += value
+	metricSeries.count++
+}
+
+// Write writes all metrics in Prometheus text exposition format
+func (registry *Registry) Write(writer io.Writer) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	var out strings.Builder
+
+	for _, name := range slices.Sorted(maps.Keys(registry.families)) {
+		metricFamily := registry.families[name]
+
+		if metricFamily.metricType == "" { // Metric was described but never measured
+			continue
+		}
+
+		if metricFamily.help != "" {
+			fmt.Fprintf(&out, "# HELP %s %s\n", name, helpEscaper.Replace(metricFamily.help))
+		}
+		fmt.Fprintf(&out, "# TYPE %s %s\n", name, metricFamily.metricType)
+
+		for _, key := range slices.Sorted(maps.Keys(metricFamily.series)) {
+			metricSeries := metricFamily.series[key]
+
+			if metricFamily.metricType != HISTOGRAM_METRIC {
+				fmt.Fprintf(&out, "%s%s %s\n", name, formatLabels(metricSeries.labels), formatValue(metricSeries.value))
+				continue
+			}
+
+			for bucket, bound := range metricFamily.bounds {
+				labels := append(slices.Clone(metricSeries.labels), Label{"le", formatValue(bound)})
+				fmt.Fprintf(&out, "%s_bucket%s %d\n", name, formatLabels(labels), metricSeries.buckets[bucket])
+			}
+
+			labels := append(slices.Clone(metricSeries.labels), Label{"le", "+Inf"})
+			fmt.Fprintf(&out, "%s_bucket%s %d\n", name, formatLabels(labels), metricSeries.count)
+			fmt.Fprintf(&out, "%s_sum%s %s\n", name, formatLabels(metricSeries.labels), formatValue(metricSeries.sum))
+			fmt.Fprintf(&out, "%s_count%s %d\n", name, formatLabels(metricSeries.labels), metricSeries.count)
+		}
+	}
+
+	_, err := io.WriteString(writer, out.String())
+
+	return err
+}
+
+// ServeHTTP exposes metrics to Prometheus scraper
+func (registry *Registry) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	response.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	if err := registry.Write(response); err != nil {
+		http.Error(response, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (registry *Registry) family(name string, metricType string) *family {
+	metricFamily, ok := registry.families[name]
+
+	if !ok {
+		metricFamily = &family{bounds: DEFAULT_BUCKETS, series: make(map[string]*series)}
+		registry.families[name] = metricFamily
+	}
+
+	if metricFamily.metricType == "" {
+		metricFamily.metricType = metricType
+	}
+
+	return metricFamily
+}
+
+func (metricFamily *family) get(labels []Label) *series {
+	key := formatLabels(labels)
+
+	metricSeries, ok := metricFamily.series[key]
+	if !ok {
+		metricSeries = &series{labels: slices.Clone(labels)}
+		metricFamily.series[key] = metricSeries
+	}
+
+	return metricSeries
+}
+
+func formatLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	formattedLabels := make([]string, len(labels))
+
+	for idx, label := range labels {
+		formattedLabels[idx] = label.Name + `="` + labelValueEscaper.Replace(label.Value) + `"`
+	}
+
+	return "{" + strings.Join(formattedLabels, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
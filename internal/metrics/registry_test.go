@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func writeRegistry(t *testing.T, registry *Registry) string {
+	t.Helper()
+	var out strings.Builder
+	if err := registry.Write(&out); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	return out.String()
+}
+
+func TestRegistry_Counter_Accumulates(t *testing.T) {
+	registry := NewRegistry()
+	registry.AddCounter("db_aborts_total", 1, Label{"reason", "conflict"})
+	registry.AddCounter("db_aborts_total", 2, Label{"reason", "conflict"})
+
+	out := writeRegistry(t, registry)
+	if !strings.Contains(out, "# TYPE db_aborts_total counter\n") {
+		t.Errorf("expected counter type line, got:\n%s", out)
+	}
+	if !strings.Contains(out, `db_aborts_total{reason="conflict"} 3`+"\n") {
+		t.Errorf("expected accumulated counter value, got:\n%s", out)
+	}
+}
+
+func TestRegistry_Counter_SeparatesLabels(t *testing.T) {
+	registry := NewRegistry()
+	registry.AddCounter("db_aborts_total", 1, Label{"reason", "conflict"})
+	registry.AddCounter("db_aborts_total", 1, Label{"reason", "error"})
+
+	out := writeRegistry(t, registry)
+	if !strings.Contains(out, `db_aborts_total{reason="conflict"} 1`) || !strings.Contains(out, `db_aborts_total{reason="error"} 1`) {
+		t.Errorf("expected separate series per label value, got:\n%s", out)
+	}
+}
+
+func TestRegistry_Gauge_Overwrites(t *testing.T) {
+	registry := NewRegistry()
+	registry.SetGauge("db_active_transactions", 5)
+	registry.SetGauge("db_active_transactions", 2)
+
+	out := writeRegistry(t, registry)
+	if !strings.Contains(out, "# TYPE db_active_transactions gauge\n") {
+		t.Errorf("expected gauge type line, got:\n%s", out)
+	}
+	if !strings.Contains(out, "db_active_transactions 2\n") {
+		t.Errorf("expected last gauge value, got:\n%s", out)
+	}
+}
+
+func TestRegistry_Histogram_CumulativeBuckets(t *testing.T) {
+	registry := NewRegistry()
+	registry.Describe("db_commit_batch_size", "Number of transactions in commit batch", 1, 10, 100)
+	registry.ObserveHistogram("db_commit_batch_size", 1)
+	registry.ObserveHistogram("db_commit_batch_size", 5)
+	registry.ObserveHistogram("db_commit_batch_size", 500)
+
+	out := writeRegistry(t, registry)
+	for _, line := range []string{
+		"# HELP db_commit_batch_size Number of transactions in commit batch\n",
+		"# TYPE db_commit_batch_size histogram\n",
+		`db_commit_batch_size_bucket{le="1"} 1` + "\n",
+		`db_commit_batch_size_bucket{le="10"} 2` + "\n",
+		`db_commit_batch_size_bucket{le="100"} 2` + "\n",
+		`db_commit_batch_size_bucket{le="+Inf"} 3` + "\n",
+		"db_commit_batch_size_sum 506\n",
+		"db_commit_batch_size_count 3\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("expected line %q, got:\n%s", line, out)
+		}
+	}
+}
+
+func TestRegistry_Histogram_RedescribedWithObservations_KeepsBounds(t *testing.T) {
+	registry := NewRegistry()
+	registry.Describe("db_commit_batch_size", "Number of transactions in commit batch", 1, 10)
+	registry.ObserveHistogram("db_commit_batch_size", 5)
+	registry.Describe("db_commit_batch_size", "Number of transactions in commit batch", 1, 2, 5, 10, 20)
+	registry.ObserveHistogram("db_commit_batch_size", 15)
+
+	out := writeRegistry(t, registry)
+	for _, line := range []string{
+		`db_commit_batch_size_bucket{le="1"} 0` + "\n",
+		`db_commit_batch_size_bucket{le="10"} 1` + "\n",
+		`db_commit_batch_size_bucket{le="+Inf"} 2` + "\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("expected line %q, got:\n%s", line, out)
+		}
+	}
+	if strings.Contains(out, `le="20"`) {
+		t.Errorf("expected bounds of observed histogram to be kept, got:\n%s", out)
+	}
+}
+
+func TestRegistry_EscapesLabelValues(t *testing.T) {
+	registry := NewRegistry()
+	registry.AddCounter("db_aborts_total", 1, Label{"reason", "quote\" and \\ slash\n"})
+
+	out := writeRegistry(t, registry)
+	if !strings.Contains(out, `db_aborts_total{reason="quote\" and \\ slash\n"} 1`) {
+		t.Errorf("expected escaped label value, got:\n%s", out)
+	}
+}
+
+func TestRegistry_Describe_WithoutMeasurements_IsSkipped(t *testing.T) {
+	registry := NewRegistry()
+	registry.Describe("db_unused", "Never measured")
+
+	if out := writeRegistry(t, registry); out != "" {
+		t.Errorf("expected empty output, got:\n%s", out)
+	}
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	registry := NewRegistry()
+	registry.SetGauge("db_file_size_bytes", 4096)
+
+	recorder := httptest.NewRecorder()
+	registry.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("unexpected content type %q", recorder.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(recorder.Body.String(), "db_file_size_bytes 4096\n") {
+		t.Errorf("expected metric in response body, got:\n%s", recorder.Body.String())
+	}
+}
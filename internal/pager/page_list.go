@@ -106,7 +106,7 @@ func (list PageList) Pages() []PageInterval {
 }
 
 func (list PageList) Empty() bool {
-	return list.intervals.Len() == 0
+	return list.intervals == nil || list.intervals.Len() == 0
 }
 
 func (list PageList) addInterval(interval PageInterval) {
@@ -15,11 +15,18 @@ type PagerConfig struct {
 	pageSize PageSize
 }
 
+type PagerStats struct {
+	AllocatedPages uint64 // Number of pages appended to the end of storage
+	ReusedPages    uint64 // Number of pages taken from reusable pages
+	RetiredPages   uint64 // Number of freed pages which are still visible to other pager instances
+}
+
 type PagerState struct {
 	PagesCount    PagesCount             // Number of all pages in the pager
 	OwnedPages    PageList               // Pages owned by this pager and safe for in-place mutation.
 	ReusablePages PageList               // Pages not currently in use but owned by this pager.
 	RetiredPages  PageList               // Pages no longer owned by this pager instance.
+	Stats         PagerStats             // Page allocation counters since pager creation
 	pageUpdates   map[PagePointer][]byte // Map of page updates that will be synced with storage
 }
 
@@ -75,11 +82,13 @@ func (pager *Pager) CreatePage(data []byte) PagePointer {
 
 	if availablePage, ok := pager.state.ReusablePages.Pop(); ok {
 		pagePointer = availablePage
+		pager.state.Stats.ReusedPages++
 	} else {
 		pagePointer = pager.state.PagesCount
 		pager.state.OwnedPages.Add(pagePointer)
 
 		pager.state.PagesCount++
+		pager.state.Stats.AllocatedPages++
 	}
 
 	pager.state.pageUpdates[pagePointer] = data
@@ -92,6 +101,7 @@ func (pager *Pager) FreePage(pointer PagePointer) {
 		pager.state.ReusablePages.Add(pointer)
 	} else {
 		pager.state.RetiredPages.Add(pointer)
+		pager.state.Stats.RetiredPages++
 	}
 
 	delete(pager.state.pageUpdates, pointer)
@@ -105,6 +115,10 @@ func (pager *Pager) ReusablePages() PageList {
 	return pager.state.ReusablePages
 }
 
+func (pager *Pager) Stats() PagerStats {
+	return pager.state.Stats
+}
+
 func (pager *Pager) PagesCount() uint64 {
 	return pager.state.PagesCount
 }
@@ -132,25 +146,31 @@ func (pager *Pager) SaveChanges() error {
 }
 
 func (pager *Pager) Snapshot() PagerState {
-	pageUpdates := make(map[PagePointer][]byte, len(pager.state.pageUpdates))
-
-	for pointer, page := range pager.state.pageUpdates {
-		pageUpdates[pointer] = page
-	}
-
-	return PagerState{
-		PagesCount:    pager.state.PagesCount,
-		OwnedPages:    pager.state.OwnedPages.Clone(),
-		ReusablePages: pager.state.ReusablePages.Clone(),
-		RetiredPages:  pager.state.RetiredPages.Clone(),
-		pageUpdates:   pageUpdates,
-	}
+	return pager.state.clone()
 }
 
+// Restore resets pager to the snapshot state. Snapshot stays untouched, so it can be restored again later.
 func (pager *Pager) Restore(state PagerState) {
-	pager.state = state
+	pager.state = state.clone()
 }
 
 func (pager *Pager) Fork(nextPageID PagePointer, mutable ...PageList) *Pager {
 	return NewPager(pager.storage, nextPageID, pager.config.pageSize, mutable...)
 }
+
+func (state PagerState) clone() PagerState {
+	pageUpdates := make(map[PagePointer][]byte, len(state.pageUpdates))
+
+	for pointer, page := range state.pageUpdates {
+		pageUpdates[pointer] = page
+	}
+
+	return PagerState{
+		PagesCount:    state.PagesCount,
+		OwnedPages:    state.OwnedPages.Clone(),
+		ReusablePages: state.ReusablePages.Clone(),
+		RetiredPages:  state.RetiredPages.Clone(),
+		Stats:         state.Stats,
+		pageUpdates:   pageUpdates,
+	}
+}
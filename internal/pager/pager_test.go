@@ -209,6 +209,38 @@ func TestPager_FreePage_NonOwnedPageMovesToRetired(t *testing.T) {
 	}
 }
 
+func TestPager_Stats_CountsAllocatedReusedAndRetiredPages(t *testing.T) {
+	p := NewPager(makeStorage(), 2, testPageSize)
+
+	ptr := p.CreatePage(pageData("first page"))
+	p.FreePage(ptr)
+	p.CreatePage(pageData("reused page"))
+	p.FreePage(0)
+
+	stats := p.Stats()
+	if stats.AllocatedPages != 1 {
+		t.Errorf("expected 1 allocated page, got %d", stats.AllocatedPages)
+	}
+	if stats.ReusedPages != 1 {
+		t.Errorf("expected 1 reused page, got %d", stats.ReusedPages)
+	}
+	if stats.RetiredPages != 1 {
+		t.Errorf("expected 1 retired page, got %d", stats.RetiredPages)
+	}
+}
+
+func TestPager_Stats_RevertedByRestore(t *testing.T) {
+	p := NewPager(makeStorage(), 1, testPageSize)
+	snapshot := p.Snapshot()
+
+	p.CreatePage(pageData("first page"))
+	p.Restore(snapshot)
+
+	if stats := p.Stats(); stats.AllocatedPages != 0 {
+		t.Errorf("expected allocated pages to be reverted, got %d", stats.AllocatedPages)
+	}
+}
+
 func TestPager_FreePage_RemovesFromPageUpdates(t *testing.T) {
 	p := NewPager(makeStorage(), 1, testPageSize)
 	ptr := p.CreatePage(pageData("first page"))
@@ -353,3 +385,21 @@ func TestPager_Snapshot_OwnedPagesIsIndependent(t *testing.T) {
 		t.Errorf("snapshot OwnedPages was mutated to include page %d", ptr)
 	}
 }
+
+func TestPager_Restore_SnapshotCanBeRestoredTwice(t *testing.T) {
+	p := NewPager(makeStorage(), 1, testPageSize)
+	snap := p.Snapshot()
+
+	p.CreatePage(pageData("first page"))
+	p.Restore(snap)
+
+	ptr := p.CreatePage(pageData("second page"))
+	p.Restore(snap)
+
+	if p.PagesCount() != snap.PagesCount {
+		t.Errorf("expected PagesCount %d after second restore, got %d", snap.PagesCount, p.PagesCount())
+	}
+	if snap.OwnedPages.Has(ptr) {
+		t.Errorf("snapshot OwnedPages was mutated after restore to include page %d", ptr)
+	}
+}
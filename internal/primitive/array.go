@@ -0,0 +1,40 @@
+package primitive
+
+type Array struct {
+	elements []Primitive
+}
+
+func (value *Array) Value() []Primitive      { return value.elements }
+func (value *Array) Len() int                { return len(value.elements) }
+func (value *Array) Get(index int) Primitive { return value.elements[index] }
+func (value *Array) Type() PrimitiveType     { return TYPE_ARRAY }
+func (value *Array) Empty() bool             { return false }
+
+func (value *Array) Append(elements ...Primitive) *Array {
+	value.elements = append(value.elements, elements...)
+
+	return value
+}
+
+func (value *Array) Equal(other Primitive) bool {
+	if other.Type() != TYPE_ARRAY {
+		return false
+	}
+
+	otherArray := other.(*Array)
+	if len(value.elements) != len(otherArray.elements) {
+		return false
+	}
+
+	for index, element := range value.elements {
+		if !element.Equal(otherArray.elements[index]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func NewArray(elements ...Primitive) *Array {
+	return &Array{elements}
+}
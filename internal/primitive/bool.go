@@ -0,0 +1,21 @@
+package primitive
+
+type Bool struct {
+	flag bool
+}
+
+func (value *Bool) Value() bool         { return value.flag }
+func (value *Bool) Type() PrimitiveType { return TYPE_BOOL }
+func (value *Bool) Empty() bool         { return false }
+
+func (value *Bool) Equal(other Primitive) bool {
+	if other.Type() != TYPE_BOOL {
+		return false
+	}
+
+	return value.flag == other.(*Bool).flag
+}
+
+func NewBool(value bool) *Bool {
+	return &Bool{value}
+}
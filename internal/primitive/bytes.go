@@ -0,0 +1,23 @@
+package primitive
+
+import "bytes"
+
+type Bytes struct {
+	data []byte
+}
+
+func (value *Bytes) Value() []byte       { return value.data }
+func (value *Bytes) Type() PrimitiveType { return TYPE_BYTES }
+func (value *Bytes) Empty() bool         { return false }
+
+func (value *Bytes) Equal(other Primitive) bool {
+	if other.Type() != TYPE_BYTES {
+		return false
+	}
+
+	return bytes.Equal(value.data, other.(*Bytes).data)
+}
+
+func NewBytes(value []byte) *Bytes {
+	return &Bytes{bytes.Clone(value)}
+}
@@ -0,0 +1,67 @@
+package primitive
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const DATE_FORMAT = time.DateOnly // RFC 3339 full-date
+const SECONDS_PER_DAY = 24 * 60 * 60
+
+// Date is a calendar date without time of day and time zone
+type Date struct {
+	days int32 // Days since Unix epoch
+}
+
+func (value *Date) Days() int32         { return value.days }
+func (value *Date) Type() PrimitiveType { return TYPE_DATE }
+func (value *Date) Empty() bool         { return false }
+
+// Value returns midnight of the date in UTC
+func (value *Date) Value() time.Time {
+	return time.Unix(int64(value.days)*SECONDS_PER_DAY, 0).UTC()
+}
+
+func (value *Date) Equal(other Primitive) bool {
+	if other.Type() != TYPE_DATE {
+		return false
+	}
+
+	return value.days == other.(*Date).days
+}
+
+func (value *Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(value.Value().Format(DATE_FORMAT))
+}
+
+func (value *Date) UnmarshalJSON(data []byte) error {
+	var formatted string
+	if err := json.Unmarshal(data, &formatted); err != nil {
+		return fmt.Errorf("Date: couldn't unmarshal value: %w", err)
+	}
+
+	parsed, err := time.Parse(DATE_FORMAT, formatted)
+	if err != nil {
+		return fmt.Errorf("Date: couldn't parse RFC 3339 full-date %q: %w", formatted, err)
+	}
+
+	*value = *NewDate(parsed.Year(), parsed.Month(), parsed.Day())
+	return nil
+}
+
+// NewDate creates date normalizing out of range month and day the same way as time.Date
+func NewDate(year int, month time.Month, day int) *Date {
+	midnight := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	return NewDateFromDays(int32(midnight.Unix() / SECONDS_PER_DAY))
+}
+
+// NewDateOf creates date of the given time in its own time zone
+func NewDateOf(value time.Time) *Date {
+	return NewDate(value.Year(), value.Month(), value.Day())
+}
+
+func NewDateFromDays(days int32) *Date {
+	return &Date{days}
+}
@@ -0,0 +1,210 @@
+package primitive
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact number of arbitrary precision, its value is unscaled * 10^-scale.
+// Scale is a part of the value, so 1.0 and 1.00 are different decimals which compare as equal numbers by Cmp.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int32
+}
+
+var bigTen = big.NewInt(10)
+
+func (value *Decimal) Type() PrimitiveType { return TYPE_DECIMAL }
+func (value *Decimal) Empty() bool         { return false }
+func (value *Decimal) Scale() int32        { return value.scale }
+func (value *Decimal) Sign() int           { return value.unscaled.Sign() }
+
+func (value *Decimal) Unscaled() *big.Int {
+	return new(big.Int).Set(value.unscaled)
+}
+
+func (value *Decimal) Value() *big.Rat {
+	rat := new(big.Rat).SetInt(value.unscaled)
+
+	if value.scale > 0 {
+		return rat.Quo(rat, new(big.Rat).SetInt(pow10(value.scale)))
+	}
+
+	return rat.Mul(rat, new(big.Rat).SetInt(pow10(-value.scale)))
+}
+
+func (value *Decimal) Equal(other Primitive) bool {
+	if other.Type() != TYPE_DECIMAL {
+		return false
+	}
+
+	otherDecimal := other.(*Decimal)
+
+	return value.scale == otherDecimal.scale && value.unscaled.Cmp(otherDecimal.unscaled) == 0
+}
+
+// Cmp compares numeric values ignoring scale and returns -1, 0 or +1
+func (value *Decimal) Cmp(other *Decimal) int {
+	left, right := alignScales(value, other)
+
+	return left.Cmp(right)
+}
+
+func (value *Decimal) Add(other *Decimal) *Decimal {
+	left, right := alignScales(value, other)
+
+	return &Decimal{new(big.Int).Add(left, right), max(value.scale, other.scale)}
+}
+
+func (value *Decimal) Sub(other *Decimal) *Decimal {
+	left, right := alignScales(value, other)
+
+	return &Decimal{new(big.Int).Sub(left, right), max(value.scale, other.scale)}
+}
+
+func (value *Decimal) Mul(other *Decimal) *Decimal {
+	return &Decimal{new(big.Int).Mul(value.unscaled, other.unscaled), value.scale + other.scale}
+}
+
+func (value *Decimal) Neg() *Decimal {
+	return &Decimal{new(big.Int).Neg(value.unscaled), value.scale}
+}
+
+// Quo divides value by other and rounds the result to the given scale, half away from zero
+func (value *Decimal) Quo(other *Decimal, scale int32) (*Decimal, error) {
+	if other.Sign() == 0 {
+		return nil, fmt.Errorf("Decimal: division by zero")
+	}
+
+	return NewDecimalFromRat(new(big.Rat).Quo(value.Value(), other.Value()), scale), nil
+}
+
+// Round returns value with the given scale, rounding half away from zero when scale is reduced
+func (value *Decimal) Round(scale int32) *Decimal {
+	if scale >= value.scale {
+		return &Decimal{new(big.Int).Mul(value.unscaled, pow10(scale-value.scale)), scale}
+	}
+
+	return NewDecimalFromRat(value.Value(), scale)
+}
+
+// String returns value in plain notation keeping all digits of the scale, e.g. "-12.50"
+func (value *Decimal) String() string {
+	if value.scale <= 0 {
+		return new(big.Int).Mul(value.unscaled, pow10(-value.scale)).String()
+	}
+
+	digits := new(big.Int).Abs(value.unscaled).String()
+	if len(digits) <= int(value.scale) {
+		digits = strings.Repeat("0", int(value.scale)-len(digits)+1) + digits
+	}
+
+	sign := ""
+	if value.unscaled.Sign() < 0 {
+		sign = "-"
+	}
+
+	point := len(digits) - int(value.scale)
+
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// MarshalJSON writes decimal as a string, because JSON numbers are usually decoded as floats
+func (value *Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(value.String())
+}
+
+// UnmarshalJSON accepts both strings and numbers
+func (value *Decimal) UnmarshalJSON(data []byte) error {
+	formatted := strings.Trim(string(data), `"`)
+
+	parsed, err := ParseDecimal(formatted)
+	if err != nil {
+		return err
+	}
+
+	*value = *parsed
+	return nil
+}
+
+// ParseDecimal parses decimal in plain or exponent notation, e.g. "12.50", "-0.001" or "1.5e3"
+func ParseDecimal(formatted string) (*Decimal, error) {
+	mantissa, exponent := formatted, int64(0)
+
+	if idx := strings.IndexAny(formatted, "eE"); idx >= 0 {
+		var err error
+		if exponent, err = strconv.ParseInt(formatted[idx+1:], 10, 32); err != nil {
+			return nil, fmt.Errorf("Decimal: couldn't parse exponent of %q: %w", formatted, err)
+		}
+		mantissa = formatted[:idx]
+	}
+
+	scale := int64(0)
+	if idx := strings.IndexByte(mantissa, '.'); idx >= 0 {
+		scale = int64(len(mantissa) - idx - 1)
+		mantissa = mantissa[:idx] + mantissa[idx+1:]
+	}
+
+	unscaled, ok := new(big.Int).SetString(mantissa, 10)
+	if !ok {
+		return nil, fmt.Errorf("Decimal: couldn't parse %q", formatted)
+	}
+
+	scale -= exponent
+	if scale < -(1<<31) || scale > 1<<31-1 {
+		return nil, fmt.Errorf("Decimal: scale of %q is out of range", formatted)
+	}
+
+	return &Decimal{unscaled, int32(scale)}, nil
+}
+
+func NewDecimal(unscaled *big.Int, scale int32) *Decimal {
+	return &Decimal{new(big.Int).Set(unscaled), scale}
+}
+
+func NewDecimalFromInt(value int64) *Decimal {
+	return &Decimal{big.NewInt(value), 0}
+}
+
+// NewDecimalFromRat converts rational number to decimal with the given scale, rounding half away from zero
+func NewDecimalFromRat(value *big.Rat, scale int32) *Decimal {
+	numerator := new(big.Int).Set(value.Num())
+	denominator := new(big.Int).Set(value.Denom())
+
+	if scale >= 0 {
+		numerator.Mul(numerator, pow10(scale))
+	} else {
+		denominator.Mul(denominator, pow10(-scale))
+	}
+
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+
+	if remainder.Sign() != 0 && new(big.Int).Abs(new(big.Int).Lsh(remainder, 1)).Cmp(denominator) >= 0 {
+		if numerator.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+
+	return &Decimal{quotient, scale}
+}
+
+// alignScales returns unscaled values of both decimals brought to the larger scale
+func alignScales(left *Decimal, right *Decimal) (*big.Int, *big.Int) {
+	switch {
+	case left.scale > right.scale:
+		return left.unscaled, new(big.Int).Mul(right.unscaled, pow10(left.scale-right.scale))
+	case left.scale < right.scale:
+		return new(big.Int).Mul(left.unscaled, pow10(right.scale-left.scale)), right.unscaled
+	default:
+		return left.unscaled, right.unscaled
+	}
+}
+
+func pow10(exponent int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(exponent)), nil)
+}
@@ -0,0 +1,112 @@
+package primitive
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func mustParseDecimal(t *testing.T, formatted string) *Decimal {
+	t.Helper()
+	value, err := ParseDecimal(formatted)
+	if err != nil {
+		t.Fatalf("ParseDecimal(%q) failed: %v", formatted, err)
+	}
+	return value
+}
+
+func TestParseDecimal_String(t *testing.T) {
+	cases := map[string]string{
+		"12.50":  "12.50",
+		"-0.001": "-0.001",
+		".5":     "0.5",
+		"+7":     "7",
+		"1.5e3":  "1500",
+		"25e-4":  "0.0025",
+		"-1E+2":  "-100",
+	}
+
+	for formatted, expected := range cases {
+		if actual := mustParseDecimal(t, formatted).String(); actual != expected {
+			t.Errorf("ParseDecimal(%q).String() = %q, expected %q", formatted, actual, expected)
+		}
+	}
+}
+
+func TestParseDecimal_Invalid(t *testing.T) {
+	for _, formatted := range []string{"", "abc", "1.2.3", "1e", "1_000", "1e99999999999"} {
+		if _, err := ParseDecimal(formatted); err == nil {
+			t.Errorf("expected error for %q", formatted)
+		}
+	}
+}
+
+func TestDecimal_ScaleIsPartOfValue(t *testing.T) {
+	one, oneWithScale := mustParseDecimal(t, "1.0"), mustParseDecimal(t, "1.00")
+
+	if one.Equal(oneWithScale) {
+		t.Error("expected decimals with different scale not to be Equal")
+	}
+	if one.Cmp(oneWithScale) != 0 {
+		t.Error("expected decimals with different scale to be numerically equal")
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	price, quantity := mustParseDecimal(t, "19.99"), mustParseDecimal(t, "3")
+
+	if sum := price.Add(mustParseDecimal(t, "0.011")); sum.String() != "20.001" {
+		t.Errorf("expected 20.001, got %s", sum)
+	}
+	if difference := price.Sub(mustParseDecimal(t, "20")); difference.String() != "-0.01" {
+		t.Errorf("expected -0.01, got %s", difference)
+	}
+	if product := price.Mul(quantity); product.String() != "59.97" {
+		t.Errorf("expected 59.97, got %s", product)
+	}
+	if negated := price.Neg(); negated.String() != "-19.99" {
+		t.Errorf("expected -19.99, got %s", negated)
+	}
+
+	quotient, err := price.Quo(quantity, 2)
+	if err != nil {
+		t.Fatalf("Quo failed: %v", err)
+	}
+	if quotient.String() != "6.66" {
+		t.Errorf("expected 6.66, got %s", quotient)
+	}
+
+	if _, err := price.Quo(NewDecimalFromInt(0), 2); err == nil {
+		t.Error("expected division by zero error")
+	}
+}
+
+func TestDecimal_Round_HalfAwayFromZero(t *testing.T) {
+	cases := map[string]string{"2.345": "2.35", "-2.345": "-2.35", "2.344": "2.34", "2.3": "2.30"}
+
+	for formatted, expected := range cases {
+		if actual := mustParseDecimal(t, formatted).Round(2).String(); actual != expected {
+			t.Errorf("Round(%s) = %s, expected %s", formatted, actual, expected)
+		}
+	}
+}
+
+func TestDecimal_JSON(t *testing.T) {
+	encoded, err := json.Marshal(mustParseDecimal(t, "12.50"))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(encoded) != `"12.50"` {
+		t.Errorf("unexpected JSON %s", encoded)
+	}
+
+	decoded := &Decimal{}
+	for _, data := range []string{`"12.50"`, `12.50`} {
+		if err := json.Unmarshal([]byte(data), decoded); err != nil {
+			t.Fatalf("Unmarshal(%s) failed: %v", data, err)
+		}
+		if !decoded.Equal(NewDecimal(big.NewInt(1250), 2)) {
+			t.Errorf("expected 12.50, got %s", decoded)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package primitive
+
+import "math"
+
+const CANONICAL_NAN_BITS = 0x7FF8000000000000 // Quiet NaN without payload
+
+type Float64 struct {
+	num float64
+}
+
+func (value *Float64) Value() float64      { return value.num }
+func (value *Float64) Type() PrimitiveType { return TYPE_FLOAT64 }
+func (value *Float64) Empty() bool         { return false }
+
+// Equal compares canonical representations, so -0 equals +0 and NaN equals NaN the same way as their encoded keys do
+func (value *Float64) Equal(other Primitive) bool {
+	if other.Type() != TYPE_FLOAT64 {
+		return false
+	}
+
+	return value.Bits() == other.(*Float64).Bits()
+}
+
+// Bits returns IEEE 754 representation with -0 folded into +0 and all NaNs folded into a single quiet NaN
+func (value *Float64) Bits() uint64 {
+	switch {
+	case math.IsNaN(value.num):
+		return CANONICAL_NAN_BITS
+	case value.num == 0:
+		return 0
+	default:
+		return math.Float64bits(value.num)
+	}
+}
+
+func NewFloat64(value float64) *Float64 {
+	return &Float64{value}
+}
@@ -1,5 +1,13 @@
 package primitive
 
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const PATH_SEPARATOR = "."
+
 type Object struct {
 	fields map[string]Primitive
 }
@@ -15,7 +23,7 @@ func (object *Object) GetMany(fields []string) []Primitive {
 }
 
 func (object *Object) Has(field string) bool {
-	_, ok := object.fields[field]
+	_, ok := object.lookup(field)
 
 	return ok
 }
@@ -26,8 +34,35 @@ func (object *Object) Set(field string, value Primitive) *Object {
 	return object
 }
 
+func (object *Object) Delete(field string) *Object {
+	delete(object.fields, field)
+
+	return object
+}
+
+// SetPath sets value by dot separated path creating missing intermediate objects, e.g. SetPath("address.city", value)
+func (object *Object) SetPath(path string, value Primitive) *Object {
+	parent := object
+	segments := strings.Split(path, PATH_SEPARATOR)
+
+	for _, segment := range segments[:len(segments)-1] {
+		child, ok := parent.fields[segment].(*Object)
+		if !ok {
+			child = NewObject()
+			parent.fields[segment] = child
+		}
+
+		parent = child
+	}
+
+	parent.fields[segments[len(segments)-1]] = value
+
+	return object
+}
+
+// Get returns field value, field can be a dot separated path to nested objects and array elements, e.g. "items.0.price"
 func (object *Object) Get(field string) Primitive {
-	value, ok := object.fields[field]
+	value, ok := object.lookup(field)
 
 	if ok {
 		return value
@@ -36,6 +71,39 @@ func (object *Object) Get(field string) Primitive {
 	}
 }
 
+func (object *Object) lookup(field string) (Primitive, bool) {
+	if value, ok := object.fields[field]; ok { // Field names containing separator take precedence over paths
+		return value, true
+	}
+
+	if !strings.Contains(field, PATH_SEPARATOR) {
+		return nil, false
+	}
+
+	var value Primitive = object
+	for _, segment := range strings.Split(field, PATH_SEPARATOR) {
+		switch container := value.(type) {
+		case *Object:
+			nested, ok := container.fields[segment]
+			if !ok {
+				return nil, false
+			}
+			value = nested
+		case *Array:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= container.Len() {
+				return nil, false
+			}
+			value = container.Get(index)
+		default:
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// Merge returns a new object with fields of other applied on top, nested objects are merged recursively
 func (object *Object) Merge(other *Object) *Object {
 	mergedObject := NewObject()
 
@@ -48,18 +116,54 @@ func (object *Object) Merge(other *Object) *Object {
 	}
 
 	for field, value := range other.Values() {
-		mergedObject.Set(field, value)
+		currentObject, currentIsObject := mergedObject.fields[field].(*Object)
+		otherObject, otherIsObject := value.(*Object)
+
+		if currentIsObject && otherIsObject {
+			mergedObject.Set(field, currentObject.Merge(otherObject))
+		} else {
+			mergedObject.Set(field, value)
+		}
 	}
 	return mergedObject
 }
 
+// Matches reports whether other contains all fields of the object, nested objects are matched partially as well
 func (object *Object) Matches(other *Object) bool {
 	if other == nil {
 		return false
 	}
 
 	for name, value := range object.fields {
-		if !other.Get(name).Equal(value) {
+		otherValue := other.Get(name)
+
+		if nestedObject, ok := value.(*Object); ok {
+			if otherObject, ok := otherValue.(*Object); !ok || !nestedObject.Matches(otherObject) {
+				return false
+			}
+		} else if !otherValue.Equal(value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (object *Object) Type() PrimitiveType { return TYPE_OBJECT }
+func (object *Object) Empty() bool         { return false }
+
+func (object *Object) Equal(other Primitive) bool {
+	if other.Type() != TYPE_OBJECT {
+		return false
+	}
+
+	otherObject := other.(*Object)
+	if len(object.fields) != len(otherObject.fields) {
+		return false
+	}
+
+	for name, value := range object.fields {
+		if otherValue, ok := otherObject.fields[name]; !ok || !value.Equal(otherValue) {
 			return false
 		}
 	}
@@ -87,6 +191,42 @@ func (object *Object) GetInt64(field string) int64 {
 	return object.Get(field).(*Int64).Value()
 }
 
+func (object *Object) GetBool(field string) bool {
+	return object.Get(field).(*Bool).Value()
+}
+
+func (object *Object) GetFloat64(field string) float64 {
+	return object.Get(field).(*Float64).Value()
+}
+
+func (object *Object) GetBytes(field string) []byte {
+	return object.Get(field).(*Bytes).Value()
+}
+
+func (object *Object) GetTimestamp(field string) time.Time {
+	return object.Get(field).(*Timestamp).Value()
+}
+
+func (object *Object) GetDate(field string) time.Time {
+	return object.Get(field).(*Date).Value()
+}
+
+func (object *Object) GetDecimal(field string) *Decimal {
+	return object.Get(field).(*Decimal)
+}
+
+func (object *Object) GetUUID(field string) *UUID {
+	return object.Get(field).(*UUID)
+}
+
+func (object *Object) GetObject(field string) *Object {
+	return object.Get(field).(*Object)
+}
+
+func (object *Object) GetArray(field string) *Array {
+	return object.Get(field).(*Array)
+}
+
 func (object *Object) Values() map[string]Primitive {
 	return object.fields
 }
@@ -0,0 +1,99 @@
+package primitive
+
+import "testing"
+
+func newOrder() *Object {
+	return NewObject().
+		Set("id", NewUint64(1)).
+		Set("address", NewObject().Set("city", NewString("Berlin")).Set("zip", NewString("10115"))).
+		Set("items", NewArray(
+			NewObject().Set("price", NewUint32(10)),
+			NewObject().Set("price", NewUint32(20)),
+		))
+}
+
+func TestObject_Get_Path(t *testing.T) {
+	order := newOrder()
+
+	if !order.Get("address.city").Equal(NewString("Berlin")) {
+		t.Errorf("expected Berlin, got %v", order.Get("address.city"))
+	}
+	if !order.Get("items.1.price").Equal(NewUint32(20)) {
+		t.Errorf("expected 20, got %v", order.Get("items.1.price"))
+	}
+	for _, missing := range []string{"address.country", "items.2.price", "items.x", "id.value"} {
+		if !order.Get(missing).Empty() {
+			t.Errorf("expected Null for missing path %q", missing)
+		}
+	}
+	if !order.Has("address.zip") || order.Has("address.country") {
+		t.Error("expected Has to follow paths")
+	}
+}
+
+func TestObject_Get_DottedFieldNameTakesPrecedence(t *testing.T) {
+	object := NewObject().
+		Set("a.b", NewString("flat")).
+		Set("a", NewObject().Set("b", NewString("nested")))
+
+	if !object.Get("a.b").Equal(NewString("flat")) {
+		t.Errorf("expected flat field, got %v", object.Get("a.b"))
+	}
+}
+
+func TestObject_SetPath_CreatesIntermediateObjects(t *testing.T) {
+	object := NewObject().SetPath("address.geo.lat", NewInt32(52))
+
+	if !object.Get("address.geo.lat").Equal(NewInt32(52)) {
+		t.Errorf("expected 52, got %v", object.Get("address.geo.lat"))
+	}
+}
+
+func TestObject_Merge_Deep(t *testing.T) {
+	merged := newOrder().Merge(NewObject().Set("address", NewObject().Set("city", NewString("Hamburg"))))
+
+	if !merged.Get("address.city").Equal(NewString("Hamburg")) {
+		t.Errorf("expected Hamburg, got %v", merged.Get("address.city"))
+	}
+	if !merged.Get("address.zip").Equal(NewString("10115")) {
+		t.Errorf("expected zip to be kept by deep merge, got %v", merged.Get("address.zip"))
+	}
+}
+
+func TestObject_Merge_DoesNotMutateNestedObjects(t *testing.T) {
+	order := newOrder()
+	order.Merge(NewObject().Set("address", NewObject().Set("city", NewString("Hamburg"))))
+
+	if !order.Get("address.city").Equal(NewString("Berlin")) {
+		t.Errorf("expected original object to be untouched, got %v", order.Get("address.city"))
+	}
+}
+
+func TestObject_Matches_NestedPaths(t *testing.T) {
+	order := newOrder()
+
+	if !NewObject().Set("address.city", NewString("Berlin")).Matches(order) {
+		t.Error("expected path query to match")
+	}
+	if !NewObject().Set("address", NewObject().Set("city", NewString("Berlin"))).Matches(order) {
+		t.Error("expected nested query to match partially")
+	}
+	if NewObject().Set("address", NewObject().Set("city", NewString("Paris"))).Matches(order) {
+		t.Error("expected nested query with different value not to match")
+	}
+	if NewObject().Set("id", NewObject().Set("value", NewUint64(1))).Matches(order) {
+		t.Error("expected nested query not to match scalar field")
+	}
+}
+
+func TestObject_Equal(t *testing.T) {
+	if !newOrder().Equal(newOrder()) {
+		t.Error("expected equal objects")
+	}
+	if newOrder().Equal(newOrder().Set("id", NewUint64(2))) {
+		t.Error("expected objects with different fields not to be equal")
+	}
+	if NewArray(NewInt32(1)).Equal(NewArray(NewInt32(1), NewInt32(2))) {
+		t.Error("expected arrays of different length not to be equal")
+	}
+}
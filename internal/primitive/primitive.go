@@ -17,8 +17,44 @@ const (
 	TYPE_INT64
 	TYPE_UINT32
 	TYPE_UINT64
+	TYPE_BOOL
+	TYPE_FLOAT64
+	TYPE_BYTES
+	TYPE_TIMESTAMP
+	TYPE_DATE
+	TYPE_ARRAY
+	TYPE_OBJECT
+	TYPE_DECIMAL
+	TYPE_UUID
 )
 
+var typeNames = map[PrimitiveType]string{
+	TYPE_NULL:      "null",
+	TYPE_STRING:    "string",
+	TYPE_INT32:     "int32",
+	TYPE_INT64:     "int64",
+	TYPE_UINT32:    "uint32",
+	TYPE_UINT64:    "uint64",
+	TYPE_BOOL:      "bool",
+	TYPE_FLOAT64:   "float64",
+	TYPE_BYTES:     "bytes",
+	TYPE_TIMESTAMP: "timestamp",
+	TYPE_DATE:      "date",
+	TYPE_ARRAY:     "array",
+	TYPE_OBJECT:    "object",
+	TYPE_DECIMAL:   "decimal",
+	TYPE_UUID:      "uuid",
+}
+
+// TypeName returns human readable name of the type for error messages
+func TypeName(primitiveType PrimitiveType) string {
+	if name, ok := typeNames[primitiveType]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("unknown(%d)", primitiveType)
+}
+
 func New(primitiveType PrimitiveType) Primitive {
 	switch primitiveType {
 	case TYPE_NULL:
@@ -33,6 +69,24 @@ func New(primitiveType PrimitiveType) Primitive {
 		return &Uint32{}
 	case TYPE_UINT64:
 		return &Uint64{}
+	case TYPE_BOOL:
+		return &Bool{}
+	case TYPE_FLOAT64:
+		return &Float64{}
+	case TYPE_BYTES:
+		return &Bytes{}
+	case TYPE_TIMESTAMP:
+		return &Timestamp{}
+	case TYPE_DATE:
+		return &Date{}
+	case TYPE_ARRAY:
+		return &Array{}
+	case TYPE_OBJECT:
+		return NewObject()
+	case TYPE_DECIMAL:
+		return NewDecimalFromInt(0)
+	case TYPE_UUID:
+		return &UUID{}
 	default:
 		panic(fmt.Sprintf("Value: can`t create new value because of wrong type %d", primitiveType))
 	}
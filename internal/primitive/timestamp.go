@@ -0,0 +1,87 @@
+package primitive
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Timestamp is an instant with nanosecond precision and optional fixed time zone offset
+type Timestamp struct {
+	seconds int64  // Seconds since Unix epoch in UTC
+	nanos   uint32 // Nanoseconds within the second, always in [0, 999999999]
+	zoned   bool   // Whether the timestamp carries its own time zone offset
+	offset  int32  // Time zone offset in seconds east of UTC, 0 when timestamp isn't zoned
+}
+
+func (value *Timestamp) Type() PrimitiveType { return TYPE_TIMESTAMP }
+func (value *Timestamp) Empty() bool         { return false }
+func (value *Timestamp) Seconds() int64      { return value.seconds }
+func (value *Timestamp) Nanos() uint32       { return value.nanos }
+func (value *Timestamp) Zoned() bool         { return value.zoned }
+func (value *Timestamp) Offset() int32       { return value.offset }
+
+// Value returns the timestamp in its own time zone or in UTC if the timestamp isn't zoned
+func (value *Timestamp) Value() time.Time {
+	instant := time.Unix(value.seconds, int64(value.nanos))
+
+	if value.zoned {
+		return instant.In(time.FixedZone("", int(value.offset)))
+	}
+
+	return instant.UTC()
+}
+
+func (value *Timestamp) Equal(other Primitive) bool {
+	if other.Type() != TYPE_TIMESTAMP {
+		return false
+	}
+
+	return *value == *other.(*Timestamp)
+}
+
+func (value *Timestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(value.Value().Format(time.RFC3339Nano))
+}
+
+// UnmarshalJSON parses RFC 3339 timestamp, explicit offset (even +00:00) makes it zoned while "Z" suffix doesn't
+func (value *Timestamp) UnmarshalJSON(data []byte) error {
+	var formatted string
+	if err := json.Unmarshal(data, &formatted); err != nil {
+		return fmt.Errorf("Timestamp: couldn't unmarshal value: %w", err)
+	}
+
+	parsed, err := time.Parse(time.RFC3339Nano, formatted)
+	if err != nil {
+		return fmt.Errorf("Timestamp: couldn't parse RFC 3339 value %q: %w", formatted, err)
+	}
+
+	if strings.HasSuffix(formatted, "Z") || strings.HasSuffix(formatted, "z") {
+		*value = *NewTimestamp(parsed)
+	} else {
+		*value = *NewZonedTimestamp(parsed)
+	}
+
+	return nil
+}
+
+// NewTimestamp creates timestamp of the given instant without time zone
+func NewTimestamp(value time.Time) *Timestamp {
+	return NewTimestampFromParts(value.Unix(), uint32(value.Nanosecond()), false, 0)
+}
+
+// NewZonedTimestamp creates timestamp of the given instant which keeps time zone offset of the value
+func NewZonedTimestamp(value time.Time) *Timestamp {
+	_, offset := value.Zone()
+
+	return NewTimestampFromParts(value.Unix(), uint32(value.Nanosecond()), true, int32(offset))
+}
+
+func NewTimestampFromParts(seconds int64, nanos uint32, zoned bool, offset int32) *Timestamp {
+	if !zoned {
+		offset = 0
+	}
+
+	return &Timestamp{seconds: seconds, nanos: nanos, zoned: zoned, offset: offset}
+}
@@ -0,0 +1,111 @@
+package primitive
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimestamp_Value_KeepsZone(t *testing.T) {
+	zone := time.FixedZone("", 2*60*60)
+	instant := time.Date(2024, time.May, 1, 10, 0, 0, 5, zone)
+
+	zoned := NewZonedTimestamp(instant)
+	if _, offset := zoned.Value().Zone(); offset != 2*60*60 {
+		t.Errorf("expected offset 7200, got %d", offset)
+	}
+	if !zoned.Value().Equal(instant) {
+		t.Errorf("expected %v, got %v", instant, zoned.Value())
+	}
+
+	plain := NewTimestamp(instant)
+	if plain.Value().Location() != time.UTC {
+		t.Errorf("expected UTC location, got %v", plain.Value().Location())
+	}
+}
+
+func TestTimestamp_Equal(t *testing.T) {
+	instant := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	if !NewTimestamp(instant).Equal(NewTimestamp(instant)) {
+		t.Error("expected equal timestamps")
+	}
+	if NewTimestamp(instant).Equal(NewZonedTimestamp(instant)) {
+		t.Error("expected zoned and not zoned timestamps to differ")
+	}
+	if NewTimestamp(instant).Equal(NewInt64(instant.Unix())) {
+		t.Error("expected timestamp not to equal Int64")
+	}
+}
+
+func TestTimestamp_JSON_RFC3339(t *testing.T) {
+	instant := time.Date(2024, time.May, 1, 10, 0, 0, 123000000, time.FixedZone("", -7*60*60))
+
+	encoded, err := json.Marshal(NewZonedTimestamp(instant))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(encoded) != `"2024-05-01T10:00:00.123-07:00"` {
+		t.Errorf("unexpected JSON %s", encoded)
+	}
+
+	decoded := &Timestamp{}
+	if err := json.Unmarshal(encoded, decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !decoded.Equal(NewZonedTimestamp(instant)) {
+		t.Errorf("expected %v, got %v", instant, decoded.Value())
+	}
+
+	if err := json.Unmarshal([]byte(`"2024-05-01T17:00:00.123Z"`), decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.Zoned() {
+		t.Error("expected timestamp with Z suffix not to be zoned")
+	}
+
+	if err := json.Unmarshal([]byte(`"yesterday"`), decoded); err == nil {
+		t.Error("expected error for non RFC 3339 value")
+	}
+}
+
+func TestDate_NewDate_Normalizes(t *testing.T) {
+	if !NewDate(2023, time.February, 29).Equal(NewDate(2023, time.March, 1)) {
+		t.Error("expected February 29 2023 to be normalized to March 1")
+	}
+	if NewDate(1969, time.December, 31).Days() != -1 {
+		t.Errorf("expected -1 days, got %d", NewDate(1969, time.December, 31).Days())
+	}
+}
+
+func TestDate_NewDateOf_UsesOwnZone(t *testing.T) {
+	value := time.Date(2024, time.May, 1, 23, 30, 0, 0, time.FixedZone("", -3*60*60))
+	if !NewDateOf(value).Equal(NewDate(2024, time.May, 1)) {
+		t.Errorf("expected 2024-05-01, got %v", NewDateOf(value).Value())
+	}
+}
+
+func TestDate_JSON_FullDate(t *testing.T) {
+	encoded, err := json.Marshal(NewDate(2024, time.February, 29))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(encoded) != `"2024-02-29"` {
+		t.Errorf("unexpected JSON %s", encoded)
+	}
+
+	decoded := &Date{}
+	if err := json.Unmarshal(encoded, decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !decoded.Equal(NewDate(2024, time.February, 29)) {
+		t.Errorf("expected 2024-02-29, got %v", decoded.Value())
+	}
+}
+
+func TestObject_GetTimestamp(t *testing.T) {
+	instant := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	object := NewObject().Set("created_at", NewTimestamp(instant))
+	if !object.GetTimestamp("created_at").Equal(instant) {
+		t.Errorf("expected %v, got %v", instant, object.GetTimestamp("created_at"))
+	}
+}
@@ -0,0 +1,127 @@
+package primitive
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const UUID_SIZE = 16
+
+type UUID struct {
+	id [UUID_SIZE]byte
+}
+
+var uuidV7State struct {
+	lastMillis uint64
+	sequence   uint16 // 12-bit counter keeping UUIDs generated within the same millisecond monotonic
+	mu         sync.Mutex
+}
+
+func (value *UUID) Value() [UUID_SIZE]byte { return value.id }
+func (value *UUID) Type() PrimitiveType    { return TYPE_UUID }
+func (value *UUID) Empty() bool            { return false }
+func (value *UUID) Version() int           { return int(value.id[6] >> 4) }
+
+func (value *UUID) Equal(other Primitive) bool {
+	if other.Type() != TYPE_UUID {
+		return false
+	}
+
+	return value.id == other.(*UUID).id
+}
+
+// String returns canonical textual representation, e.g. "0190163d-8694-739b-aea5-966c26f8ad91"
+func (value *UUID) String() string {
+	var out [36]byte
+
+	hex.Encode(out[0:8], value.id[0:4])
+	out[8] = '-'
+	hex.Encode(out[9:13], value.id[4:6])
+	out[13] = '-'
+	hex.Encode(out[14:18], value.id[6:8])
+	out[18] = '-'
+	hex.Encode(out[19:23], value.id[8:10])
+	out[23] = '-'
+	hex.Encode(out[24:36], value.id[10:16])
+
+	return string(out[:])
+}
+
+func (value *UUID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(value.String())
+}
+
+func (value *UUID) UnmarshalJSON(data []byte) error {
+	var formatted string
+	if err := json.Unmarshal(data, &formatted); err != nil {
+		return fmt.Errorf("UUID: couldn't unmarshal value: %w", err)
+	}
+
+	parsed, err := ParseUUID(formatted)
+	if err != nil {
+		return err
+	}
+
+	*value = *parsed
+	return nil
+}
+
+// ParseUUID parses canonical textual representation of UUID
+func ParseUUID(formatted string) (*UUID, error) {
+	if len(formatted) != 36 || formatted[8] != '-' || formatted[13] != '-' || formatted[18] != '-' || formatted[23] != '-' {
+		return nil, fmt.Errorf("UUID: couldn't parse %q, expected xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx format", formatted)
+	}
+
+	value := &UUID{}
+	digits := formatted[0:8] + formatted[9:13] + formatted[14:18] + formatted[19:23] + formatted[24:36]
+
+	if _, err := hex.Decode(value.id[:], []byte(digits)); err != nil {
+		return nil, fmt.Errorf("UUID: couldn't parse %q: %w", formatted, err)
+	}
+
+	return value, nil
+}
+
+func NewUUID(id [UUID_SIZE]byte) *UUID {
+	return &UUID{id}
+}
+
+// NewUUIDv7 generates UUID version 7: 48-bit Unix timestamp in milliseconds followed by a 12-bit counter and random bits.
+// UUIDs generated by the process are strictly increasing, so they keep insertion order in B+tree keys.
+func NewUUIDv7() *UUID {
+	value := &UUID{}
+	if _, err := rand.Read(value.id[:]); err != nil {
+		panic(fmt.Sprintf("UUID: couldn't read random bytes: %v", err))
+	}
+
+	uuidV7State.mu.Lock()
+	millis := uint64(time.Now().UnixMilli())
+	if millis > uuidV7State.lastMillis {
+		uuidV7State.lastMillis = millis
+		uuidV7State.sequence = (uint16(value.id[6])<<8 | uint16(value.id[7])) & 0x07FF // Random start leaves room for the counter to grow
+	} else {
+		uuidV7State.sequence++
+		if uuidV7State.sequence > 0x0FFF { // Counter overflow borrows the next millisecond
+			uuidV7State.lastMillis++
+			uuidV7State.sequence = 0
+		}
+	}
+	millis, sequence := uuidV7State.lastMillis, uuidV7State.sequence
+	uuidV7State.mu.Unlock()
+
+	value.id[0] = byte(millis >> 40)
+	value.id[1] = byte(millis >> 32)
+	value.id[2] = byte(millis >> 24)
+	value.id[3] = byte(millis >> 16)
+	value.id[4] = byte(millis >> 8)
+	value.id[5] = byte(millis)
+	value.id[6] = 0x70 | byte(sequence>>8)&0x0F // Version 7
+	value.id[7] = byte(sequence)
+	value.id[8] = 0x80 | value.id[8]&0x3F // RFC 9562 variant
+
+	return value
+}
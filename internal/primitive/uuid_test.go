@@ -0,0 +1,64 @@
+package primitive
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestUUID_ParseString_RoundTrip(t *testing.T) {
+	formatted := "0190163d-8694-739b-aea5-966c26f8ad91"
+
+	value, err := ParseUUID(formatted)
+	if err != nil {
+		t.Fatalf("ParseUUID failed: %v", err)
+	}
+	if value.String() != formatted {
+		t.Errorf("expected %s, got %s", formatted, value)
+	}
+	if value.Version() != 7 {
+		t.Errorf("expected version 7, got %d", value.Version())
+	}
+}
+
+func TestUUID_Parse_Invalid(t *testing.T) {
+	for _, formatted := range []string{"", "0190163d8694739baea5966c26f8ad91", "0190163d-8694-739b-aea5-966c26f8ad9z"} {
+		if _, err := ParseUUID(formatted); err == nil {
+			t.Errorf("expected error for %q", formatted)
+		}
+	}
+}
+
+func TestNewUUIDv7_VersionVariantAndTimestamp(t *testing.T) {
+	before := time.Now().UnixMilli()
+	value := NewUUIDv7()
+	id := value.Value()
+
+	if value.Version() != 7 {
+		t.Errorf("expected version 7, got %d", value.Version())
+	}
+	if id[8]>>6 != 0b10 {
+		t.Errorf("expected RFC 9562 variant, got %08b", id[8])
+	}
+
+	millis := int64(id[0])<<40 | int64(id[1])<<32 | int64(id[2])<<24 | int64(id[3])<<16 | int64(id[4])<<8 | int64(id[5])
+	if millis < before || millis > time.Now().UnixMilli()+1 {
+		t.Errorf("expected timestamp around %d, got %d", before, millis)
+	}
+}
+
+func TestUUID_JSON(t *testing.T) {
+	value := NewUUIDv7()
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	decoded := &UUID{}
+	if err := json.Unmarshal(encoded, decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !decoded.Equal(value) {
+		t.Errorf("expected %s, got %s", value, decoded)
+	}
+}
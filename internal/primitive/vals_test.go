@@ -1,18 +1,10 @@
 package primitive
 
 import (
-	"bytes"
 	"testing"
 )
 
-// newStringVal builds a StringValue for the given ASCII string (no 0x00/0x01 bytes).
-func newStringVal(s string) *StringValue {
-	sv := &StringValue{}
-	sv.Parse(append([]byte(s), 0x00))
-	return sv
-}
-
-// ── NullValue ──────────────────────────────────────────────────────────────
+// ── Null ──────────────────────────────────────────────────────────────
 
 func TestNullValue_Type(t *testing.T) {
 	if NewNull().Type() != TYPE_NULL {
@@ -22,7 +14,7 @@ func TestNullValue_Type(t *testing.T) {
 
 func TestNullValue_Empty(t *testing.T) {
 	if !NewNull().Empty() {
-		t.Error("NullValue.Empty() should return true")
+		t.Error("Null.Empty() should return true")
 	}
 }
 
@@ -34,134 +26,56 @@ func TestNullValue_Equal_AnotherNull(t *testing.T) {
 
 func TestNullValue_Equal_DifferentType(t *testing.T) {
 	if NewNull().Equal(NewInt32(0)) {
-		t.Error("NullValue should not Equal a non-null value")
-	}
-}
-
-func TestNullValue_Serialize_IsEmpty(t *testing.T) {
-	if len(NewNull().Serialize()) != 0 {
-		t.Error("NullValue.Serialize() should return empty slice")
-	}
-}
-
-func TestNullValue_Parse_ReturnsZero(t *testing.T) {
-	size := NewNull().Parse([]byte{0x01, 0x02, 0x03})
-	if size != 0 {
-		t.Errorf("NullValue.Parse() should return 0, got %d", size)
+		t.Error("Null should not Equal a non-null value")
 	}
 }
 
 func TestNewNull_ReturnsNullValue(t *testing.T) {
 	v := NewNull()
 	if v == nil || v.Type() != TYPE_NULL {
-		t.Error("NewNull() should return a valid NullValue")
-	}
-}
-
-func TestParseNull_ReturnsNullAndZeroSize(t *testing.T) {
-	v, size := ParseNull([]byte{0xFF})
-	if v == nil || v.Type() != TYPE_NULL {
-		t.Error("ParseNull should return NullValue")
-	}
-	if size != 0 {
-		t.Errorf("ParseNull size should be 0, got %d", size)
+		t.Error("NewNull() should return a valid Null")
 	}
 }
 
-func TestSerializeNull_IsEmpty(t *testing.T) {
-	if len(SerializeNull()) != 0 {
-		t.Error("SerializeNull() should return empty slice")
-	}
-}
-
-// ── StringValue ────────────────────────────────────────────────────────────
+// ── String ────────────────────────────────────────────────────────────
 
 func TestStringValue_Type(t *testing.T) {
-	if newStringVal("x").Type() != TYPE_STRING {
+	if NewString("x").Type() != TYPE_STRING {
 		t.Error("expected TYPE_STRING")
 	}
 }
 
 func TestStringValue_Empty(t *testing.T) {
-	if newStringVal("hello").Empty() {
-		t.Error("StringValue.Empty() should return false")
+	if NewString("hello").Empty() {
+		t.Error("String.Empty() should return false")
 	}
 }
 
 func TestStringValue_Value(t *testing.T) {
-	if newStringVal("hello").Value() != "hello" {
+	if NewString("hello").Value() != "hello" {
 		t.Error("Value() should return the original string")
 	}
 }
 
 func TestStringValue_Equal_SameString(t *testing.T) {
-	if !newStringVal("abc").Equal(newStringVal("abc")) {
+	if !NewString("abc").Equal(NewString("abc")) {
 		t.Error("equal strings should be Equal")
 	}
 }
 
 func TestStringValue_Equal_DifferentString(t *testing.T) {
-	if newStringVal("abc").Equal(newStringVal("xyz")) {
+	if NewString("abc").Equal(NewString("xyz")) {
 		t.Error("different strings should not be Equal")
 	}
 }
 
 func TestStringValue_Equal_DifferentType(t *testing.T) {
-	if newStringVal("1").Equal(NewInt32(1)) {
-		t.Error("StringValue should not Equal a non-string value")
+	if NewString("1").Equal(NewInt32(1)) {
+		t.Error("String should not Equal a non-string value")
 	}
 }
 
-func TestStringValue_SerializeParse_Roundtrip(t *testing.T) {
-	original := newStringVal("hello world")
-	serialized := original.Serialize()
-	parsed := &StringValue{}
-	size := parsed.Parse(serialized)
-	if parsed.Value() != "hello world" {
-		t.Errorf("roundtrip failed: got %q", parsed.Value())
-	}
-	if size != len(serialized) {
-		t.Errorf("Parse size mismatch: got %d, want %d", size, len(serialized))
-	}
-}
-
-func TestStringValue_Serialize_NullTerminated(t *testing.T) {
-	b := newStringVal("hi").Serialize()
-	if b[len(b)-1] != 0x00 {
-		t.Error("serialized string should end with 0x00 terminator")
-	}
-}
-
-func TestStringValue_Serialize_EscapesNullByte(t *testing.T) {
-	// A 0x00 byte in the string must be escaped as 0x01 0x01.
-	sv := &StringValue{str: []byte{0x00}}
-	b := sv.Serialize()
-	if !bytes.HasPrefix(b, []byte{0x01, 0x01}) {
-		t.Errorf("0x00 byte should serialize as 0x01 0x01 prefix, got %v", b)
-	}
-}
-
-func TestStringValue_Serialize_EscapesOneByte(t *testing.T) {
-	// A 0x01 byte in the string must be escaped as 0x01 0x02.
-	sv := &StringValue{str: []byte{0x01}}
-	b := sv.Serialize()
-	if !bytes.HasPrefix(b, []byte{0x01, 0x02}) {
-		t.Errorf("0x01 byte should serialize as 0x01 0x02 prefix, got %v", b)
-	}
-}
-
-func TestStringValue_Parse_EmptyString(t *testing.T) {
-	sv := &StringValue{}
-	size := sv.Parse([]byte{0x00}) // just the null terminator
-	if sv.Value() != "" {
-		t.Errorf("expected empty string, got %q", sv.Value())
-	}
-	if size != 1 {
-		t.Errorf("expected size 1, got %d", size)
-	}
-}
-
-// ── Int32Value ─────────────────────────────────────────────────────────────
+// ── Int32 ─────────────────────────────────────────────────────────────
 
 func TestInt32Value_Type(t *testing.T) {
 	if NewInt32(0).Type() != TYPE_INT32 {
@@ -171,7 +85,7 @@ func TestInt32Value_Type(t *testing.T) {
 
 func TestInt32Value_Empty(t *testing.T) {
 	if NewInt32(0).Empty() {
-		t.Error("Int32Value.Empty() should return false")
+		t.Error("Int32.Empty() should return false")
 	}
 }
 
@@ -199,49 +113,6 @@ func TestInt32Value_Equal_DifferentType(t *testing.T) {
 	}
 }
 
-func TestInt32Value_SerializeParse_Positive(t *testing.T) {
-	parsed, size := ParseInt32(NewInt32(100).Serialize())
-	if parsed.Value() != 100 || size != 4 {
-		t.Errorf("got %d, size %d", parsed.Value(), size)
-	}
-}
-
-func TestInt32Value_SerializeParse_Negative(t *testing.T) {
-	parsed, size := ParseInt32(NewInt32(-100).Serialize())
-	if parsed.Value() != -100 || size != 4 {
-		t.Errorf("got %d, size %d", parsed.Value(), size)
-	}
-}
-
-func TestInt32Value_SerializeParse_Zero(t *testing.T) {
-	parsed, _ := ParseInt32(NewInt32(0).Serialize())
-	if parsed.Value() != 0 {
-		t.Errorf("expected 0, got %d", parsed.Value())
-	}
-}
-
-func TestInt32Value_SerializeParse_MinValue(t *testing.T) {
-	const minInt32 = int32(-2147483648)
-	parsed, _ := ParseInt32(NewInt32(minInt32).Serialize())
-	if parsed.Value() != minInt32 {
-		t.Errorf("expected %d, got %d", minInt32, parsed.Value())
-	}
-}
-
-func TestInt32Value_SerializeParse_MaxValue(t *testing.T) {
-	const maxInt32 = int32(2147483647)
-	parsed, _ := ParseInt32(NewInt32(maxInt32).Serialize())
-	if parsed.Value() != maxInt32 {
-		t.Errorf("expected %d, got %d", maxInt32, parsed.Value())
-	}
-}
-
-func TestInt32Value_Serialize_Size(t *testing.T) {
-	if len(NewInt32(0).Serialize()) != 4 {
-		t.Error("Int32 serialized size should be 4")
-	}
-}
-
 func TestNewInt32_ReturnsCorrectValue(t *testing.T) {
 	v := NewInt32(-7)
 	if v.Value() != -7 {
@@ -249,14 +120,7 @@ func TestNewInt32_ReturnsCorrectValue(t *testing.T) {
 	}
 }
 
-func TestSerializeInt32_Roundtrip(t *testing.T) {
-	parsed, _ := ParseInt32(SerializeInt32(7))
-	if parsed.Value() != 7 {
-		t.Errorf("SerializeInt32 roundtrip failed: got %d", parsed.Value())
-	}
-}
-
-// ── Int64Value ─────────────────────────────────────────────────────────────
+// ── Int64 ─────────────────────────────────────────────────────────────
 
 func TestInt64Value_Type(t *testing.T) {
 	if NewInt64(0).Type() != TYPE_INT64 {
@@ -266,7 +130,7 @@ func TestInt64Value_Type(t *testing.T) {
 
 func TestInt64Value_Empty(t *testing.T) {
 	if NewInt64(0).Empty() {
-		t.Error("Int64Value.Empty() should return false")
+		t.Error("Int64.Empty() should return false")
 	}
 }
 
@@ -294,33 +158,6 @@ func TestInt64Value_Equal_DifferentType(t *testing.T) {
 	}
 }
 
-func TestInt64Value_SerializeParse_Positive(t *testing.T) {
-	parsed, size := ParseInt64(NewInt64(12345678).Serialize())
-	if parsed.Value() != 12345678 || size != 8 {
-		t.Errorf("got %d, size %d", parsed.Value(), size)
-	}
-}
-
-func TestInt64Value_SerializeParse_Negative(t *testing.T) {
-	parsed, size := ParseInt64(NewInt64(-99999).Serialize())
-	if parsed.Value() != -99999 || size != 8 {
-		t.Errorf("got %d, size %d", parsed.Value(), size)
-	}
-}
-
-func TestInt64Value_SerializeParse_Zero(t *testing.T) {
-	parsed, _ := ParseInt64(NewInt64(0).Serialize())
-	if parsed.Value() != 0 {
-		t.Errorf("expected 0, got %d", parsed.Value())
-	}
-}
-
-func TestInt64Value_Serialize_Size(t *testing.T) {
-	if len(NewInt64(0).Serialize()) != 8 {
-		t.Error("Int64 serialized size should be 8")
-	}
-}
-
 func TestNewInt64_ReturnsCorrectValue(t *testing.T) {
 	v := NewInt64(-9999999999)
 	if v.Value() != -9999999999 {
@@ -328,14 +165,7 @@ func TestNewInt64_ReturnsCorrectValue(t *testing.T) {
 	}
 }
 
-func TestSerializeInt64_Roundtrip(t *testing.T) {
-	parsed, _ := ParseInt64(SerializeInt64(-42))
-	if parsed.Value() != -42 {
-		t.Errorf("SerializeInt64 roundtrip failed: got %d", parsed.Value())
-	}
-}
-
-// ── Uint32Value ────────────────────────────────────────────────────────────
+// ── Uint32 ────────────────────────────────────────────────────────────
 
 func TestUint32Value_Type(t *testing.T) {
 	if NewUint32(0).Type() != TYPE_UINT32 {
@@ -345,7 +175,7 @@ func TestUint32Value_Type(t *testing.T) {
 
 func TestUint32Value_Empty(t *testing.T) {
 	if NewUint32(0).Empty() {
-		t.Error("Uint32Value.Empty() should return false")
+		t.Error("Uint32.Empty() should return false")
 	}
 }
 
@@ -373,26 +203,6 @@ func TestUint32Value_Equal_DifferentType(t *testing.T) {
 	}
 }
 
-func TestUint32Value_SerializeParse_Roundtrip(t *testing.T) {
-	parsed, size := ParseUint32(NewUint32(0xDEADBEEF).Serialize())
-	if parsed.Value() != 0xDEADBEEF || size != 4 {
-		t.Errorf("got %d, size %d", parsed.Value(), size)
-	}
-}
-
-func TestUint32Value_SerializeParse_Zero(t *testing.T) {
-	parsed, _ := ParseUint32(NewUint32(0).Serialize())
-	if parsed.Value() != 0 {
-		t.Errorf("expected 0, got %d", parsed.Value())
-	}
-}
-
-func TestUint32Value_Serialize_Size(t *testing.T) {
-	if len(NewUint32(0).Serialize()) != 4 {
-		t.Error("Uint32 serialized size should be 4")
-	}
-}
-
 func TestNewUint32_ReturnsCorrectValue(t *testing.T) {
 	v := NewUint32(100)
 	if v.Value() != 100 {
@@ -400,14 +210,7 @@ func TestNewUint32_ReturnsCorrectValue(t *testing.T) {
 	}
 }
 
-func TestSerializeUint32_Roundtrip(t *testing.T) {
-	parsed, _ := ParseUint32(SerializeUint32(999))
-	if parsed.Value() != 999 {
-		t.Errorf("SerializeUint32 roundtrip failed: got %d", parsed.Value())
-	}
-}
-
-// ── Uint64Value ────────────────────────────────────────────────────────────
+// ── Uint64 ────────────────────────────────────────────────────────────
 
 func TestUint64Value_Type(t *testing.T) {
 	if NewUint64(0).Type() != TYPE_UINT64 {
@@ -417,7 +220,7 @@ func TestUint64Value_Type(t *testing.T) {
 
 func TestUint64Value_Empty(t *testing.T) {
 	if NewUint64(0).Empty() {
-		t.Error("Uint64Value.Empty() should return false")
+		t.Error("Uint64.Empty() should return false")
 	}
 }
 
@@ -445,27 +248,6 @@ func TestUint64Value_Equal_DifferentType(t *testing.T) {
 	}
 }
 
-func TestUint64Value_SerializeParse_Roundtrip(t *testing.T) {
-	const val = uint64(0xDEADBEEFCAFEBABE)
-	parsed, size := ParseUint64(NewUint64(val).Serialize())
-	if parsed.Value() != val || size != 8 {
-		t.Errorf("got %d, size %d", parsed.Value(), size)
-	}
-}
-
-func TestUint64Value_SerializeParse_Zero(t *testing.T) {
-	parsed, _ := ParseUint64(NewUint64(0).Serialize())
-	if parsed.Value() != 0 {
-		t.Errorf("expected 0, got %d", parsed.Value())
-	}
-}
-
-func TestUint64Value_Serialize_Size(t *testing.T) {
-	if len(NewUint64(0).Serialize()) != 8 {
-		t.Error("Uint64 serialized size should be 8")
-	}
-}
-
 func TestNewUint64_ReturnsCorrectValue(t *testing.T) {
 	v := NewUint64(1234567890123)
 	if v.Value() != 1234567890123 {
@@ -473,48 +255,41 @@ func TestNewUint64_ReturnsCorrectValue(t *testing.T) {
 	}
 }
 
-func TestSerializeUint64_Roundtrip(t *testing.T) {
-	parsed, _ := ParseUint64(SerializeUint64(1234567890123))
-	if parsed.Value() != 1234567890123 {
-		t.Errorf("SerializeUint64 roundtrip failed: got %d", parsed.Value())
-	}
-}
-
 // ── New / ParseValue ───────────────────────────────────────────────────────
 
 func TestNew_Null(t *testing.T) {
 	if New(TYPE_NULL).Type() != TYPE_NULL {
-		t.Error("New(TYPE_NULL) should return NullValue")
+		t.Error("New(TYPE_NULL) should return Null")
 	}
 }
 
 func TestNew_String(t *testing.T) {
 	if New(TYPE_STRING).Type() != TYPE_STRING {
-		t.Error("New(TYPE_STRING) should return StringValue")
+		t.Error("New(TYPE_STRING) should return String")
 	}
 }
 
 func TestNew_Int32(t *testing.T) {
 	if New(TYPE_INT32).Type() != TYPE_INT32 {
-		t.Error("New(TYPE_INT32) should return Int32Value")
+		t.Error("New(TYPE_INT32) should return Int32")
 	}
 }
 
 func TestNew_Int64(t *testing.T) {
 	if New(TYPE_INT64).Type() != TYPE_INT64 {
-		t.Error("New(TYPE_INT64) should return Int64Value")
+		t.Error("New(TYPE_INT64) should return Int64")
 	}
 }
 
 func TestNew_Uint32(t *testing.T) {
 	if New(TYPE_UINT32).Type() != TYPE_UINT32 {
-		t.Error("New(TYPE_UINT32) should return Uint32Value")
+		t.Error("New(TYPE_UINT32) should return Uint32")
 	}
 }
 
 func TestNew_Uint64(t *testing.T) {
 	if New(TYPE_UINT64).Type() != TYPE_UINT64 {
-		t.Error("New(TYPE_UINT64) should return Uint64Value")
+		t.Error("New(TYPE_UINT64) should return Uint64")
 	}
 }
 
@@ -524,28 +299,7 @@ func TestNew_UnknownType_Panics(t *testing.T) {
 			t.Error("New with unknown type should panic")
 		}
 	}()
-	New(ValueType(99))
-}
-
-func TestParseValue_Null(t *testing.T) {
-	v, size := ParseValue(TYPE_NULL, []byte{})
-	if v.Type() != TYPE_NULL || size != 0 {
-		t.Errorf("ParseValue(NULL): type=%d size=%d", v.Type(), size)
-	}
-}
-
-func TestParseValue_Int32(t *testing.T) {
-	v, size := ParseValue(TYPE_INT32, SerializeInt32(77))
-	if v.(*Int32Value).Value() != 77 || size != 4 {
-		t.Errorf("ParseValue(INT32): value=%d size=%d", v.(*Int32Value).Value(), size)
-	}
-}
-
-func TestParseValue_Uint64(t *testing.T) {
-	v, size := ParseValue(TYPE_UINT64, SerializeUint64(888))
-	if v.(*Uint64Value).Value() != 888 || size != 8 {
-		t.Errorf("ParseValue(UINT64): value=%d size=%d", v.(*Uint64Value).Value(), size)
-	}
+	New(PrimitiveType(99))
 }
 
 // ── Object ─────────────────────────────────────────────────────────────────
@@ -563,14 +317,14 @@ func TestNewObject_Empty(t *testing.T) {
 func TestObject_Set_Get(t *testing.T) {
 	obj := NewObject()
 	obj.Set("key", NewInt32(42))
-	if obj.Get("key").(*Int32Value).Value() != 42 {
+	if obj.Get("key").(*Int32).Value() != 42 {
 		t.Error("Get should return the value that was Set")
 	}
 }
 
 func TestObject_Get_MissingField_ReturnsNull(t *testing.T) {
 	if NewObject().Get("missing").Type() != TYPE_NULL {
-		t.Error("missing field should return NullValue")
+		t.Error("missing field should return Null")
 	}
 }
 
@@ -596,17 +350,17 @@ func TestObject_GetMany(t *testing.T) {
 	if len(got) != 2 {
 		t.Fatalf("expected 2 values, got %d", len(got))
 	}
-	if got[0].(*Int32Value).Value() != 1 {
+	if got[0].(*Int32).Value() != 1 {
 		t.Error("GetMany()[0] should be 1")
 	}
-	if got[1].(*Int32Value).Value() != 2 {
+	if got[1].(*Int32).Value() != 2 {
 		t.Error("GetMany()[1] should be 2")
 	}
 }
 
 func TestObject_GetString(t *testing.T) {
 	obj := NewObject()
-	obj.Set("s", newStringVal("hello"))
+	obj.Set("s", NewString("hello"))
 	if obj.GetString("s") != "hello" {
 		t.Error("GetString should return the stored string")
 	}
@@ -51,7 +51,7 @@ func (cursor *Cursor) Prev() ([]byte, []byte) {
 		cursor.moveToLeftSiblingNode()
 	}
 
-	return cursor.Next()
+	return cursor.Current()
 }
 
 func (cursor *Cursor) HasNext() bool {
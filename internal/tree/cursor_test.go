@@ -35,24 +35,21 @@ func TestCursor_Prev_AtFirstElement_ReturnsNil(t *testing.T) {
 	}
 }
 
-// Prev() moves the cursor one step left and then one step right via Next(),
-// so the cursor ends up at the original position and returns that position's
-// key-value pair.
-func TestCursor_Prev_WhenHasPrev_ReturnsCurrent(t *testing.T) {
+func TestCursor_Prev_WhenHasPrev_ReturnsPrevious(t *testing.T) {
 	tr := newTestTree()
 	seedTree(t, tr, [][2]string{{"a", "1"}, {"b", "2"}, {"c", "3"}})
 
 	cursor := NewScanner(tr).Seek([]byte("b"), GREATER_OR_EQUAL_COMPARISON)
 	k, v := cursor.Prev()
-	if !bytes.Equal(k, []byte("b")) {
-		t.Errorf("expected key %q from Prev, got %q", "b", k)
+	if !bytes.Equal(k, []byte("a")) {
+		t.Errorf("expected key %q from Prev, got %q", "a", k)
 	}
-	if !bytes.Equal(v, []byte("2")) {
-		t.Errorf("expected value %q from Prev, got %q", "2", v)
+	if !bytes.Equal(v, []byte("1")) {
+		t.Errorf("expected value %q from Prev, got %q", "1", v)
 	}
 }
 
-func TestCursor_Prev_CursorPositionUnchangedAfterCall(t *testing.T) {
+func TestCursor_Prev_MovesCursorPosition(t *testing.T) {
 	tr := newTestTree()
 	seedTree(t, tr, [][2]string{{"a", "1"}, {"b", "2"}, {"c", "3"}})
 
@@ -60,13 +57,13 @@ func TestCursor_Prev_CursorPositionUnchangedAfterCall(t *testing.T) {
 	cursor.Prev()
 
 	k, _ := cursor.Current()
-	if !bytes.Equal(k, []byte("c")) {
-		t.Errorf("expected cursor to remain at %q after Prev, got %q", "c", k)
+	if !bytes.Equal(k, []byte("b")) {
+		t.Errorf("expected cursor to move to %q after Prev, got %q", "b", k)
 	}
 }
 
-// Verify that multiple Prev calls keep the cursor at the same position each time.
-func TestCursor_Prev_MultipleCalls_CursorStaysAtSamePosition(t *testing.T) {
+// Repeated Prev calls stop at the first element.
+func TestCursor_Prev_MultipleCalls_StopsAtFirstElement(t *testing.T) {
 	tr := newTestTree()
 	seedTree(t, tr, [][2]string{{"a", "1"}, {"b", "2"}, {"c", "3"}})
 
@@ -75,8 +72,27 @@ func TestCursor_Prev_MultipleCalls_CursorStaysAtSamePosition(t *testing.T) {
 		cursor.Prev()
 	}
 	k, _ := cursor.Current()
-	if !bytes.Equal(k, []byte("b")) {
-		t.Errorf("expected cursor to remain at %q after repeated Prev, got %q", "b", k)
+	if !bytes.Equal(k, []byte("a")) {
+		t.Errorf("expected cursor to stop at %q after repeated Prev, got %q", "a", k)
+	}
+}
+
+func TestCursor_Prev_TraversesAllKeysAfterSplit(t *testing.T) {
+	tr := newTestTree()
+	const n = 50
+	for i := 1; i <= n; i++ {
+		treeSet(t, tr, fmt.Sprintf("k%03d", i), "v")
+	}
+	cursor := NewScanner(tr).Seek([]byte("k050"), GREATER_OR_EQUAL_COMPARISON)
+	expected := n
+	for k, _ := cursor.Current(); k != nil; k, _ = cursor.Prev() {
+		if want := fmt.Sprintf("k%03d", expected); string(k) != want {
+			t.Fatalf("expected key %q, got %q", want, k)
+		}
+		expected--
+	}
+	if expected != 0 {
+		t.Errorf("expected all %d keys traversed backwards, %d left", n, expected)
 	}
 }
 
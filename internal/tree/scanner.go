@@ -58,6 +58,9 @@ func (scanner *Scanner) seekLessOrEqual(key []byte) *Cursor {
 
 	for parentPointer := tree.root; parentPointer != NULL_NODE; {
 		parent := &Node{data: tree.pager.Page(parentPointer)}
+		if parent.getStoredKeysNumber() == 0 { // Root leaf becomes empty once the last key is deleted
+			break
+		}
 
 		lessOrEqualNodePointer := tree.getLessOrEqualKeyPosition(parent, key)
 
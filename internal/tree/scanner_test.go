@@ -54,6 +54,19 @@ func TestCursor_Empty_False_AfterSeek(t *testing.T) {
 
 // --- Cursor.Current ---
 
+func TestScanner_Seek_AfterDeletingAllKeys_CursorIsEmpty(t *testing.T) {
+	tr := newTestTree()
+	seedTree(t, tr, [][2]string{{"a", "1"}, {"b", "2"}})
+	tr.Delete([]byte("a"))
+	tr.Delete([]byte("b"))
+
+	for _, strategy := range []int{GREATER_OR_EQUAL_COMPARISON, LESS_COMPARISON} {
+		if cursor := NewScanner(tr).Seek([]byte("a"), strategy); !cursor.Empty() {
+			t.Errorf("expected empty cursor for strategy %d on emptied tree", strategy)
+		}
+	}
+}
+
 func TestCursor_Current_NilCursor_ReturnsNil(t *testing.T) {
 	var c *Cursor
 	k, v := c.Current()
@@ -2,8 +2,10 @@ package tree
 
 import (
 	"bytes"
+	"cmp"
 	"distributed-storage/internal/pager"
 	"fmt"
+	"slices"
 )
 
 type TreeConfig struct {
@@ -11,6 +13,20 @@ type TreeConfig struct {
 	MaxKeySize   int
 	MaxValueSize int
 }
+
+// batchLevel is a node on the path of batched lookup, upperBound is nil for the rightmost nodes
+type batchLevel struct {
+	node       *Node
+	upperBound []byte
+}
+
+// batchPage is a page holding keys[from:to] of batched lookup
+type batchPage struct {
+	pointer pager.PagePointer
+	from    int
+	to      int
+}
+
 type Tree struct {
 	root   pager.PagePointer
 	config TreeConfig
@@ -37,6 +53,35 @@ func (tree *Tree) Get(key []byte) ([]byte, error) {
 	return tree.getKeyValue(&Node{data: tree.pager.Page(tree.root)}, key), nil
 }
 
+// GetMany returns values of the keys in the same order, nil for missing keys. Keys must be sorted. Keys are looked up
+// along a single path from the root which is reused while the next key belongs to the same nodes, so every node
+// is read once. With prefetch keys are resolved level by level instead, reading pages of each level in page order.
+func (tree *Tree) GetMany(keys [][]byte, prefetch bool) ([][]byte, error) {
+	for idx, key := range keys {
+		if len(key) > tree.config.MaxKeySize {
+			return nil, fmt.Errorf("Tree: supports only keys within the size %d", tree.config.MaxKeySize)
+		}
+
+		if idx > 0 && bytes.Compare(keys[idx-1], key) > 0 {
+			return nil, fmt.Errorf("Tree: keys of batched lookup must be sorted")
+		}
+	}
+
+	values := make([][]byte, len(keys))
+
+	if tree.root == NULL_NODE || len(keys) == 0 {
+		return values, nil
+	}
+
+	if prefetch {
+		tree.getKeyValuesByLevel(keys, values)
+	} else {
+		tree.getKeyValuesByPath(keys, values)
+	}
+
+	return values, nil
+}
+
 func (tree *Tree) Set(key []byte, value []byte) ([]byte, error) {
 	if len(value) > tree.config.MaxValueSize {
 		return nil, fmt.Errorf("Tree: supports only values within the size %d", tree.config.MaxValueSize)
@@ -99,6 +144,8 @@ func (tree *Tree) Delete(key []byte) ([]byte, error) {
 	if updatedRootNode.getType() == NODE_PARENT && updatedRootNode.getStoredKeysNumber() == 1 {
 		firstChild := updatedRootNode.getChildPointer(NodeKeyPosition(0))
 		tree.root = firstChild
+	} else if updatedRootNode.getStoredKeysNumber() == 0 {
+		tree.root = NULL_NODE
 	} else {
 		tree.root = tree.pager.CreatePage(updatedRootNode.data)
 	}
@@ -106,10 +153,31 @@ func (tree *Tree) Delete(key []byte) ([]byte, error) {
 	return oldValue, nil
 }
 
+// Clear frees every page of the tree and leaves the tree empty
+func (tree *Tree) Clear() {
+	if tree.root != NULL_NODE {
+		tree.freeNode(tree.root)
+	}
+
+	tree.root = NULL_NODE
+}
+
 func (tree *Tree) Root() pager.PagePointer {
 	return tree.root
 }
 
+func (tree *Tree) freeNode(pointer NodePointer) {
+	node := &Node{data: tree.pager.Page(pointer)}
+
+	if node.getType() == NODE_PARENT {
+		for position := range node.getStoredKeysNumber() {
+			tree.freeNode(node.getChildPointer(position))
+		}
+	}
+
+	tree.pager.FreePage(pointer)
+}
+
 func (tree *Tree) getKeyValue(node *Node, key []byte) []byte {
 	if node.getStoredKeysNumber() == 0 {
 		return nil
@@ -138,6 +206,95 @@ func (tree *Tree) getKeyValue(node *Node, key []byte) []byte {
 	}
 }
 
+// getKeyValuesByPath keeps nodes on the path to the last found key with the first key of their right sibling,
+// the next key descends from the deepest node whose sibling starts after it
+func (tree *Tree) getKeyValuesByPath(keys [][]byte, values [][]byte) {
+	path := []*batchLevel{{node: &Node{data: tree.pager.Page(tree.root)}}}
+
+	for idx, key := range keys {
+		for len(path) > 1 && path[len(path)-1].upperBound != nil && bytes.Compare(key, path[len(path)-1].upperBound) >= 0 {
+			path = path[:len(path)-1]
+		}
+
+		level := path[len(path)-1]
+
+		for level.node.getStoredKeysNumber() > 0 {
+			position := tree.getLessOrEqualKeyPosition(level.node, key)
+
+			if level.node.getType() == NODE_LEAF {
+				if bytes.Equal(key, level.node.getKey(position)) {
+					values[idx] = level.node.getValue(position)
+				}
+
+				break
+			}
+
+			child := &batchLevel{
+				node:       &Node{data: tree.pager.Page(level.node.getChildPointer(position))},
+				upperBound: level.upperBound,
+			}
+			if position+1 < level.node.getStoredKeysNumber() {
+				child.upperBound = level.node.getKey(position + 1)
+			}
+
+			path = append(path, child)
+			level = child
+		}
+	}
+}
+
+// getKeyValuesByLevel splits sorted keys into contiguous groups by child nodes of each level, pages of the next
+// level are read in page order, so the storage is accessed sequentially
+func (tree *Tree) getKeyValuesByLevel(keys [][]byte, values [][]byte) {
+	level := []batchPage{{pointer: tree.root, from: 0, to: len(keys)}}
+
+	for len(level) > 0 {
+		slices.SortFunc(level, func(first batchPage, second batchPage) int {
+			return cmp.Compare(first.pointer, second.pointer)
+		})
+
+		nodes := make([]*Node, len(level))
+		for idx, page := range level {
+			nodes[idx] = &Node{data: tree.pager.Page(page.pointer)}
+		}
+
+		var nextLevel []batchPage
+
+		for idx, page := range level {
+			node := nodes[idx]
+			if node.getStoredKeysNumber() == 0 {
+				continue
+			}
+
+			for from := page.from; from < page.to; {
+				position := tree.getLessOrEqualKeyPosition(node, keys[from])
+
+				if node.getType() == NODE_LEAF {
+					if bytes.Equal(keys[from], node.getKey(position)) {
+						values[from] = node.getValue(position)
+					}
+
+					from++
+					continue
+				}
+
+				to := page.to
+				if position+1 < node.getStoredKeysNumber() {
+					upperBound := node.getKey(position + 1)
+
+					for to = from + 1; to < page.to && bytes.Compare(keys[to], upperBound) < 0; to++ {
+					}
+				}
+
+				nextLevel = append(nextLevel, batchPage{pointer: node.getChildPointer(position), from: from, to: to})
+				from = to
+			}
+		}
+
+		level = nextLevel
+	}
+}
+
 func (tree *Tree) setKeyValue(node *Node, key []byte, value []byte) (*Node, []byte) {
 	keyPosition := tree.getLessOrEqualKeyPosition(node, key)
 	switch node.getType() {
@@ -233,6 +233,23 @@ func TestTree_Delete_AllKeys_GetReturnsNil(t *testing.T) {
 	}
 }
 
+func TestTree_Delete_AllKeys_SetStillWorks(t *testing.T) {
+	tr := newTestTree()
+	treeSet(t, tr, "k", "v")
+
+	if _, err := tr.Delete([]byte("k")); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if tr.Root() != NULL_NODE {
+		t.Errorf("expected empty tree after deleting last key, root is %d", tr.Root())
+	}
+
+	treeSet(t, tr, "k2", "v2")
+	if got := treeGet(t, tr, "k2"); !bytes.Equal(got, []byte("v2")) {
+		t.Errorf("expected %q, got %q", "v2", got)
+	}
+}
+
 // --- Multi-key scenarios ---
 
 func TestTree_MultipleKeys_AllRetrievable(t *testing.T) {
@@ -354,3 +371,124 @@ func TestTree_NodeSplit_DeletesAfterSplits(t *testing.T) {
 		t.Errorf("last key %q: expected val, got %q", lastKey, got)
 	}
 }
+
+// --- GetMany ---
+
+type countingStorage struct {
+	store.Storage
+	segments int
+}
+
+func (storage *countingStorage) Segment(offset int, size int) []byte {
+	storage.segments++
+	return storage.Storage.Segment(offset, size)
+}
+
+func newCountingTree(t *testing.T, n int) (*Tree, *countingStorage) {
+	t.Helper()
+	storage := &countingStorage{Storage: store.NewMemoryStorage(treePageSize * 512)}
+	p := pager.NewPager(storage, 1, treePageSize)
+	tr := NewTree(NULL_NODE, p, TreeConfig{PageSize: treePageSize, MaxKeySize: treeMaxKeySize, MaxValueSize: treeMaxValueSize})
+
+	for i := 0; i < n; i++ {
+		treeSet(t, tr, fmt.Sprintf("key%04d", i), fmt.Sprintf("val%04d%s", i, bytes.Repeat([]byte("x"), 200)))
+	}
+	if err := p.SaveChanges(); err != nil {
+		t.Fatalf("SaveChanges: %v", err)
+	}
+
+	storage.segments = 0
+	return tr, storage
+}
+
+func TestTree_GetMany_ReturnsValuesInKeyOrder(t *testing.T) {
+	tr, _ := newCountingTree(t, 500)
+
+	var keys [][]byte
+	for i := 0; i < 520; i += 7 {
+		keys = append(keys, []byte(fmt.Sprintf("key%04d", i)))
+	}
+	keys = append([][]byte{[]byte("a")}, keys...)
+
+	for _, prefetch := range []bool{false, true} {
+		values, err := tr.GetMany(keys, prefetch)
+		if err != nil {
+			t.Fatalf("GetMany(prefetch=%v): %v", prefetch, err)
+		}
+
+		for idx, key := range keys {
+			if want := treeGet(t, tr, string(key)); !bytes.Equal(values[idx], want) {
+				t.Errorf("prefetch=%v: key %q expected %.7q, got %.7q", prefetch, key, want, values[idx])
+			}
+		}
+	}
+}
+
+func TestTree_GetMany_UnsortedKeys_ReturnsError(t *testing.T) {
+	tr := newTestTree()
+	treeSet(t, tr, "a", "1")
+
+	if _, err := tr.GetMany([][]byte{[]byte("b"), []byte("a")}, false); err == nil {
+		t.Error("expected error for unsorted keys")
+	}
+}
+
+func TestTree_GetMany_EmptyTree_ReturnsNils(t *testing.T) {
+	tr := newTestTree()
+
+	values, err := tr.GetMany([][]byte{[]byte("a"), []byte("b")}, true)
+	if err != nil || len(values) != 2 || values[0] != nil || values[1] != nil {
+		t.Errorf("expected two nil values, got %v, %v", values, err)
+	}
+}
+
+func TestTree_GetMany_ReadsEveryPageOnce(t *testing.T) {
+	tr, storage := newCountingTree(t, 500)
+
+	var keys [][]byte
+	for i := 100; i < 300; i++ {
+		keys = append(keys, []byte(fmt.Sprintf("key%04d", i)))
+	}
+
+	for _, key := range keys {
+		treeGet(t, tr, string(key))
+	}
+	pointReads := storage.segments
+
+	for _, prefetch := range []bool{false, true} {
+		storage.segments = 0
+		if _, err := tr.GetMany(keys, prefetch); err != nil {
+			t.Fatalf("GetMany: %v", err)
+		}
+
+		if storage.segments*4 > pointReads {
+			t.Errorf("prefetch=%v: expected far fewer page reads than %d point lookups, got %d", prefetch, pointReads, storage.segments)
+		}
+	}
+}
+
+// --- Clear ---
+
+func TestTree_Clear_FreesEveryPage(t *testing.T) {
+	tr := newTestTree()
+	for i := 0; i < 300; i++ {
+		treeSet(t, tr, fmt.Sprintf("key%04d", i), fmt.Sprintf("val%04d", i))
+	}
+
+	tr.Clear()
+
+	if tr.Root() != pager.NULL_PAGE {
+		t.Errorf("expected NULL_PAGE root after Clear, got %d", tr.Root())
+	}
+	if got := treeGet(t, tr, "key0001"); got != nil {
+		t.Errorf("expected cleared key to be missing, got %q", got)
+	}
+
+	reusable := 0
+	for _, interval := range tr.pager.ReusablePages().Pages() {
+		reusable += int(interval.End - interval.Start + 1)
+	}
+	if allocated := int(tr.pager.PagesCount()) - 1; reusable != allocated {
+		t.Errorf("expected all %d allocated pages to be reusable, got %d", allocated, reusable)
+	}
+}
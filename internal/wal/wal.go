@@ -1,59 +1,86 @@
 package wal
 
 import (
-	"bufio"
 	"distributed-storage/internal/codec"
-	"distributed-storage/internal/events"
-	"distributed-storage/internal/helpers"
-	"io"
+	"distributed-storage/internal/metrics"
+	"encoding/binary"
+	"fmt"
 	"iter"
+	"log/slog"
 	"os"
 	"slices"
 	"sync"
-
-	"fmt"
+	"time"
 )
 
+const METRIC_APPENDED_BYTES = "wal_appended_bytes_total"
+const METRIC_SYNC_DURATION = "wal_sync_duration_seconds"
+const METRIC_ARCHIVED_SEGMENTS = "wal_archived_segments_total"
+
+const ENTRY_HEADER_SIZE = 16 // Index, length and checksum preceding entry data
+
 type WALConfig struct {
 	Directory        string
 	ArchiveDirectory string
-	SegmentSize      int
+	SegmentSize      int // Size after which active segment is moved to the archive directory
+
+	Logger  *slog.Logger    // Logger for failures which can't be returned to the caller, slog.Default() if not set
+	Metrics metrics.Metrics // Receiver of WAL metrics, no-op if not set
+}
+
+// Entry is a record appended to WAL, indexes grow by one starting from 1
+type Entry struct {
+	Index EntryIndex
+	Data  []byte
 }
 
+// WAL is an append-only log split into segments. Entries are appended to the active segment, which is moved to the
+// archive directory once it grows over SegmentSize.
 type WAL struct {
 	segment         *os.File
-	activeSegment   Segment
-	archiveSegments []Segment
+	segmentID       SegmentID
+	segmentCapacity int // Bytes written to the active segment
+	segmentSize     int
 
 	lastEntryIndex EntryIndex
 
 	directory        string
 	archiveDirectory string
 
-	pendingArchive bool
+	logger  *slog.Logger
+	metrics metrics.Metrics
 
-	mu sync.RWMutex
+	mu sync.Mutex
 }
 
-func newWAL(config WALConfig) (*WAL, error) {
+func NewWAL(config WALConfig) (*WAL, error) {
 	wal := &WAL{
 		segmentSize: config.SegmentSize,
 
 		directory:        config.Directory,
 		archiveDirectory: config.ArchiveDirectory,
 
-		pendingArchive: false,
+		logger:  config.Logger,
+		metrics: config.Metrics,
+	}
+
+	if wal.logger == nil {
+		wal.logger = slog.Default()
+	}
+
+	if wal.metrics == nil {
+		wal.metrics = metrics.NewNoop()
 	}
 
 	var err error
 	var segmentFound bool
 
-	if wal.segmentID, segmentFound, err = wal.latestSegmentID(wal.directory); err != nil { // We will reuse the latest active segment if it exists
+	if wal.segmentID, segmentFound, err = wal.lastSegmentID(wal.directory); err != nil { // We will reuse the latest active segment if it exists
 		return nil, fmt.Errorf("WAL: failed to find existing segment: %w", err)
 	}
 
 	if !segmentFound { // If there is no existing active segment, check for the latest archived
-		if wal.segmentID, segmentFound, err = wal.latestSegmentID(wal.archiveDirectory); err != nil {
+		if wal.segmentID, segmentFound, err = wal.lastSegmentID(wal.archiveDirectory); err != nil {
 			return nil, fmt.Errorf("WAL: failed to find existing segment in archive directory: %w", err)
 		}
 
@@ -62,6 +89,10 @@ func newWAL(config WALConfig) (*WAL, error) {
 		}
 	}
 
+	if err = wal.recoverActiveSegment(); err != nil {
+		return nil, err
+	}
+
 	if wal.segment, wal.segmentCapacity, err = wal.openSegment(wal.segmentID, wal.directory); err != nil {
 		return nil, fmt.Errorf("WAL: failed to open existing segment file: %w", err)
 	}
@@ -69,80 +100,80 @@ func newWAL(config WALConfig) (*WAL, error) {
 	return wal, nil
 }
 
-func (wal *WAL) Append(data []byte) (EntryIndex, error) {
+// Append writes entries to the active segment and returns index of the last one. Entries are durable after Sync.
+func (wal *WAL) Append(entries ...[]byte) (EntryIndex, error) {
 	wal.mu.Lock()
 	defer wal.mu.Unlock()
 
-	entry := codec.EncodeWALEntry(uint64(wal.lastEntryIndex+1), data)
+	var data []byte
+	for idx, entry := range entries {
+		data = append(data, codec.EncodeWALEntry(uint64(wal.lastEntryIndex)+uint64(idx)+1, entry)...)
+	}
 
-	if _, err := wal.segment.Write(entry); err != nil {
-		return 0, fmt.Errorf("WAL: failed to append entry: %w", err)
+	if _, err := wal.segment.Write(data); err != nil {
+		return 0, fmt.Errorf("WAL: failed to append entries: %w", err)
 	}
 
-	wal.lastEntryIndex++
-	wal.segmentCapacity += len(entry)
+	wal.lastEntryIndex += EntryIndex(len(entries))
+	wal.segmentCapacity += len(data)
+	wal.metrics.AddCounter(METRIC_APPENDED_BYTES, float64(len(data)))
 
 	return wal.lastEntryIndex, nil
 }
 
-func (wal *WAL) Scan(since EntryIndex) iter.Seq[Entry] {
-	wal.mu.Lock()         // Lock the WAL to prevent concurrent archiving current segment and closing files while reading changes.
-	defer wal.mu.Unlock() // This method is called during db initialization, so no need to worry about performance implications of locking here.
-
-	segment := wal.segment
-	segmentID := wal.segmentID
+// Scan iterates over entries with index greater than since, from archived segments to the active one
+func (wal *WAL) Scan(since EntryIndex) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		wal.mu.Lock()
+		defer wal.mu.Unlock()
 
-	var changes [][]TableEvent
-	changesFound := false
-
-	for {
-		var segmentChanges []TableEvent
-
-		for event := range wal.scanSegment(segment) {
-			segmentChanges = append(segmentChanges, event)
-
-			if versionEvent, ok := event.(*events.UpdateDBVersion); ok && versionEvent.Version == uint64(version) {
-				changesFound = true
-				segmentChanges = nil // Clear changes collected so far as they are from a previous version
-			}
+		archived, err := wal.segmentIDs(wal.archiveDirectory)
+		if err != nil {
+			yield(Entry{}, err)
+			return
 		}
 
-		changes = append(changes, segmentChanges)
-
-		if changesFound || segmentID == INITIAL_SEGMENT_ID { // If changes for the requested version are found or we have reached the initial segment, stop scanning further
-			break
+		names := make([]string, 0, len(archived)+1)
+		for _, segmentID := range archived {
+			names = append(names, wal.segmentName(wal.archiveDirectory, segmentID))
 		}
+		names = append(names, wal.segmentName(wal.directory, wal.segmentID))
 
-		nextSegment, _, err := wal.openSegment(segmentID-1, wal.archiveDirectory)
-		if err != nil {
-			return nil, fmt.Errorf("WAL: failed to open previous segment file: %w", err)
-		}
+		for _, name := range names {
+			stopped := false
 
-		defer nextSegment.Close()
+			_, err := wal.scanSegment(name, func(entry Entry) bool {
+				if entry.Index <= since {
+					return true
+				}
 
-		segment = nextSegment
-		segmentID -= 1
-	}
+				stopped = !yield(entry, nil)
+				return !stopped
+			})
 
-	if !changesFound {
-		return nil, fmt.Errorf("WAL: no changes found for version %d", version)
+			if stopped {
+				return
+			}
+			if err != nil {
+				yield(Entry{}, err)
+				return
+			}
+		}
 	}
-
-	slices.Reverse(changes)
-
-	return helpers.Flatten(changes), nil
 }
 
 func (wal *WAL) Sync() error {
 	wal.mu.Lock()
+	defer wal.mu.Unlock()
 
+	startedAt := time.Now()
 	if err := wal.segment.Sync(); err != nil {
-		return fmt.Errorf("WAL: failed to sync WAL segment %w", err)
+		return fmt.Errorf("WAL: failed to sync WAL segment: %w", err)
 	}
+	wal.metrics.ObserveHistogram(METRIC_SYNC_DURATION, time.Since(startedAt).Seconds())
 
-	if !wal.pendingArchive && wal.segmentFull(wal.segmentCapacity) {
-		wal.pendingArchive = true
-		go wal.archiveSegment()
+	if wal.segmentFull(wal.segmentCapacity) {
+		return wal.archiveSegment()
 	}
 
 	return nil
@@ -166,31 +197,130 @@ func (wal *WAL) Close() error {
 	return nil
 }
 
-func (wal *WAL) lastIndex() (EntryIndex, error) {
-	var lastIndex EntryIndex
+// recoverActiveSegment restores index of the last entry and cuts off entry partially written before crash, so new
+// entries aren't appended after it
+func (wal *WAL) recoverActiveSegment() error {
+	name := wal.segmentName(wal.directory, wal.segmentID)
+
+	size, err := wal.scanSegment(name, func(entry Entry) bool {
+		wal.lastEntryIndex = entry.Index
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	if stat, err := os.Stat(name); err == nil && stat.Size() > int64(size) {
+		wal.logger.Warn("WAL: discarding partially written entry", "segment", wal.segmentID, "bytes", stat.Size()-int64(size))
+
+		if err := os.Truncate(name, int64(size)); err != nil {
+			return fmt.Errorf("WAL: failed to truncate partially written entry: %w", err)
+		}
+	}
+
+	if wal.lastEntryIndex > INITIAL_LAST_ENTRY_INDEX || wal.segmentID == INITIAL_SEGMENT_ID {
+		return nil
+	}
+
+	// Active segment is empty, so entry indexes continue from the last archived segment
+	_, err = wal.scanSegment(wal.segmentName(wal.archiveDirectory, wal.segmentID-1), func(entry Entry) bool {
+		wal.lastEntryIndex = entry.Index
+		return true
+	})
+
+	return err
+}
+
+// scanSegment visits entries of the segment file and returns size of its complete entries. Missing file has no
+// entries, incomplete entry at the end of the file is ignored.
+func (wal *WAL) scanSegment(name string, visit func(entry Entry) bool) (int, error) {
+	data, err := os.ReadFile(name)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("WAL: failed to read segment file: %w", err)
+	}
+
+	offset := 0
+	for len(data)-offset >= ENTRY_HEADER_SIZE {
+		length := int(binary.LittleEndian.Uint32(data[offset+8 : offset+12]))
+		if len(data)-offset < ENTRY_HEADER_SIZE+length {
+			break
+		}
+
+		index, entry, size, err := codec.DecodeWALEntry(data[offset:])
+		if err != nil {
+			return offset, fmt.Errorf("WAL: failed to decode entry of segment %s at offset %d: %w", name, offset, err)
+		}
+
+		offset += size
+
+		if !visit(Entry{Index: EntryIndex(index), Data: entry}) {
+			break
+		}
+	}
+
+	return offset, nil
+}
+
+// archiveSegment moves the full active segment to the archive directory and starts a new one
+func (wal *WAL) archiveSegment() error {
+	archivedSegment := wal.segment
+	archivedSegmentID := wal.segmentID
+
+	segment, capacity, err := wal.openSegment(wal.segmentID+1, wal.directory)
+	if err != nil {
+		return fmt.Errorf("WAL: failed to archive active segment: %w", err)
+	}
+
+	if err := os.Rename(archivedSegment.Name(), wal.segmentName(wal.archiveDirectory, archivedSegmentID)); err != nil {
+		segment.Close()
+		os.Remove(segment.Name())
+		return fmt.Errorf("WAL: failed to move archived segment to archive directory: %w", err)
+	}
 
-	for event := range wal.scanSegment(wal.segment) {
+	wal.segment = segment
+	wal.segmentID++
+	wal.segmentCapacity = capacity
 
+	if err := archivedSegment.Close(); err != nil {
+		wal.logger.Error("WAL: failed to close archived segment", "segment", archivedSegmentID, "error", err)
 	}
+
+	wal.metrics.AddCounter(METRIC_ARCHIVED_SEGMENTS, 1)
+
+	return nil
 }
 
 func (wal *WAL) lastSegmentID(directory string) (SegmentID, bool, error) {
-	segmentID := INITIAL_SEGMENT_ID
+	segmentIDs, err := wal.segmentIDs(directory)
+	if err != nil || len(segmentIDs) == 0 {
+		return INITIAL_SEGMENT_ID, false, err
+	}
+
+	return segmentIDs[len(segmentIDs)-1], true, nil
+}
 
+// segmentIDs returns ids of segments in the directory in ascending order
+func (wal *WAL) segmentIDs(directory string) ([]SegmentID, error) {
 	entries, err := os.ReadDir(directory)
 	if err != nil {
-		return segmentID, false, fmt.Errorf("WAL: failed to read directory: %w", err)
+		return nil, fmt.Errorf("WAL: failed to read directory: %w", err)
 	}
 
-	for idx := len(entries) - 1; idx >= 0; idx-- {
-		entry := entries[idx]
+	var segmentIDs []SegmentID
+	for _, entry := range entries {
+		var segmentID SegmentID
 
 		if parsed, _ := fmt.Sscanf(entry.Name(), SEGMENT_NAME_FORMAT, &segmentID); parsed == 1 {
-			return segmentID, true, nil
+			segmentIDs = append(segmentIDs, segmentID)
 		}
 	}
 
-	return segmentID, false, nil
+	slices.Sort(segmentIDs)
+
+	return segmentIDs, nil
 }
 
 func (wal *WAL) openSegment(segmentID SegmentID, directory string) (segment *os.File, capacity int, err error) {
@@ -217,74 +347,6 @@ func (wal *WAL) openSegment(segmentID SegmentID, directory string) (segment *os.
 	return
 }
 
-func (wal *WAL) scanSegment(segment *os.File) iter.Seq[EntryIndex, []byte] {
-	return func(yield func(Entry) bool) {
-		const chunkSize = 4096 // Read the segment in chunks to avoid loading the entire file into memory
-
-		reader := bufio.NewReaderSize(segment, chunkSize)
-
-		accumulator := make([]byte, 0, 2*chunkSize)
-		chunk := make([]byte, chunkSize)
-
-		for {
-			readBytes, err := reader.Read(chunk)
-			accumulator = append(accumulator, chunk[:readBytes]...)
-
-			for len(accumulator) > 0 {
-				data, index, consumed, err := codec.DecodeWALEntry(accumulator)
-
-				if err != nil {
-					fmt.Printf("WAL: failed to decode event: %v\n", err)
-					return
-				}
-
-				if consumed == 0 {
-					break // Need to read more data to decode a full event
-				}
-
-				accumulator = accumulator[consumed:]
-
-				if !yield(event) {
-					return
-				}
-			}
-
-			if err == io.EOF {
-				return
-			}
-		}
-	}
-}
-
-func (wal *WAL) archiveSegment() error {
-	archivedSegment := wal.segment
-	archivedSegmentID := wal.segmentID
-	newSegmentID := wal.segmentID + 1
-
-	segment, capacity, err := wal.openSegment(newSegmentID, wal.directory)
-
-	if err != nil {
-		return fmt.Errorf("WAL: failed to archive active segment: %w", err)
-	}
-
-	wal.mu.Lock()
-	wal.segment = segment
-	wal.segmentID = newSegmentID
-	wal.segmentCapacity = capacity
-	wal.pendingArchive = false
-	wal.mu.Unlock()
-
-	if err := os.Rename(archivedSegment.Name(), wal.segmentName(wal.archiveDirectory, archivedSegmentID)); err != nil {
-		fmt.Printf("WAL: failed to move archived segment to archive directory: %v\n", err)
-	}
-
-	if err := archivedSegment.Close(); err != nil {
-		fmt.Printf("WAL: failed to close archived segment: %v\n", err)
-	}
-
-	return nil
-}
-
 func (wal *WAL) segmentName(directory string, segmentID SegmentID) string {
 	return fmt.Sprintf("%s/"+SEGMENT_NAME_FORMAT, directory, segmentID)
 }
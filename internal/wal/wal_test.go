@@ -1,153 +1,158 @@
 package wal
 
 import (
-	"distributed-storage/internal/events"
+	"os"
 	"path/filepath"
 	"testing"
 )
 
-func newTestWAL(t *testing.T) *WAL {
+func newTestWALConfig(t *testing.T) WALConfig {
 	t.Helper()
 	dir := t.TempDir()
-	walDir := filepath.Join(dir, "wal")
-	archiveDir := filepath.Join(walDir, "archive")
-
-	if err := setupFS(DatabaseConfig{
-		Directory:           dir,
-		WALDirectory:        walDir,
-		WALArchiveDirectory: archiveDir,
-	}); err != nil {
-		t.Fatalf("setupFS failed: %v", err)
-	}
-
-	wal, err := newWAL(DatabaseConfig{
-		WALDirectory:        walDir,
-		WALArchiveDirectory: archiveDir,
-		WALSegmentSize:      1 * 1024 * 1024,
-	})
+	config := WALConfig{
+		Directory:        filepath.Join(dir, "wal"),
+		ArchiveDirectory: filepath.Join(dir, "wal", "archive"),
+		SegmentSize:      1 * 1024 * 1024,
+	}
+
+	if err := os.MkdirAll(config.ArchiveDirectory, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	return config
+}
+
+func newTestWAL(t *testing.T, config WALConfig) *WAL {
+	t.Helper()
+	wal, err := NewWAL(config)
 	if err != nil {
-		t.Fatalf("newWAL failed: %v", err)
+		t.Fatalf("NewWAL failed: %v", err)
 	}
+	t.Cleanup(func() { wal.Close() })
 	return wal
 }
 
+func scanEntries(t *testing.T, wal *WAL, since EntryIndex) []Entry {
+	t.Helper()
+	var entries []Entry
+	for entry, err := range wal.Scan(since) {
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
 func TestNewWAL_CreatesSegmentFile(t *testing.T) {
-	wal := newTestWAL(t)
+	wal := newTestWAL(t, newTestWALConfig(t))
 	if wal.segment == nil {
 		t.Error("expected non-nil segment file")
 	}
-}
-
-func TestNewWAL_InitialSegmentID(t *testing.T) {
-	wal := newTestWAL(t)
 	if wal.segmentID != INITIAL_SEGMENT_ID {
 		t.Errorf("expected segmentID=%d, got %d", INITIAL_SEGMENT_ID, wal.segmentID)
 	}
 }
 
-func TestWAL_Empty_NewWALIsEmpty(t *testing.T) {
-	wal := newTestWAL(t)
-	if !wal.empty() {
+func TestWAL_Empty_FalseAfterAppend(t *testing.T) {
+	wal := newTestWAL(t, newTestWALConfig(t))
+	if !wal.Empty() {
 		t.Error("expected new WAL to be empty")
 	}
-}
 
-func TestWAL_Empty_FalseAfterSync(t *testing.T) {
-	wal := newTestWAL(t)
-	wal.appendVersionUpdate(DatabaseVersion(1))
-	if err := wal.sync(); err != nil {
-		t.Fatalf("sync failed: %v", err)
+	wal.Append([]byte("entry"))
+	if err := wal.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
 	}
-	if wal.empty() {
-		t.Error("WAL should not be empty after writing and syncing events")
+	if wal.Empty() {
+		t.Error("WAL should not be empty after appending entries")
 	}
 }
 
-func TestWAL_Sync_ClearsPendingLog(t *testing.T) {
-	wal := newTestWAL(t)
-	wal.appendVersionUpdate(DatabaseVersion(1))
-	if err := wal.sync(); err != nil {
-		t.Fatalf("sync failed: %v", err)
+func TestWAL_Append_ReturnsLastIndex(t *testing.T) {
+	wal := newTestWAL(t, newTestWALConfig(t))
+
+	if index, _ := wal.Append([]byte("a"), []byte("b")); index != 2 {
+		t.Errorf("expected last index 2, got %d", index)
 	}
-	if len(wal.pendingLog) != 0 {
-		t.Errorf("expected pendingLog to be cleared after sync, got %d bytes", len(wal.pendingLog))
+	if index, _ := wal.Append([]byte("c")); index != 3 {
+		t.Errorf("expected last index 3, got %d", index)
 	}
 }
 
-func TestWAL_Sync_UpdatesSegmentCapacity(t *testing.T) {
-	wal := newTestWAL(t)
-	wal.appendVersionUpdate(DatabaseVersion(1))
-	if err := wal.sync(); err != nil {
-		t.Fatalf("sync failed: %v", err)
-	}
-	if wal.segmentCapacity == 0 {
-		t.Error("expected segmentCapacity > 0 after sync")
+func TestWAL_Scan_SinceIndex(t *testing.T) {
+	wal := newTestWAL(t, newTestWALConfig(t))
+	wal.Append([]byte("a"), []byte("b"), []byte("c"))
+
+	entries := scanEntries(t, wal, 1)
+	if len(entries) != 2 || string(entries[0].Data) != "b" || entries[1].Index != 3 {
+		t.Errorf("expected entries 2 and 3, got %v", entries)
 	}
 }
 
-func TestWAL_EncodeDecodeEvent_RoundTrip(t *testing.T) {
-	wal := newTestWAL(t)
-	original := events.NewUpdateDBVersion(42)
+func TestWAL_Reopen_ContinuesIndexes(t *testing.T) {
+	config := newTestWALConfig(t)
+	wal := newTestWAL(t, config)
+	wal.Append([]byte("a"), []byte("b"))
+	wal.Sync()
+	wal.Close()
 
-	encoded := wal.encodeEvent(original)
-	event, consumed, err := wal.decodeEvent(encoded)
-	if err != nil {
-		t.Fatalf("decodeEvent failed: %v", err)
-	}
-	if consumed != len(encoded) {
-		t.Errorf("expected consumed=%d, got %d", len(encoded), consumed)
-	}
-	if event == nil {
-		t.Fatal("expected non-nil event")
+	reopened := newTestWAL(t, config)
+	if index, _ := reopened.Append([]byte("c")); index != 3 {
+		t.Errorf("expected index to continue from 3, got %d", index)
 	}
-	versionEvent, ok := event.(*events.UpdateDBVersion)
-	if !ok {
-		t.Fatalf("expected *events.UpdateDBVersion, got %T", event)
-	}
-	if versionEvent.Version != 42 {
-		t.Errorf("expected version=42, got %d", versionEvent.Version)
+	if entries := scanEntries(t, reopened, 0); len(entries) != 3 {
+		t.Errorf("expected 3 entries after reopen, got %d", len(entries))
 	}
 }
 
-func TestWAL_DecodeEvent_TooShort_ReturnsNil(t *testing.T) {
-	wal := newTestWAL(t)
-	event, consumed, err := wal.decodeEvent([]byte{1, 2, 3}) // fewer than 8 bytes
-	if err != nil || event != nil || consumed != 0 {
-		t.Errorf("expected nil event/error for short row: event=%v consumed=%d err=%v", event, consumed, err)
-	}
-}
+func TestWAL_Reopen_DiscardsPartialEntry(t *testing.T) {
+	config := newTestWALConfig(t)
+	wal := newTestWAL(t, config)
+	wal.Append([]byte("a"))
+	wal.Sync()
+	wal.segment.Write([]byte{2, 0, 0}) // Entry header cut by crash
+	wal.Close()
 
-func TestWAL_DecodeEvent_CorruptChecksum_ReturnsError(t *testing.T) {
-	wal := newTestWAL(t)
-	encoded := wal.encodeEvent(events.NewUpdateDBVersion(1))
-	// Corrupt the checksum bytes (bytes 4-7)
-	encoded[4] ^= 0xFF
-	_, _, err := wal.decodeEvent(encoded)
-	if err == nil {
-		t.Error("expected error for corrupted checksum")
+	reopened := newTestWAL(t, config)
+	reopened.Append([]byte("b"))
+	entries := scanEntries(t, reopened, 0)
+	if len(entries) != 2 || string(entries[1].Data) != "b" || entries[1].Index != 2 {
+		t.Errorf("expected partial entry to be replaced by the next one, got %v", entries)
 	}
 }
 
-func TestWAL_AppendTransactions_Empty_NoOp(t *testing.T) {
-	wal := newTestWAL(t)
-	before := len(wal.pendingLog)
-	wal.appendTransactions([]TransactionCommit{})
-	if len(wal.pendingLog) != before {
-		t.Error("appendTransactions with empty slice should not modify pendingLog")
+func TestWAL_Sync_ArchivesFullSegment(t *testing.T) {
+	config := newTestWALConfig(t)
+	config.SegmentSize = 32
+	wal := newTestWAL(t, config)
+
+	wal.Append([]byte("first entry over segment size"))
+	if err := wal.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
 	}
-}
+	if wal.segmentID != INITIAL_SEGMENT_ID+1 || wal.segmentCapacity != 0 {
+		t.Errorf("expected new active segment after archiving, got id=%d capacity=%d", wal.segmentID, wal.segmentCapacity)
+	}
+	if _, err := os.Stat(wal.segmentName(config.ArchiveDirectory, INITIAL_SEGMENT_ID)); err != nil {
+		t.Errorf("expected archived segment file: %v", err)
+	}
+
+	wal.Append([]byte("second"))
+	wal.Sync()
+	wal.Close()
 
-func TestWAL_AppendVersionUpdate_AddsToLog(t *testing.T) {
-	wal := newTestWAL(t)
-	before := len(wal.pendingLog)
-	wal.appendVersionUpdate(DatabaseVersion(5))
-	if len(wal.pendingLog) <= before {
-		t.Error("expected pendingLog to grow after appendVersionUpdate")
+	reopened := newTestWAL(t, config)
+	entries := scanEntries(t, reopened, 0)
+	if len(entries) != 2 || string(entries[1].Data) != "second" {
+		t.Errorf("expected entries of archived and active segments, got %v", entries)
+	}
+	if index, _ := reopened.Append([]byte("third")); index != 3 {
+		t.Errorf("expected index to continue from archived segments, got %d", index)
 	}
 }
 
 func TestWAL_SegmentName_Format(t *testing.T) {
-	wal := newTestWAL(t)
+	wal := newTestWAL(t, newTestWALConfig(t))
 	name := wal.segmentName("/some/dir", SegmentID(1))
 	expected := "/some/dir/" + "segment_0000000001.wal"
 	if name != expected {